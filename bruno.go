@@ -0,0 +1,299 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+/*
+	###################################### BRUNO EXPORT ################################################################
+*/
+
+// unsafeFileCharRegex matches characters that are not allowed in file names on common file systems
+var unsafeFileCharRegex = regexp.MustCompile(`[<>:"/\\|?*\x00-\x1f]+`)
+
+// BrunoConfig is the bruno.json file that marks a directory as a Bruno collection
+type BrunoConfig struct {
+	Version string   `json:"version"`
+	Name    string   `json:"name"`
+	Type    string   `json:"type"`
+	Ignore  []string `json:"ignore"`
+}
+
+// WriteBruno writes a collection as a Bruno collection directory and returns the number of request files
+//
+// Folders become directories with a folder.bru, each request becomes a .bru file in collection order, and
+// environments are written to the environments directory with secret values left for Bruno to store locally.
+func WriteBruno(dir string, collection PostmanCollection, environments []PostmanEnvironment) (int, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return 0, fmt.Errorf("error creating Bruno directory: %v", err)
+	}
+
+	config, err := json.MarshalIndent(BrunoConfig{Version: "1", Name: collection.Info.Name, Type: "collection", Ignore: []string{"node_modules", ".git"}}, "", "  ")
+	if err != nil {
+		return 0, fmt.Errorf("error marshaling bruno.json: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "bruno.json"), append(config, '\n'), 0644); err != nil {
+		return 0, fmt.Errorf("error writing bruno.json: %v", err)
+	}
+
+	// Collection variables with a value are defaults that environments override
+	var defaults []string
+	for _, variable := range collection.Variable {
+		if variable.Value != "" && variable.Type != "secret" {
+			defaults = append(defaults, variable.Key+": "+variable.Value)
+		}
+	}
+	var out strings.Builder
+	brunoBlock(&out, "vars:pre-request", defaults)
+	brunoTextBlock(&out, "docs", collection.Info.Description)
+	if out.Len() > 0 {
+		if err := os.WriteFile(filepath.Join(dir, "collection.bru"), []byte(strings.TrimSuffix(out.String(), "\n")), 0644); err != nil {
+			return 0, fmt.Errorf("error writing collection.bru: %v", err)
+		}
+	}
+
+	if len(environments) > 0 {
+		envDir := filepath.Join(dir, "environments")
+		if err := os.MkdirAll(envDir, 0755); err != nil {
+			return 0, fmt.Errorf("error creating Bruno environments directory: %v", err)
+		}
+		for _, environment := range environments {
+			fileName := filepath.Join(envDir, SafeFileName(environment.Name)+".bru")
+			if err := os.WriteFile(fileName, []byte(brunoEnvironment(environment)), 0644); err != nil {
+				return 0, fmt.Errorf("error writing Bruno environment %s: %v", environment.Name, err)
+			}
+		}
+	}
+
+	return writeBrunoItems(dir, collection.Item)
+}
+
+// writeBrunoItems writes the requests and folders of one level of the collection
+func writeBrunoItems(dir string, items []PostmanItem) (int, error) {
+	written := 0
+	used := map[string]bool{"collection": true, "folder": true}
+	for i, item := range items {
+		name := uniqueFileName(SafeFileName(item.Name), used)
+		if !item.IsFolder() {
+			if err := os.WriteFile(filepath.Join(dir, name+".bru"), []byte(brunoRequest(item, i+1)), 0644); err != nil {
+				return written, fmt.Errorf("error writing Bruno request %s: %v", item.Name, err)
+			}
+			written++
+			continue
+		}
+
+		folderDir := filepath.Join(dir, name)
+		if err := os.MkdirAll(folderDir, 0755); err != nil {
+			return written, fmt.Errorf("error creating Bruno folder %s: %v", item.Name, err)
+		}
+		var out strings.Builder
+		brunoBlock(&out, "meta", []string{"name: " + item.Name, fmt.Sprintf("seq: %d", i+1)})
+		brunoTextBlock(&out, "docs", item.Description)
+		if err := os.WriteFile(filepath.Join(folderDir, "folder.bru"), []byte(strings.TrimSuffix(out.String(), "\n")), 0644); err != nil {
+			return written, fmt.Errorf("error writing Bruno folder %s: %v", item.Name, err)
+		}
+		count, err := writeBrunoItems(folderDir, item.Item)
+		written += count
+		if err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
+
+// brunoRequest renders a request as a .bru file
+func brunoRequest(item PostmanItem, seq int) string {
+	req := item.Request
+	var out strings.Builder
+	brunoBlock(&out, "meta", []string{"name: " + item.Name, "type: http", fmt.Sprintf("seq: %d", seq)})
+
+	rawURL := req.URL.Raw
+	if rawURL == "" {
+		rawURL = BuildRawURL(req.URL)
+	}
+
+	// Auth blocks replace any recorded Authorization header
+	authMode, authLines := "none", []string(nil)
+	if req.Auth != nil {
+		switch req.Auth.Type {
+		case "bearer":
+			authMode, authLines = "bearer", []string{"token: " + authValue(req.Auth, "token")}
+		case "basic":
+			if username, password, ok := basicCredentials(req.Auth); ok {
+				authMode, authLines = "basic", []string{"username: " + username, "password: " + password}
+			}
+		}
+	}
+
+	bodyMode, bodyBlock, bodyLines := "none", "", []string(nil)
+	switch {
+	case len(req.Body.Formdata) > 0:
+		bodyMode, bodyBlock = "multipartForm", "body:multipart-form"
+		for _, field := range req.Body.Formdata {
+			value := field.Value
+			if field.Type == "file" {
+				value = "@file(" + field.Src + ")"
+			}
+			bodyLines = append(bodyLines, brunoEntry(field.Key, value, field.Disabled))
+		}
+	case len(req.Body.Urlencoded) > 0:
+		bodyMode, bodyBlock = "formUrlEncoded", "body:form-urlencoded"
+		for _, field := range req.Body.Urlencoded {
+			bodyLines = append(bodyLines, brunoEntry(field.Key, field.Value, field.Disabled))
+		}
+	case req.Body.Raw != "":
+		bodyMode = "text"
+		for _, header := range req.Header {
+			if strings.EqualFold(header.Key, "Content-Type") && !header.Disabled {
+				switch BodyLanguage(header.Value) {
+				case "json":
+					bodyMode = "json"
+				case "xml":
+					bodyMode = "xml"
+				}
+			}
+		}
+		bodyBlock = "body:" + bodyMode
+	}
+
+	brunoBlock(&out, strings.ToLower(req.Method), []string{"url: " + rawURL, "body: " + bodyMode, "auth: " + authMode})
+
+	var query, pathParams, headers []string
+	for _, param := range req.URL.Query {
+		query = append(query, param.Key+": "+param.Value)
+	}
+	for _, variable := range req.URL.Variable {
+		pathParams = append(pathParams, variable.Key+": "+variable.Value)
+	}
+	for _, header := range req.Header {
+		if authMode != "none" && strings.EqualFold(header.Key, "Authorization") {
+			continue
+		}
+		if bodyMode == "multipartForm" && strings.EqualFold(header.Key, "Content-Type") {
+			// Bruno sets the multipart boundary itself
+			continue
+		}
+		headers = append(headers, brunoEntry(header.Key, header.Value, header.Disabled))
+	}
+	brunoBlock(&out, "params:query", query)
+	brunoBlock(&out, "params:path", pathParams)
+	brunoBlock(&out, "headers", headers)
+	if authMode != "none" {
+		brunoBlock(&out, "auth:"+authMode, authLines)
+	}
+
+	if bodyLines != nil {
+		brunoBlock(&out, bodyBlock, bodyLines)
+	} else if bodyBlock != "" {
+		brunoTextBlock(&out, bodyBlock, req.Body.Raw)
+	}
+
+	// Values later requests reuse are stored as runtime variables after the response arrives
+	var extract []string
+	for _, rule := range ItemCorrelationRules(item) {
+		extract = append(extract, brunoExtractScript(rule))
+	}
+	brunoTextBlock(&out, "script:post-response", strings.Join(extract, "\n"))
+	brunoTextBlock(&out, "docs", item.Description)
+
+	return strings.TrimSuffix(out.String(), "\n")
+}
+
+// brunoExtractScript returns the Bruno script that stores a correlated value as a runtime variable
+func brunoExtractScript(rule CorrelationRule) string {
+	var expr string
+	switch rule.Source {
+	case "json":
+		expr = "res.getBody()" + JSONPathAccessor(rule.Expression)
+	case "header":
+		expr = fmt.Sprintf("res.getHeader(%q)", strings.ToLower(rule.Expression))
+	case "cookie":
+		expr = fmt.Sprintf("([].concat(res.getHeader(\"set-cookie\") || []).join(\"\\n\").match(new RegExp(%q)) || [])[1]", "(?:^|\\n)"+regexp.QuoteMeta(rule.Expression)+"=([^;\\n]*)")
+	default:
+		expr = fmt.Sprintf("(String(res.getBody()).match(new RegExp(%q)) || [])[1]", rule.Expression)
+	}
+	return fmt.Sprintf("bru.setVar(%q, %s);", rule.Variable, expr)
+}
+
+// brunoEnvironment renders an environment as a .bru file, listing secrets by name only
+func brunoEnvironment(environment PostmanEnvironment) string {
+	var vars, secrets []string
+	for _, value := range environment.Values {
+		if value.Type == "secret" {
+			secrets = append(secrets, value.Key)
+		} else {
+			vars = append(vars, brunoEntry(value.Key, value.Value, !value.Enabled))
+		}
+	}
+
+	var out strings.Builder
+	brunoBlock(&out, "vars", vars)
+	if len(secrets) > 0 {
+		fmt.Fprintf(&out, "vars:secret [\n  %s\n]\n\n", strings.Join(secrets, ",\n  "))
+	}
+	return strings.TrimSuffix(out.String(), "\n")
+}
+
+// brunoEntry renders a dictionary entry, prefixed with ~ when disabled
+func brunoEntry(key, value string, disabled bool) string {
+	if disabled {
+		key = "~" + key
+	}
+	return key + ": " + value
+}
+
+// brunoBlock writes a dictionary block, leaving it out when it has no entries
+func brunoBlock(out *strings.Builder, name string, lines []string) {
+	if len(lines) == 0 {
+		return
+	}
+	fmt.Fprintf(out, "%s {\n", name)
+	for _, line := range lines {
+		if line == "" {
+			out.WriteString("\n")
+			continue
+		}
+		fmt.Fprintf(out, "  %s\n", line)
+	}
+	out.WriteString("}\n\n")
+}
+
+// brunoTextBlock writes a block holding indented text, such as a body, script or docs
+func brunoTextBlock(out *strings.Builder, name, text string) {
+	if strings.TrimSpace(text) == "" {
+		return
+	}
+	var lines []string
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		if name == "docs" {
+			// Trailing spaces in descriptions are noise, while bodies and scripts are kept as written
+			line = strings.TrimRight(line, " \t")
+		}
+		lines = append(lines, line)
+	}
+	brunoBlock(out, name, lines)
+}
+
+// SafeFileName turns a request or folder name into a file name, replacing characters file systems reject
+func SafeFileName(name string) string {
+	name = strings.Trim(unsafeFileCharRegex.ReplaceAllString(name, "_"), " .")
+	if name == "" {
+		return "request"
+	}
+	return name
+}
+
+// uniqueFileName returns name, or name with a number appended if it is already used in the directory
+func uniqueFileName(name string, used map[string]bool) string {
+	candidate := name
+	for n := 2; used[strings.ToLower(candidate)]; n++ {
+		candidate = fmt.Sprintf("%s %d", name, n)
+	}
+	used[strings.ToLower(candidate)] = true
+	return candidate
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBrunoRequest(t *testing.T) {
+	tests := []struct {
+		name string
+		item PostmanItem
+		want string
+	}{
+		{
+			name: "body lines are kept as written",
+			item: PostmanItem{Name: "POST notes", Description: "Adds a note  ", Request: PostmanRequest{
+				Method: "POST",
+				URL:    URLFromString("{{baseUrl}}/notes?draft=1"),
+				Header: []PostmanHeader{{Key: "Content-Type", Value: "text/markdown"}, {Key: "X-Trace", Value: "1", Disabled: true}},
+				Body:   PostmanBody{Mode: "raw", Raw: "line break  \r\n\tindented\r\n"},
+			}},
+			want: "meta {\n  name: POST notes\n  type: http\n  seq: 3\n}\n\n" +
+				"post {\n  url: {{baseUrl}}/notes?draft=1\n  body: text\n  auth: none\n}\n\n" +
+				"params:query {\n  draft: 1\n}\n\n" +
+				"headers {\n  Content-Type: text/markdown\n  ~X-Trace: 1\n}\n\n" +
+				"body:text {\n  line break  \n  \tindented\n\n}\n\n" +
+				"docs {\n  Adds a note\n}\n",
+		},
+		{
+			name: "auth blocks replace the Authorization header",
+			item: PostmanItem{Name: "upload", Request: PostmanRequest{
+				Method: "PUT",
+				URL:    URLFromString("https://api.example.com/files"),
+				Header: []PostmanHeader{{Key: "Authorization", Value: "Bearer old"}, {Key: "Content-Type", Value: "multipart/form-data; boundary=x"}},
+				Auth:   &PostmanAuth{Type: "bearer", Bearer: []PostmanAuthDetail{{Key: "token", Value: "{{token}}"}}},
+				Body:   PostmanBody{Mode: "formdata", Formdata: []PostmanFormParam{{Key: "file", Type: "file", Src: "/tmp/a.png"}, {Key: "note", Value: "hi", Disabled: true}}},
+			}},
+			want: "meta {\n  name: upload\n  type: http\n  seq: 3\n}\n\n" +
+				"put {\n  url: https://api.example.com/files\n  body: multipartForm\n  auth: bearer\n}\n\n" +
+				"auth:bearer {\n  token: {{token}}\n}\n\n" +
+				"body:multipart-form {\n  file: @file(/tmp/a.png)\n  ~note: hi\n}\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := brunoRequest(tt.item, 3); got != tt.want {
+				t.Errorf("brunoRequest =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBrunoEnvironment(t *testing.T) {
+	environment := PostmanEnvironment{Name: "staging", Values: []PostmanEnvironmentValue{
+		{Key: "baseUrl", Value: "https://staging.example.com", Enabled: true},
+		{Key: "debug", Value: "1"},
+		{Key: "token", Value: "abc", Type: "secret", Enabled: true},
+		{Key: "password", Value: "def", Type: "secret", Enabled: true},
+	}}
+	want := "vars {\n  baseUrl: https://staging.example.com\n  ~debug: 1\n}\n\nvars:secret [\n  token,\n  password\n]\n"
+	if got := brunoEnvironment(environment); got != want {
+		t.Errorf("brunoEnvironment =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestInsomniaRequest(t *testing.T) {
+	req := PostmanRequest{
+		Method: "post",
+		URL:    URLFromString("{{baseUrl}}/users/:id?expand={{my-field}}"),
+		Header: []PostmanHeader{{Key: "Content-Type", Value: "application/json; charset=utf-8"}, {Key: "Authorization", Value: "Basic old"}},
+		Auth:   &PostmanAuth{Type: "basic", Basic: []PostmanAuthDetail{{Key: "username", Value: "{{user}}"}, {Key: "password", Value: "pw"}}},
+		Body:   PostmanBody{Mode: "raw", Raw: `{"token":"{{token}}"}`},
+	}
+	req.URL.Variable = []PostmanVariable{{Key: "id", Value: "7"}}
+
+	got := insomniaRequest(req)
+	if got.Method != "POST" || got.URL != "{{ _.baseUrl }}/users/7" {
+		t.Errorf("request line = %s %s, want POST {{ _.baseUrl }}/users/7", got.Method, got.URL)
+	}
+	if len(got.Parameters) != 1 || got.Parameters[0] != (InsomniaParam{Name: "expand", Value: `{{ _["my-field"] }}`}) {
+		t.Errorf("parameters = %+v, want expand referencing my-field", got.Parameters)
+	}
+	if len(got.Headers) != 1 || got.Headers[0].Name != "Content-Type" {
+		t.Errorf("headers = %+v, want only Content-Type", got.Headers)
+	}
+	if got.Authentication == nil || *got.Authentication != (InsomniaAuth{Type: "basic", Username: "{{ _.user }}", Password: "pw"}) {
+		t.Errorf("authentication = %+v, want basic credentials", got.Authentication)
+	}
+	if got.Body.MimeType != "application/json" || got.Body.Text != `{"token":"{{ _.token }}"}` {
+		t.Errorf("body = %+v, want the JSON text with templated variables", *got.Body)
+	}
+}
+
+func TestSafeFileName(t *testing.T) {
+	used := map[string]bool{}
+	var names []string
+	for _, name := range []string{"GET /users/:id", "GET /users/:id", "  ..  ", "a<b>c"} {
+		names = append(names, uniqueFileName(SafeFileName(name), used))
+	}
+	if got, want := strings.Join(names, "|"), "GET _users_id|GET _users_id 2|request|a_b_c"; got != want {
+		t.Errorf("file names = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,375 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"mime/multipart"
+	"net/textproto"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/*
+	###################################### BURP XML EXPORT #############################################################
+*/
+
+// burpTimeLayout is the Java Date.toString format Burp writes for <time> and exportTime
+const burpTimeLayout = "Mon Jan 02 15:04:05 MST 2006"
+
+// RawRequest is a request rendered as HTTP/1.1 bytes along with where to send it
+type RawRequest struct {
+	Protocol string
+	Host     string
+	Port     string
+	Target   string
+	Data     []byte
+}
+
+// URL returns the absolute URL of a raw request, leaving out the default port
+func (r RawRequest) URL() string {
+	return r.Protocol + "://" + JoinHostPort(r.Host, r.Port, r.Protocol) + r.Target
+}
+
+// BuildRawRequest renders a Postman request as an HTTP/1.1 request, resolving variables that have a value
+//
+// The Host header is kept where the request has one and added first otherwise. Auth blocks are written as an
+// Authorization header unless one is already present, form bodies are encoded, and Content-Length is set to
+// the length of the body written. Disabled headers and HTTP/2 pseudo-headers are left out.
+func BuildRawRequest(req PostmanRequest, variables map[string]string) (RawRequest, error) {
+	resolve := func(s string) string {
+		return ResolveVariables(s, variables)
+	}
+
+	raw := RawRequest{}
+	rawURL := resolve(ResolvePathVariables(req.URL))
+	scheme, rest, found := strings.Cut(rawURL, "://")
+	if !found {
+		return raw, fmt.Errorf("URL %s has no protocol and host", rawURL)
+	}
+	authority := rest
+	raw.Target = "/"
+	if end := strings.IndexAny(rest, "/?#"); end >= 0 {
+		authority = rest[:end]
+		raw.Target = rest[end:]
+	}
+	raw.Target, _, _ = strings.Cut(raw.Target, "#")
+	if strings.HasPrefix(raw.Target, "?") {
+		raw.Target = "/" + raw.Target
+	}
+	if authority == "" || strings.Contains(authority, "{{") {
+		return raw, fmt.Errorf("URL %s has no resolved host", rawURL)
+	}
+	raw.Protocol = strings.ToLower(scheme)
+	raw.Host, raw.Port = SplitHostPort(authority)
+	if raw.Port == "" {
+		raw.Port = DefaultPort(raw.Protocol)
+	}
+
+	var headers []PostmanHeader
+	hasHost, hasAuthorization, hasContentType, hasLength := false, false, false, false
+	for _, header := range req.Header {
+		if header.Disabled || strings.HasPrefix(header.Key, ":") {
+			continue
+		}
+		switch strings.ToLower(header.Key) {
+		case "content-length", "transfer-encoding":
+			// Set from the body written below
+			hasLength = true
+			continue
+		case "host":
+			hasHost = true
+		case "authorization":
+			hasAuthorization = true
+		case "content-type":
+			hasContentType = true
+		}
+		headers = append(headers, PostmanHeader{Key: header.Key, Value: resolve(header.Value)})
+	}
+	if !hasHost {
+		headers = append([]PostmanHeader{{Key: "Host", Value: JoinHostPort(raw.Host, raw.Port, raw.Protocol)}}, headers...)
+	}
+	if authorization := authorizationValue(req.Auth); authorization != "" && !hasAuthorization {
+		headers = append(headers, PostmanHeader{Key: "Authorization", Value: resolve(authorization)})
+	}
+
+	body, contentType, err := encodeRequestBody(req.Body, resolve, "")
+	if err != nil {
+		return raw, err
+	}
+	if contentType != "" {
+		// A multipart boundary has to match the body, so a recorded Content-Type is replaced
+		if hasContentType && strings.HasPrefix(contentType, "multipart/") {
+			for h := range headers {
+				if strings.EqualFold(headers[h].Key, "Content-Type") {
+					headers[h].Value = contentType
+				}
+			}
+		} else if !hasContentType {
+			headers = append(headers, PostmanHeader{Key: "Content-Type", Value: contentType})
+		}
+	}
+	if len(body) > 0 || hasLength {
+		headers = append(headers, PostmanHeader{Key: "Content-Length", Value: strconv.Itoa(len(body))})
+	}
+
+	method := strings.ToUpper(req.Method)
+	if method == "" {
+		method = "GET"
+	}
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "%s %s HTTP/1.1\r\n", method, raw.Target)
+	for _, header := range headers {
+		fmt.Fprintf(&out, "%s: %s\r\n", header.Key, header.Value)
+	}
+	out.WriteString("\r\n")
+	out.Write(body)
+	raw.Data = out.Bytes()
+	return raw, nil
+}
+
+// authorizationValue returns the Authorization header value for a Basic or Bearer auth block
+func authorizationValue(auth *PostmanAuth) string {
+	if auth == nil {
+		return ""
+	}
+	switch auth.Type {
+	case "basic":
+		username, password, ok := basicCredentials(auth)
+		if !ok {
+			// Converted requests keep the encoded Authorization value as the password
+			return "Basic " + password
+		}
+		return "Basic " + base64.StdEncoding.EncodeToString([]byte(username+":"+password))
+	case "bearer":
+		return "Bearer " + authValue(auth, "token")
+	}
+	return ""
+}
+
+// authValue returns a field of a Basic or Bearer auth block
+func authValue(auth *PostmanAuth, key string) string {
+	for _, detail := range append(append([]PostmanAuthDetail{}, auth.Basic...), auth.Bearer...) {
+		if detail.Key == key {
+			return detail.Value
+		}
+	}
+	return ""
+}
+
+// basicCredentials returns the username and password of a Basic auth block
+//
+// Converted requests have no username and keep the encoded Authorization value as the password, which is
+// decoded where possible. ok is false when the credentials could not be recovered, with the password unchanged.
+func basicCredentials(auth *PostmanAuth) (username, password string, ok bool) {
+	username, password = authValue(auth, "username"), authValue(auth, "password")
+	if username != "" {
+		return username, password, true
+	}
+	decoded, err := base64.StdEncoding.DecodeString(password)
+	if err != nil {
+		return "", password, false
+	}
+	username, decodedPassword, found := strings.Cut(string(decoded), ":")
+	if !found {
+		return "", password, false
+	}
+	return username, decodedPassword, true
+}
+
+// encodeRequestBody returns the bytes of a request body and the Content-Type implied by its mode
+//
+// Multipart bodies are separated by boundary, or by a random boundary when it is empty.
+func encodeRequestBody(body PostmanBody, resolve func(string) string, boundary string) ([]byte, string, error) {
+	switch {
+	case len(body.Formdata) > 0:
+		var out bytes.Buffer
+		writer := multipart.NewWriter(&out)
+		if boundary != "" {
+			if err := writer.SetBoundary(boundary); err != nil {
+				return nil, "", err
+			}
+		}
+		for _, field := range body.Formdata {
+			if field.Disabled {
+				continue
+			}
+			if field.Type != "file" {
+				if err := writer.WriteField(resolve(field.Key), resolve(field.Value)); err != nil {
+					return nil, "", err
+				}
+				continue
+			}
+
+			// File fields carry the file contents when it can be read, otherwise an empty part
+			part := textproto.MIMEHeader{}
+			part.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`, resolve(field.Key), filepath.Base(field.Src)))
+			part.Set("Content-Type", "application/octet-stream")
+			w, err := writer.CreatePart(part)
+			if err != nil {
+				return nil, "", err
+			}
+			if data, err := os.ReadFile(field.Src); err == nil {
+				w.Write(data)
+			}
+		}
+		if err := writer.Close(); err != nil {
+			return nil, "", err
+		}
+		return out.Bytes(), writer.FormDataContentType(), nil
+	case len(body.Urlencoded) > 0:
+		var pairs []string
+		for _, field := range body.Urlencoded {
+			if !field.Disabled {
+				pairs = append(pairs, url.QueryEscape(resolve(field.Key))+"="+url.QueryEscape(resolve(field.Value)))
+			}
+		}
+		return []byte(strings.Join(pairs, "&")), "application/x-www-form-urlencoded", nil
+	}
+	return []byte(resolve(body.Raw)), "", nil
+}
+
+// BuildRawResponse renders a saved example as an HTTP/1.1 response with a Content-Length for its decoded body
+func BuildRawResponse(response PostmanResponse) []byte {
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "HTTP/1.1 %d %s\r\n", response.Code, response.Status)
+	for _, header := range response.Header {
+		switch strings.ToLower(header.Key) {
+		case "content-length", "transfer-encoding", "content-encoding":
+			// The saved body is already decoded
+			continue
+		}
+		fmt.Fprintf(&out, "%s: %s\r\n", header.Key, header.Value)
+	}
+	fmt.Fprintf(&out, "Content-Length: %d\r\n\r\n", len(response.Body))
+	out.WriteString(response.Body)
+	return out.Bytes()
+}
+
+// ExportBurpXML builds Burp items from a collection, with one item per saved example
+//
+// Requests without examples are written with an empty response. Requests whose host is a variable without
+// a value cannot be addressed and are returned as skipped.
+func ExportBurpXML(collection PostmanCollection) (BurpItems, []string) {
+	variables := map[string]string{}
+	for _, variable := range collection.Variable {
+		if variable.Value != "" {
+			variables[variable.Key] = variable.Value
+		}
+	}
+
+	export := BurpItems{BurpVersion: "go2postman", ExportTime: time.Now().Format(burpTimeLayout)}
+	var skipped []string
+	WalkItems(collection.Item, func(folders []string, item *PostmanItem) {
+		started := collection.Info.Updated
+		if recorded, ok := itemTime(*item); ok {
+			started = recorded
+		}
+		comment := item.Name
+		if item.Source != nil && item.Source.Burp != nil && item.Source.Burp.Comment != "" {
+			comment = item.Source.Burp.Comment
+		}
+
+		requests := []PostmanRequest{item.Request}
+		responses := []*PostmanResponse{nil}
+		if len(item.Response) > 0 {
+			requests, responses = nil, nil
+			for r := range item.Response {
+				request := item.Request
+				if item.Response[r].OriginalRequest != nil {
+					request = *item.Response[r].OriginalRequest
+				}
+				requests = append(requests, request)
+				responses = append(responses, &item.Response[r])
+			}
+		}
+
+		for r, request := range requests {
+			raw, err := BuildRawRequest(request, variables)
+			if err != nil {
+				skipped = append(skipped, fmt.Sprintf("%s: %v", item.Name, err))
+				return
+			}
+			burp := BurpItem{
+				Time:      started.Format(burpTimeLayout),
+				URL:       raw.URL(),
+				Host:      raw.Host,
+				Port:      raw.Port,
+				Protocol:  raw.Protocol,
+				Method:    strings.ToUpper(request.Method),
+				Path:      raw.Target,
+				Extension: burpExtension(raw.Target),
+				Request:   BurpRequestData{Base64: "true", Content: base64.StdEncoding.EncodeToString(raw.Data)},
+				Response:  BurpResponseData{Base64: "true"},
+				Comment:   comment,
+			}
+			if response := responses[r]; response != nil {
+				data := BuildRawResponse(*response)
+				burp.Status = strconv.Itoa(response.Code)
+				burp.ResponseLength = strconv.Itoa(len(data))
+				burp.MimeType = burpMimeType(response)
+				burp.Response.Content = base64.StdEncoding.EncodeToString(data)
+			}
+			export.Items = append(export.Items, burp)
+		}
+	})
+	return export, skipped
+}
+
+// WriteBurpXML writes a collection as a Burp items file and returns the number of items and the skipped requests
+func WriteBurpXML(fileName string, collection PostmanCollection) (int, []string, error) {
+	export, skipped := ExportBurpXML(collection)
+	output, err := xml.MarshalIndent(export, "", "  ")
+	if err != nil {
+		return 0, skipped, fmt.Errorf("error marshaling Burp XML: %v", err)
+	}
+	output = append([]byte(xml.Header), output...)
+	if err := os.WriteFile(fileName, append(output, '\n'), 0644); err != nil {
+		return 0, skipped, fmt.Errorf("error writing Burp XML file: %v", err)
+	}
+	return len(export.Items), skipped, nil
+}
+
+// burpExtension returns the file extension of a request path as Burp records it, or "null"
+func burpExtension(target string) string {
+	requestPath, _, _ := strings.Cut(target, "?")
+	extension := strings.TrimPrefix(filepath.Ext(requestPath), ".")
+	if extension == "" || strings.Contains(extension, "/") {
+		return "null"
+	}
+	return extension
+}
+
+// burpMimeType returns Burp's MIME type label for the Content-Type of a saved example
+func burpMimeType(response *PostmanResponse) string {
+	contentType := ""
+	for _, header := range response.Header {
+		if strings.EqualFold(header.Key, "Content-Type") {
+			contentType = strings.ToLower(header.Value)
+		}
+	}
+	switch {
+	case strings.Contains(contentType, "json"):
+		return "JSON"
+	case strings.Contains(contentType, "html"):
+		return "HTML"
+	case strings.Contains(contentType, "xml"):
+		return "XML"
+	case strings.Contains(contentType, "javascript"), strings.Contains(contentType, "ecmascript"):
+		return "script"
+	case strings.Contains(contentType, "css"):
+		return "CSS"
+	case strings.HasPrefix(contentType, "image/"):
+		return "image"
+	case strings.HasPrefix(contentType, "text/"):
+		return "text"
+	case contentType == "":
+		return ""
+	}
+	return "app"
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBuildRawRequest(t *testing.T) {
+	variables := map[string]string{"baseUrl": "https://api.example.com:8443", "token": "abc"}
+	tests := []struct {
+		name string
+		req  PostmanRequest
+		url  string
+		want string
+	}{
+		{
+			name: "Host added and Content-Length set from the body",
+			req: PostmanRequest{
+				Method: "post",
+				URL:    URLFromString("{{baseUrl}}/items?x=1#frag"),
+				Header: []PostmanHeader{{Key: ":authority", Value: "x"}, {Key: "Content-Length", Value: "99"}, {Key: "X-Off", Value: "1", Disabled: true}, {Key: "X-Token", Value: "{{token}}"}},
+				Body:   PostmanBody{Mode: "raw", Raw: `{"a":1}`},
+			},
+			url:  "https://api.example.com:8443/items?x=1",
+			want: "POST /items?x=1 HTTP/1.1\r\nHost: api.example.com:8443\r\nX-Token: abc\r\nContent-Length: 7\r\n\r\n{\"a\":1}",
+		},
+		{
+			name: "recorded Host kept and auth block written",
+			req: PostmanRequest{
+				URL:    URLFromString("http://10.0.0.1?q=1"),
+				Header: []PostmanHeader{{Key: "Host", Value: "internal.example.com"}},
+				Auth:   &PostmanAuth{Type: "bearer", Bearer: []PostmanAuthDetail{{Key: "token", Value: "{{token}}"}}},
+			},
+			url:  "http://10.0.0.1/?q=1",
+			want: "GET /?q=1 HTTP/1.1\r\nHost: internal.example.com\r\nAuthorization: Bearer abc\r\n\r\n",
+		},
+		{
+			name: "URL-encoded form",
+			req: PostmanRequest{
+				Method: "POST",
+				URL:    URLFromString("https://api.example.com/login"),
+				Body:   PostmanBody{Mode: "urlencoded", Urlencoded: []PostmanFormParam{{Key: "user", Value: "bob smith"}, {Key: "pass", Value: "a&b"}}},
+			},
+			url:  "https://api.example.com/login",
+			want: "POST /login HTTP/1.1\r\nHost: api.example.com\r\nContent-Type: application/x-www-form-urlencoded\r\nContent-Length: 25\r\n\r\nuser=bob+smith&pass=a%26b",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raw, err := BuildRawRequest(tt.req, variables)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if raw.URL() != tt.url || string(raw.Data) != tt.want {
+				t.Errorf("request %s =\n%q\nwant %s\n%q", raw.URL(), raw.Data, tt.url, tt.want)
+			}
+		})
+	}
+
+	if _, err := BuildRawRequest(PostmanRequest{URL: URLFromString("{{host}}/x")}, variables); err == nil {
+		t.Errorf("BuildRawRequest accepted an unresolved host")
+	}
+}
+
+func TestBurpXMLRoundTrip(t *testing.T) {
+	collection := PostmanCollection{Item: []PostmanItem{
+		{Name: "GET users.json", Request: PostmanRequest{Method: "GET", URL: URLFromString("https://api.example.com/users.json")}, Response: []PostmanResponse{{
+			Code:   200,
+			Status: "OK",
+			Header: []PostmanHeader{{Key: "Content-Type", Value: "application/json"}, {Key: "Content-Encoding", Value: "gzip"}},
+			Body:   `[{"id":1}]`,
+		}}},
+		{Name: "unaddressable", Request: PostmanRequest{Method: "GET", URL: URLFromString("{{baseUrl}}/x")}},
+	}}
+
+	export, skipped := ExportBurpXML(collection)
+	if len(export.Items) != 1 || len(skipped) != 1 || !strings.HasPrefix(skipped[0], "unaddressable: ") {
+		t.Fatalf("items = %d, skipped = %q, want one item and the unaddressable request skipped", len(export.Items), skipped)
+	}
+	if item := export.Items[0]; item.Extension != "json" || item.MimeType != "JSON" || item.Status != "200" || item.Port != "443" {
+		t.Errorf("item = %+v, want the Burp metadata filled in", item)
+	}
+
+	fileName := filepath.Join(t.TempDir(), "export.xml")
+	if count, _, err := WriteBurpXML(fileName, collection); err != nil || count != 1 {
+		t.Fatalf("WriteBurpXML = %d, %v", count, err)
+	}
+	items, err := ProcessBurpXML(fileName, io.Discard)
+	if err != nil || len(items) != 1 {
+		t.Fatalf("ProcessBurpXML = %d items, %v", len(items), err)
+	}
+	response := items[0].Response[0]
+	if items[0].Request.URL.Raw != "https://api.example.com/users.json" || response.Code != 200 || response.Body != `[{"id":1}]` {
+		t.Errorf("read back %s with %d %q", items[0].Request.URL.Raw, response.Code, response.Body)
+	}
+}
@@ -0,0 +1,478 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+/*
+	###################################### CLIENT CODE GENERATION ######################################################
+*/
+
+// codeLanguages are the languages -code-lang accepts, in the order they are written
+var codeLanguages = []string{"go", "python", "javascript"}
+
+// generatedSkipHeaders are set by the HTTP client libraries themselves, and Host would point at the recorded
+// host when the URL has been moved to a {{baseUrl}} variable
+var generatedSkipHeaders = map[string]bool{"host": true, "content-length": true, "connection": true, "transfer-encoding": true}
+
+// codeBoundary separates the parts of multipart bodies in generated code, so regenerating a collection gives the same files
+const codeBoundary = "go2postmanBoundary"
+
+// CodeCase is one request of a collection reduced to what the generated clients send and assert
+type CodeCase struct {
+	Name     string
+	Method   string
+	URL      string
+	Header   [][2]string
+	Username string
+	Password string
+	Basic    bool
+	Body     string
+	Status   int
+}
+
+// BuildCodeCases converts the requests of a collection in order, with the recorded status of the first saved example
+func BuildCodeCases(collection PostmanCollection) []CodeCase {
+	var cases []CodeCase
+	WalkItems(collection.Item, func(folders []string, item *PostmanItem) {
+		cases = append(cases, buildCodeCase(folders, *item))
+	})
+	return cases
+}
+
+// buildCodeCase converts one request, named by its folder path and name
+//
+// Path variables are filled in while other {{variables}} are kept for the generated code to expand at run
+// time. Form bodies are encoded with the matching Content-Type, and Basic auth is kept as credentials where
+// they can be recovered and as an Authorization header otherwise.
+func buildCodeCase(folders []string, item PostmanItem) CodeCase {
+	keep := func(s string) string { return s }
+	req := item.Request
+	c := CodeCase{
+		Name:   strings.Join(append(append([]string{}, folders...), item.Name), " / "),
+		Method: strings.ToUpper(req.Method),
+		URL:    ResolvePathVariables(req.URL),
+	}
+	if c.Method == "" {
+		c.Method = "GET"
+	}
+	if len(item.Response) > 0 {
+		c.Status = item.Response[0].Code
+	}
+
+	body, contentType, _ := encodeRequestBody(req.Body, keep, codeBoundary)
+	c.Body = string(body)
+
+	hasAuthorization := false
+	for _, header := range req.Header {
+		key := strings.ToLower(header.Key)
+		if header.Disabled || generatedSkipHeaders[key] || strings.HasPrefix(key, ":") {
+			continue
+		}
+		if key == "content-type" && strings.HasPrefix(contentType, "multipart/") {
+			// The boundary has to match the encoded body
+			continue
+		}
+		if key == "content-type" {
+			contentType = ""
+		}
+		if key == "authorization" {
+			hasAuthorization = true
+		}
+		c.Header = append(c.Header, [2]string{header.Key, header.Value})
+	}
+	if contentType != "" {
+		c.Header = append(c.Header, [2]string{"Content-Type", contentType})
+	}
+	if req.Auth != nil && !hasAuthorization {
+		if username, password, ok := basicCredentials(req.Auth); ok && req.Auth.Type == "basic" {
+			c.Basic, c.Username, c.Password = true, username, password
+		} else if authorization := authorizationValue(req.Auth); authorization != "" {
+			c.Header = append(c.Header, [2]string{"Authorization", authorization})
+		}
+	}
+	return c
+}
+
+// GenerateCode writes client code for the requests of a collection in each language and returns the files written
+//
+// Go gets a table-driven net/http test, Python a parametrised pytest module using requests and JavaScript a
+// node:test module using fetch. Every request is sent in collection order and its status is checked against the
+// recorded response when there is one. {{variables}} are read from environment variables at run time, falling
+// back to the collection values.
+func GenerateCode(dir string, collection PostmanCollection, languages []string) ([]string, error) {
+	cases := BuildCodeCases(collection)
+	variables := map[string]string{}
+	for _, variable := range collection.Variable {
+		variables[variable.Key] = variable.Value
+	}
+
+	var files []string
+	for _, language := range languages {
+		var generated map[string][]byte
+		var err error
+		switch language {
+		case "go":
+			generated, err = generateGo(cases, variables)
+		case "python":
+			generated = generatePython(cases, variables)
+		case "javascript":
+			generated = generateJavaScript(cases, variables)
+		default:
+			return files, fmt.Errorf("unknown language %q, expected one of %s", language, strings.Join(codeLanguages, ", "))
+		}
+		if err != nil {
+			return files, err
+		}
+
+		langDir := filepath.Join(dir, language)
+		if err := os.MkdirAll(langDir, 0755); err != nil {
+			return files, fmt.Errorf("error creating %s directory: %v", language, err)
+		}
+		names := make([]string, 0, len(generated))
+		for name := range generated {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fileName := filepath.Join(langDir, name)
+			if err := os.WriteFile(fileName, generated[name], 0644); err != nil {
+				return files, fmt.Errorf("error writing %s: %v", fileName, err)
+			}
+			files = append(files, fileName)
+		}
+	}
+	return files, nil
+}
+
+// ParseCodeLanguages splits a comma separated -code-lang list, accepting js for javascript and py for python
+func ParseCodeLanguages(list string) ([]string, error) {
+	aliases := map[string]string{"go": "go", "golang": "go", "python": "python", "py": "python", "javascript": "javascript", "js": "javascript"}
+	seen := map[string]bool{}
+	for _, name := range strings.Split(list, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		language, ok := aliases[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown language %q, expected one of %s", name, strings.Join(codeLanguages, ", "))
+		}
+		seen[language] = true
+	}
+
+	var languages []string
+	for _, language := range codeLanguages {
+		if seen[language] {
+			languages = append(languages, language)
+		}
+	}
+	if len(languages) == 0 {
+		return nil, fmt.Errorf("no languages given")
+	}
+	return languages, nil
+}
+
+// generateGo writes a table-driven test using net/http and a go.mod so it runs with go test
+func generateGo(cases []CodeCase, variables map[string]string) (map[string][]byte, error) {
+	var out strings.Builder
+	out.WriteString(`// Code generated by go2postman. DO NOT EDIT.
+
+package apitest
+
+import (
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+// variables are the collection values, overridden by environment variables of the same name
+var variables = map[string]string{
+`)
+	for _, key := range sortedStringKeys(variables) {
+		fmt.Fprintf(&out, "%q: %q,\n", key, variables[key])
+	}
+	out.WriteString(`}
+
+var variableRegex = regexp.MustCompile(` + "`\\{\\{([^{}]+)\\}\\}`" + `)
+
+// expand replaces {{variable}} references with their values
+func expand(s string) string {
+	return variableRegex.ReplaceAllStringFunc(s, func(reference string) string {
+		name := strings.TrimSpace(reference[2 : len(reference)-2])
+		if value, ok := os.LookupEnv(name); ok {
+			return value
+		}
+		if value, ok := variables[name]; ok {
+			return value
+		}
+		return reference
+	})
+}
+
+func TestRequests(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		url        string
+		header     [][2]string
+		basicAuth  []string
+		body       string
+		wantStatus int
+	}{
+`)
+	for _, c := range cases {
+		fmt.Fprintf(&out, "{\nname: %q,\nmethod: %q,\nurl: %q,\n", c.Name, c.Method, c.URL)
+		if len(c.Header) > 0 {
+			out.WriteString("header: [][2]string{\n")
+			for _, header := range c.Header {
+				fmt.Fprintf(&out, "{%q, %q},\n", header[0], header[1])
+			}
+			out.WriteString("},\n")
+		}
+		if c.Basic {
+			fmt.Fprintf(&out, "basicAuth: []string{%q, %q},\n", c.Username, c.Password)
+		}
+		if c.Body != "" {
+			fmt.Fprintf(&out, "body: %q,\n", c.Body)
+		}
+		if c.Status != 0 {
+			fmt.Fprintf(&out, "wantStatus: %d,\n", c.Status)
+		}
+		out.WriteString("},\n")
+	}
+	out.WriteString(`}
+
+	client := &http.Client{
+		Timeout: 30 * time.Second,
+		// Redirects are checked like any other recorded status
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, expand(tt.url), strings.NewReader(expand(tt.body)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, header := range tt.header {
+				req.Header.Add(header[0], expand(header[1]))
+			}
+			if tt.basicAuth != nil {
+				req.SetBasicAuth(expand(tt.basicAuth[0]), expand(tt.basicAuth[1]))
+			}
+
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			if tt.wantStatus != 0 && resp.StatusCode != tt.wantStatus {
+				t.Errorf("%s %s returned %d, want %d", tt.method, req.URL, resp.StatusCode, tt.wantStatus)
+			}
+		})
+	}
+}
+`)
+
+	source, err := format.Source([]byte(out.String()))
+	if err != nil {
+		return nil, fmt.Errorf("error formatting generated Go code: %v", err)
+	}
+	return map[string][]byte{
+		"requests_test.go": source,
+		"go.mod":           []byte("module apitest\n\ngo 1.21\n"),
+	}, nil
+}
+
+// generatePython writes a parametrised pytest module using requests
+func generatePython(cases []CodeCase, variables map[string]string) map[string][]byte {
+	var out strings.Builder
+	out.WriteString(`# Generated by go2postman
+import os
+import re
+
+import pytest
+import requests
+from requests.structures import CaseInsensitiveDict
+
+# The collection values, overridden by environment variables of the same name
+VARIABLES = {
+`)
+	for _, key := range sortedStringKeys(variables) {
+		fmt.Fprintf(&out, "    %s: %s,\n", codeString(key), codeString(variables[key]))
+	}
+	out.WriteString(`}
+
+
+def expand(value):
+    """Replace {{variable}} references with their values."""
+    def lookup(match):
+        name = match.group(1).strip()
+        return os.environ.get(name, VARIABLES.get(name, match.group(0)))
+    return re.sub(r"\{\{([^{}]+)\}\}", lookup, value)
+
+
+CASES = [
+`)
+	for _, c := range cases {
+		var headers []string
+		for _, header := range c.Header {
+			headers = append(headers, "("+codeString(header[0])+", "+codeString(header[1])+")")
+		}
+		auth := "None"
+		if c.Basic {
+			auth = "(" + codeString(c.Username) + ", " + codeString(c.Password) + ")"
+		}
+		body := "None"
+		if c.Body != "" && !utf8.ValidString(c.Body) {
+			body = pythonBytes(c.Body)
+		} else if c.Body != "" {
+			body = codeString(c.Body)
+		}
+		fmt.Fprintf(&out, "    pytest.param(\n        %s,\n        %s,\n        [%s],\n        %s,\n        %s,\n        %d,\n        id=%s,\n    ),\n",
+			codeString(c.Method), codeString(c.URL), strings.Join(headers, ", "), auth, body, c.Status, codeString(c.Name))
+	}
+	out.WriteString(`]
+
+
+@pytest.mark.parametrize("method,url,headers,auth,body,status", CASES)
+def test_request(method, url, headers, auth, body, status):
+    # requests takes one value per header, so repeated headers are joined
+    merged = CaseInsensitiveDict()
+    for name, value in headers:
+        if name in merged:
+            merged[name] += ("; " if name.lower() == "cookie" else ", ") + expand(value)
+        else:
+            merged[name] = expand(value)
+    if isinstance(body, str):
+        body = expand(body).encode("utf-8")
+    response = requests.request(
+        method,
+        expand(url),
+        headers=merged,
+        auth=tuple(expand(value) for value in auth) if auth else None,
+        data=body,
+        allow_redirects=False,
+        timeout=30,
+    )
+    if status:
+        assert response.status_code == status
+`)
+	return map[string][]byte{"test_requests.py": []byte(out.String())}
+}
+
+// generateJavaScript writes a node:test module using fetch
+func generateJavaScript(cases []CodeCase, variables map[string]string) map[string][]byte {
+	var out strings.Builder
+	out.WriteString(`// Generated by go2postman
+import { test } from "node:test";
+import assert from "node:assert/strict";
+
+// The collection values, overridden by environment variables of the same name
+const variables = {
+`)
+	for _, key := range sortedStringKeys(variables) {
+		fmt.Fprintf(&out, "  %s: %s,\n", codeString(key), codeString(variables[key]))
+	}
+	out.WriteString(`};
+
+// Replace {{variable}} references with their values
+const expand = (value) =>
+  value.replace(/\{\{([^{}]+)\}\}/g, (match, name) => process.env[name.trim()] ?? variables[name.trim()] ?? match);
+
+const cases = [
+`)
+	for _, c := range cases {
+		var pairs []string
+		for _, header := range c.Header {
+			pairs = append(pairs, "["+codeString(header[0])+", "+codeString(header[1])+"]")
+		}
+		auth := "null"
+		if c.Basic {
+			auth = "[" + codeString(c.Username) + ", " + codeString(c.Password) + "]"
+		}
+		body := "null"
+		switch {
+		case c.Body != "" && (c.Method == "GET" || c.Method == "HEAD"):
+			// fetch rejects a body on GET and HEAD requests
+			body = "null /* the recorded body is not sent, fetch does not allow one for " + c.Method + " */"
+		case c.Body != "" && !utf8.ValidString(c.Body):
+			body = "Buffer.from(" + codeString(base64.StdEncoding.EncodeToString([]byte(c.Body))) + ", \"base64\")"
+		case c.Body != "":
+			body = codeString(c.Body)
+		}
+		fmt.Fprintf(&out, "  {\n    name: %s,\n    method: %s,\n    url: %s,\n    headers: [%s],\n    auth: %s,\n    body: %s,\n    status: %d,\n  },\n",
+			codeString(c.Name), codeString(c.Method), codeString(c.URL), strings.Join(pairs, ", "), auth, body, c.Status)
+	}
+	out.WriteString(`];
+
+for (const c of cases) {
+  test(c.name, async () => {
+    const headers = c.headers.map(([name, value]) => [name, expand(value)]);
+    if (c.auth) {
+      const [username, password] = c.auth.map(expand);
+      headers.push(["Authorization", "Basic " + Buffer.from(username + ":" + password).toString("base64")]);
+    }
+    const response = await fetch(expand(c.url), {
+      method: c.method,
+      headers,
+      body: c.body === null ? undefined : typeof c.body === "string" ? expand(c.body) : c.body,
+      redirect: "manual",
+    });
+    if (c.status) {
+      assert.equal(response.status, c.status);
+    }
+  });
+}
+`)
+	return map[string][]byte{"requests.test.mjs": []byte(out.String())}
+}
+
+// codeString quotes a string as a literal valid in both Python and JavaScript
+//
+// The string has to be valid UTF-8, as invalid bytes are replaced; bodies holding other bytes are written
+// as byte literals instead.
+func codeString(s string) string {
+	quoted, _ := json.Marshal(s)
+	return string(quoted)
+}
+
+// pythonBytes quotes a string as a Python bytes literal, escaping everything but printable ASCII
+func pythonBytes(s string) string {
+	var out strings.Builder
+	out.WriteString(`b"`)
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '"' || c == '\\':
+			out.WriteByte('\\')
+			out.WriteByte(c)
+		case c >= 0x20 && c < 0x7f:
+			out.WriteByte(c)
+		default:
+			fmt.Fprintf(&out, `\x%02x`, c)
+		}
+	}
+	out.WriteString(`"`)
+	return out.String()
+}
+
+// sortedStringKeys returns the keys of a map in order
+func sortedStringKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
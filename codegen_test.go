@@ -0,0 +1,87 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBuildCodeCases(t *testing.T) {
+	collection := PostmanCollection{Item: []PostmanItem{
+		{Name: "upload", Request: PostmanRequest{
+			Method: "post",
+			URL:    URLFromString("https://api.example.com/upload"),
+			Header: []PostmanHeader{{Key: "Content-Type", Value: "multipart/form-data; boundary=old"}, {Key: "Host", Value: "api.example.com"}},
+			Body:   PostmanBody{Mode: "formdata", Formdata: []PostmanFormParam{{Key: "name", Value: "bob"}}},
+		}},
+		{Name: "login", Request: PostmanRequest{
+			URL:  URLFromString("https://api.example.com/login"),
+			Auth: &PostmanAuth{Type: "basic", Basic: []PostmanAuthDetail{{Key: "username", Value: "bob"}, {Key: "password", Value: "secret"}}},
+		}},
+	}}
+
+	cases := BuildCodeCases(collection)
+	upload := cases[0]
+	if upload.Method != "POST" || len(upload.Header) != 1 || upload.Header[0] != [2]string{"Content-Type", "multipart/form-data; boundary=" + codeBoundary} {
+		t.Errorf("upload = %+v, want POST with only a Content-Type carrying the fixed boundary", upload)
+	}
+	if want := "--" + codeBoundary + "\r\nContent-Disposition: form-data; name=\"name\"\r\n\r\nbob\r\n--" + codeBoundary + "--\r\n"; upload.Body != want {
+		t.Errorf("upload body = %q, want %q", upload.Body, want)
+	}
+	if login := cases[1]; login.Method != "GET" || !login.Basic || login.Username != "bob" || login.Password != "secret" {
+		t.Errorf("login = %+v, want GET with Basic credentials", login)
+	}
+}
+
+func TestGeneratedClients(t *testing.T) {
+	cases := []CodeCase{
+		{Name: "search", Method: "GET", URL: "{{baseUrl}}/search", Header: [][2]string{{"Accept", "a"}, {"Accept", "b"}}, Body: "q=1"},
+		{Name: "image", Method: "PUT", URL: "{{baseUrl}}/image", Body: "\x89PNG\"\\"},
+		{Name: "note", Method: "POST", URL: "{{baseUrl}}/notes", Body: "héllo", Status: 201},
+	}
+	tests := []struct {
+		name  string
+		files map[string][]byte
+		file  string
+		want  []string
+	}{
+		{
+			name:  "python keeps repeated headers and writes bytes literals",
+			files: generatePython(cases, nil),
+			file:  "test_requests.py",
+			want: []string{
+				`[("Accept", "a"), ("Accept", "b")],`,
+				`b"\x89PNG\"\\",`,
+				`"héllo",`,
+			},
+		},
+		{
+			name:  "javascript drops GET bodies and decodes binary bodies",
+			files: generateJavaScript(cases, nil),
+			file:  "requests.test.mjs",
+			want: []string{
+				`headers: [["Accept", "a"], ["Accept", "b"]],`,
+				"body: null /* the recorded body is not sent, fetch does not allow one for GET */,",
+				`body: Buffer.from("iVBORyJc", "base64"),`,
+				`body: "héllo",`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := string(tt.files[tt.file])
+			for _, want := range tt.want {
+				if !strings.Contains(source, want) {
+					t.Errorf("%s does not contain %s:\n%s", tt.file, want, source)
+				}
+			}
+		})
+	}
+
+	files, err := generateGo(cases, map[string]string{"baseUrl": "http://localhost"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if source := string(files["requests_test.go"]); !strings.Contains(source, `body:   "\x89PNG\"\\",`) || !strings.Contains(source, `"baseUrl": "http://localhost",`) {
+		t.Errorf("requests_test.go does not keep the body bytes and variables:\n%s", source)
+	}
+}
@@ -0,0 +1,480 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+/*
+	################################### TOKEN CORRELATION BETWEEN REQUESTS #############################################
+*/
+
+// CorrelationRule describes a value produced by a response that later requests reuse
+type CorrelationRule struct {
+	Variable string
+	Value    string
+
+	// Source is "json", "header", "cookie" or "regex" and Expression is the JSON path, header name, cookie name or pattern
+	Source     string
+	Expression string
+}
+
+// minCorrelationLength is the shortest string value considered for correlation, to avoid matching common words
+const minCorrelationLength = 6
+
+// uncorrelatedHeaders lists response headers whose values are never tokens
+var uncorrelatedHeaders = map[string]bool{
+	"date": true, "expires": true, "last-modified": true, "server": true, "vary": true, "connection": true,
+	"content-type": true, "content-length": true, "content-encoding": true, "transfer-encoding": true,
+	"cache-control": true, "pragma": true, "age": true, "keep-alive": true, "accept-ranges": true,
+	"strict-transport-security": true, "content-security-policy": true, "x-content-type-options": true,
+	"x-frame-options": true, "x-xss-protection": true, "referrer-policy": true, "permissions-policy": true,
+	"access-control-allow-origin": true, "access-control-allow-methods": true, "access-control-allow-headers": true,
+	"access-control-allow-credentials": true, "access-control-expose-headers": true, "access-control-max-age": true,
+	"set-cookie": true,
+}
+
+// CorrelateItems replaces values first seen in a response and reused by later requests with {{variables}}
+//
+// Items must be in the order they were recorded. The producing item gets a test script that stores the
+// fresh value from its response, and the rule is kept on the item for exporters.
+func CorrelateItems(items []PostmanItem) []CorrelationRule {
+	var rules []CorrelationRule
+	usedNames := map[string]bool{}
+	claimed := map[string]bool{}
+
+	for i := range items {
+		if len(items[i].Response) == 0 || i == len(items)-1 {
+			continue
+		}
+		response := items[i].Response[0]
+
+		candidates := responseCandidates(response)
+		candidates = append(candidates, textBodyCandidates(response, items[i+1:])...)
+
+		for _, candidate := range candidates {
+			if claimed[candidate.Value] || sentBefore(items[:i+1], candidate.Value) {
+				continue
+			}
+
+			reused := false
+			for j := i + 1; j < len(items); j++ {
+				if requestContains(items[j].Request, candidate.Value) {
+					reused = true
+					break
+				}
+			}
+			if !reused {
+				continue
+			}
+
+			candidate.Variable = uniqueVariableName(candidate.Variable, usedNames)
+			claimed[candidate.Value] = true
+			for j := i + 1; j < len(items); j++ {
+				RewriteRequestStrings(&items[j].Request, func(s string) string {
+					return replaceBounded(s, candidate.Value, "{{"+candidate.Variable+"}}")
+				})
+			}
+
+			items[i].Extract = append(items[i].Extract, candidate)
+			AddTestScript(&items[i], []string{candidate.Script()})
+			rules = append(rules, candidate)
+		}
+	}
+
+	return rules
+}
+
+// Script returns the Postman test script line that stores the value from a fresh response
+func (r CorrelationRule) Script() string {
+	var expr string
+	switch r.Source {
+	case "json":
+		expr = "pm.response.json()" + JSONPathAccessor(r.Expression)
+	case "header":
+		expr = fmt.Sprintf("pm.response.headers.get(%q)", r.Expression)
+	case "cookie":
+		expr = fmt.Sprintf("pm.cookies.get(%q)", r.Expression)
+	default:
+		expr = fmt.Sprintf("(pm.response.text().match(new RegExp(%q)) || [])[1]", r.Expression)
+	}
+	return fmt.Sprintf("pm.collectionVariables.set(%q, %s);", r.Variable, expr)
+}
+
+// correlationScriptRegex matches a line written by CorrelationRule.Script, capturing the variable and expression
+var correlationScriptRegex = regexp.MustCompile(`^pm\.collectionVariables\.set\(("(?:[^"\\]|\\.)*"), (.+)\);$`)
+
+// accessorSegmentRegex matches one ["key"] or [0] step of a JavaScript property accessor
+var accessorSegmentRegex = regexp.MustCompile(`^\[("(?:[^"\\]|\\.)*"|\d+)\]`)
+
+// ItemCorrelationRules returns the values an item's response provides to later requests
+//
+// Rules found during this run are kept on the item; items loaded from an existing collection with -update
+// only have their test scripts, so the rules are recovered from the script lines Script writes.
+func ItemCorrelationRules(item PostmanItem) []CorrelationRule {
+	if len(item.Extract) > 0 {
+		return item.Extract
+	}
+	var rules []CorrelationRule
+	for _, event := range item.Event {
+		if event.Listen != "test" {
+			continue
+		}
+		for _, line := range event.Script.Exec {
+			if rule, ok := parseCorrelationScript(strings.TrimSpace(line)); ok {
+				rules = append(rules, rule)
+			}
+		}
+	}
+	return rules
+}
+
+// parseCorrelationScript reverses CorrelationRule.Script, reporting false for any other script line
+func parseCorrelationScript(line string) (CorrelationRule, bool) {
+	match := correlationScriptRegex.FindStringSubmatch(line)
+	if match == nil {
+		return CorrelationRule{}, false
+	}
+	variable, err := strconv.Unquote(match[1])
+	if err != nil {
+		return CorrelationRule{}, false
+	}
+	rule := CorrelationRule{Variable: variable}
+	quoted := func(expr, prefix, suffix string) (string, bool) {
+		if !strings.HasPrefix(expr, prefix) || !strings.HasSuffix(expr, suffix) {
+			return "", false
+		}
+		value, err := strconv.Unquote(strings.TrimSuffix(strings.TrimPrefix(expr, prefix), suffix))
+		return value, err == nil
+	}
+
+	expr := match[2]
+	var ok bool
+	switch {
+	case strings.HasPrefix(expr, "pm.response.json()"):
+		rule.Source, rule.Expression = "json", "$"
+		accessor := strings.TrimPrefix(expr, "pm.response.json()")
+		for accessor != "" {
+			segment := accessorSegmentRegex.FindStringSubmatch(accessor)
+			if segment == nil {
+				return CorrelationRule{}, false
+			}
+			if key, err := strconv.Unquote(segment[1]); err == nil {
+				rule.Expression += "." + key
+			} else {
+				rule.Expression += "[" + segment[1] + "]"
+			}
+			accessor = accessor[len(segment[0]):]
+		}
+		ok = true
+	case strings.HasPrefix(expr, "pm.response.headers.get("):
+		rule.Source = "header"
+		rule.Expression, ok = quoted(expr, "pm.response.headers.get(", ")")
+	case strings.HasPrefix(expr, "pm.cookies.get("):
+		rule.Source = "cookie"
+		rule.Expression, ok = quoted(expr, "pm.cookies.get(", ")")
+	default:
+		rule.Source = "regex"
+		rule.Expression, ok = quoted(expr, "(pm.response.text().match(new RegExp(", ")) || [])[1]")
+	}
+	return rule, ok
+}
+
+// JSONPathAccessor converts a $.a.b[0] style JSON path into a JavaScript property accessor
+func JSONPathAccessor(path string) string {
+	var out strings.Builder
+	for _, segment := range SplitJSONPath(path) {
+		if index, err := strconv.Atoi(segment); err == nil {
+			out.WriteString(fmt.Sprintf("[%d]", index))
+		} else {
+			out.WriteString(fmt.Sprintf("[%q]", segment))
+		}
+	}
+	return out.String()
+}
+
+// SplitJSONPath splits a $.a.b[0] style JSON path into keys and array indexes
+func SplitJSONPath(path string) []string {
+	path = strings.TrimPrefix(strings.TrimPrefix(path, "$"), ".")
+	var segments []string
+	for _, part := range strings.Split(path, ".") {
+		for part != "" {
+			open := strings.IndexByte(part, '[')
+			if open < 0 {
+				segments = append(segments, part)
+				break
+			}
+			if open > 0 {
+				segments = append(segments, part[:open])
+			}
+			end := strings.IndexByte(part[open:], ']')
+			if end < 0 {
+				segments = append(segments, part[open:])
+				break
+			}
+			segments = append(segments, part[open+1:open+end])
+			part = part[open+end+1:]
+		}
+	}
+	return segments
+}
+
+// responseCandidates collects token-like values from a JSON body, response headers and Set-Cookie
+func responseCandidates(response PostmanResponse) []CorrelationRule {
+	var candidates []CorrelationRule
+
+	decoder := json.NewDecoder(bytes.NewReader([]byte(response.Body)))
+	decoder.UseNumber()
+	var body interface{}
+	if decoder.Decode(&body) == nil {
+		walkJSONValues(body, "$", "", func(path, key, value string) {
+			candidates = append(candidates, CorrelationRule{Variable: key, Value: value, Source: "json", Expression: path})
+		})
+	}
+
+	for _, header := range response.Header {
+		name := strings.ToLower(header.Key)
+		if name == "set-cookie" {
+			pair := strings.SplitN(strings.SplitN(header.Value, ";", 2)[0], "=", 2)
+			if len(pair) == 2 && isCorrelationValue(strings.TrimSpace(pair[1])) {
+				cookie := strings.TrimSpace(pair[0])
+				candidates = append(candidates, CorrelationRule{Variable: cookie, Value: strings.TrimSpace(pair[1]), Source: "cookie", Expression: cookie})
+			}
+			continue
+		}
+		if uncorrelatedHeaders[name] || !isCorrelationValue(header.Value) {
+			continue
+		}
+		candidates = append(candidates, CorrelationRule{Variable: header.Key, Value: header.Value, Source: "header", Expression: header.Key})
+	}
+
+	return candidates
+}
+
+// walkJSONValues calls fn for every string or long integer leaf in a decoded JSON document
+func walkJSONValues(value interface{}, path, key string, fn func(path, key, value string)) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			childKey := k
+			if strings.EqualFold(k, "id") && key != "" {
+				childKey = key + "Id"
+			}
+			walkJSONValues(v[k], path+"."+k, childKey, fn)
+		}
+	case []interface{}:
+		for i, element := range v {
+			walkJSONValues(element, fmt.Sprintf("%s[%d]", path, i), key, fn)
+		}
+	case string:
+		if isCorrelationValue(v) {
+			fn(path, key, v)
+		}
+	case json.Number:
+		// Numeric identifiers are only considered once they are long enough to be distinctive
+		if _, err := v.Int64(); err == nil && len(v.String()) >= 4 {
+			fn(path, key, v.String())
+		}
+	}
+}
+
+// textBodyCandidates finds values sent by later requests inside a non-JSON response body and builds regex rules for them
+func textBodyCandidates(response PostmanResponse, later []PostmanItem) []CorrelationRule {
+	body := response.Body
+	if body == "" || json.Valid([]byte(body)) {
+		return nil
+	}
+
+	var candidates []CorrelationRule
+	seen := map[string]bool{}
+	for _, item := range later {
+		for _, value := range requestValues(item.Request) {
+			if seen[value] || !isCorrelationValue(value) {
+				continue
+			}
+			seen[value] = true
+
+			index := indexBounded(body, value)
+			if index < 0 {
+				continue
+			}
+
+			// Anchor the pattern on the text leading up to the value on the same line
+			prefixStart := index - 40
+			if prefixStart < 0 {
+				prefixStart = 0
+			}
+			prefix := body[prefixStart:index]
+			if newline := strings.LastIndexAny(prefix, "\r\n"); newline >= 0 {
+				prefix = prefix[newline+1:]
+			}
+			if strings.TrimSpace(prefix) == "" {
+				continue
+			}
+
+			capture := `(\S+)`
+			if end := index + len(value); end < len(body) {
+				next := body[end : end+1]
+				capture = "([^" + regexp.QuoteMeta(next) + `\s]+)`
+			}
+
+			candidates = append(candidates, CorrelationRule{
+				Variable:   nameFromContext(prefix),
+				Value:      value,
+				Source:     "regex",
+				Expression: regexp.QuoteMeta(prefix) + capture,
+			})
+		}
+	}
+
+	return candidates
+}
+
+// requestValues returns the query, header, cookie and body field values sent by a request
+func requestValues(req PostmanRequest) []string {
+	var values []string
+	for _, param := range req.URL.Query {
+		values = append(values, param.Value)
+	}
+	for _, header := range req.Header {
+		if strings.EqualFold(header.Key, "Cookie") {
+			for _, cookie := range strings.Split(header.Value, ";") {
+				if pair := strings.SplitN(cookie, "=", 2); len(pair) == 2 {
+					values = append(values, strings.TrimSpace(pair[1]))
+				}
+			}
+			continue
+		}
+		values = append(values, header.Value)
+		if fields := strings.Fields(header.Value); len(fields) == 2 {
+			values = append(values, fields[1])
+		}
+	}
+	for _, pair := range strings.Split(req.Body.Raw, "&") {
+		if kv := strings.SplitN(pair, "=", 2); len(kv) == 2 {
+			values = append(values, kv[1])
+		}
+	}
+	// Numbers decode as json.Number, as in the responses, so numeric IDs sent back in a body are matched
+	decoder := json.NewDecoder(strings.NewReader(req.Body.Raw))
+	decoder.UseNumber()
+	var body interface{}
+	if decoder.Decode(&body) == nil {
+		walkJSONValues(body, "$", "", func(_, _, value string) {
+			values = append(values, value)
+		})
+	}
+	return values
+}
+
+// nameFromContext picks a variable name from the field name preceding a value, such as name="csrf_token" value=
+func nameFromContext(prefix string) string {
+	words := regexp.MustCompile(`[A-Za-z_][A-Za-z0-9_-]{2,}`).FindAllString(prefix, -1)
+	for i := len(words) - 1; i >= 0; i-- {
+		switch strings.ToLower(words[i]) {
+		case "value", "content", "name", "input", "type", "hidden", "meta":
+			continue
+		}
+		return words[i]
+	}
+	return "value"
+}
+
+// isCorrelationValue reports whether a string is distinctive enough to be treated as a token
+func isCorrelationValue(value string) bool {
+	if len(value) < minCorrelationLength || strings.ContainsAny(value, " \t\r\n") || strings.Contains(value, "{{") {
+		return false
+	}
+	switch strings.ToLower(value) {
+	case "true", "false", "null", "undefined":
+		return false
+	}
+	return true
+}
+
+// sentBefore reports whether any of the items already sent the value in their request
+func sentBefore(items []PostmanItem, value string) bool {
+	for _, item := range items {
+		if requestContains(item.Request, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// requestContains reports whether the value appears as a whole token anywhere in a request
+func requestContains(req PostmanRequest, value string) bool {
+	found := false
+
+	// The function hands every string back unchanged, so the request is only read
+	RewriteRequestStrings(&req, func(s string) string {
+		if !found && indexBounded(s, value) >= 0 {
+			found = true
+		}
+		return s
+	})
+	return found
+}
+
+// indexBounded returns the index of value in s where it is not part of a longer alphanumeric run
+func indexBounded(s, value string) int {
+	offset := 0
+	for {
+		index := strings.Index(s[offset:], value)
+		if index < 0 {
+			return -1
+		}
+		index += offset
+		end := index + len(value)
+		if (index == 0 || !isWordByte(s[index-1])) && (end == len(s) || !isWordByte(s[end])) {
+			return index
+		}
+		offset = index + 1
+	}
+}
+
+// replaceBounded replaces every bounded occurrence of value in s
+func replaceBounded(s, value, replacement string) string {
+	var out strings.Builder
+	for {
+		index := indexBounded(s, value)
+		if index < 0 {
+			out.WriteString(s)
+			return out.String()
+		}
+		out.WriteString(s[:index])
+		out.WriteString(replacement)
+		s = s[index+len(value):]
+	}
+}
+
+// isWordByte reports whether c is an ASCII letter or digit
+func isWordByte(c byte) bool {
+	return c < 0x80 && (unicode.IsLetter(rune(c)) || unicode.IsDigit(rune(c)))
+}
+
+// uniqueVariableName turns a field name into a camelCase variable name not already in use
+func uniqueVariableName(name string, used map[string]bool) string {
+	base := camelCase(name)
+	if base == "" || unicode.IsDigit(rune(base[0])) {
+		base = "value" + base
+	}
+
+	candidate := base
+	for n := 2; used[candidate]; n++ {
+		candidate = fmt.Sprintf("%s%d", base, n)
+	}
+	used[candidate] = true
+	return candidate
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCorrelateItems(t *testing.T) {
+	items := []PostmanItem{
+		{Name: "POST login", Request: PostmanRequest{Method: "POST", URL: URLFromString("https://api.example.com/login")}, Response: []PostmanResponse{{
+			Code:   200,
+			Header: []PostmanHeader{{Key: "Set-Cookie", Value: "session=s3ss10nvalue; Path=/"}, {Key: "Date", Value: "Mon, 01 Jan 2024 00:00:00 GMT"}},
+			Body:   `{"access_token":"tok_abcdef123","user":{"id":48213,"name":"bob"}}`,
+		}}},
+		{Name: "GET orders", Request: PostmanRequest{
+			Method: "GET",
+			URL:    URLFromString("https://api.example.com/users/48213/orders"),
+			Header: []PostmanHeader{{Key: "Authorization", Value: "Bearer tok_abcdef123"}, {Key: "Cookie", Value: "session=s3ss10nvalue"}},
+		}, Response: []PostmanResponse{{Code: 200, Body: `<input name="csrf" value="csrf9876zyx">`}}},
+		{Name: "POST order", Request: PostmanRequest{
+			Method: "POST",
+			URL:    URLFromString("https://api.example.com/orders"),
+			Body:   PostmanBody{Mode: "raw", Raw: "csrf=csrf9876zyx&user=482130"},
+		}},
+	}
+
+	rules := CorrelateItems(items)
+	var variables []string
+	for _, rule := range rules {
+		variables = append(variables, rule.Variable+"="+rule.Source+":"+rule.Expression)
+	}
+	want := []string{"accessToken=json:$.access_token", "userId=json:$.user.id", "session=cookie:session"}
+	if !reflect.DeepEqual(variables[:3], want) || len(variables) != 4 || rules[3].Source != "regex" {
+		t.Fatalf("rules = %q, want %q followed by a regex rule", variables, want)
+	}
+
+	orders := items[1].Request
+	if got := orders.URL.Raw; got != "https://api.example.com/users/{{userId}}/orders" {
+		t.Errorf("URL = %s, want the user id replaced", got)
+	}
+	if got := orders.Header[0].Value + "; " + orders.Header[1].Value; got != "Bearer {{accessToken}}; session={{session}}" {
+		t.Errorf("headers = %s, want the token and cookie replaced", got)
+	}
+	// Only whole values are replaced, so 482130 keeps the user id digits
+	if got := items[2].Request.Body.Raw; got != "csrf={{"+rules[3].Variable+"}}&user=482130" {
+		t.Errorf("body = %s, want only the CSRF token replaced", got)
+	}
+
+	if got := ItemCorrelationRules(items[0]); !reflect.DeepEqual(got, rules[:3]) {
+		t.Errorf("item rules = %+v, want %+v", got, rules[:3])
+	}
+}
+
+func TestParseCorrelationScript(t *testing.T) {
+	rules := []CorrelationRule{
+		{Variable: "accessToken", Source: "json", Expression: "$.data.items[0].token"},
+		{Variable: "odd", Source: "json", Expression: "$.a\"b"},
+		{Variable: "requestId", Source: "header", Expression: "X-Request-Id"},
+		{Variable: "session", Source: "cookie", Expression: "session"},
+		{Variable: "csrf", Source: "regex", Expression: `name="csrf" value="([^"]+)"`},
+	}
+	for _, rule := range rules {
+		t.Run(rule.Variable, func(t *testing.T) {
+			got, ok := parseCorrelationScript(rule.Script())
+			if !ok || got != rule {
+				t.Errorf("parseCorrelationScript(%s) = %+v, %v, want %+v", rule.Script(), got, ok, rule)
+			}
+		})
+	}
+	if _, ok := parseCorrelationScript(`pm.test("status", function () {});`); ok {
+		t.Errorf("parseCorrelationScript accepted a line it did not write")
+	}
+}
+
+func TestUniqueVariableName(t *testing.T) {
+	used := map[string]bool{}
+	var names []string
+	for _, name := range []string{"access_token", "access-token", "X-Request-Id", "2fa", "", "accessToken"} {
+		names = append(names, uniqueVariableName(name, used))
+	}
+	want := []string{"accessToken", "accessToken2", "xRequestId", "value2fa", "value", "accessToken3"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("names = %q, want %q", names, want)
+	}
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+/*
+	###################################### CURL EXPORT #################################################################
+*/
+
+// CurlScript renders requests as curl commands for a "bash" or "powershell" script
+type CurlScript struct {
+	Shell     string
+	Resolved  map[string]string
+	Defaults  map[string]string
+	variables map[string]bool
+}
+
+// NewCurlScript returns a script writer for a shell, resolving {{variables}} found in resolved and
+// leaving the others as shell variables that default to the collection variable values
+func NewCurlScript(shell string, resolved map[string]string, collection PostmanCollection) (*CurlScript, error) {
+	if shell != "bash" && shell != "powershell" {
+		return nil, fmt.Errorf("unknown shell %q, expected bash or powershell", shell)
+	}
+	defaults := map[string]string{}
+	for _, variable := range collection.Variable {
+		defaults[variable.Key] = variable.Value
+	}
+	if resolved == nil {
+		resolved = map[string]string{}
+	}
+	return &CurlScript{Shell: shell, Resolved: resolved, Defaults: defaults, variables: map[string]bool{}}, nil
+}
+
+// CurlShellForFile picks powershell for .ps1 files and bash otherwise
+func CurlShellForFile(fileName string) string {
+	if strings.EqualFold(filepath.Ext(fileName), ".ps1") {
+		return "powershell"
+	}
+	return "bash"
+}
+
+// WriteCurlScript writes one curl command per request of a collection and returns the number of commands
+func WriteCurlScript(fileName string, collection PostmanCollection, script *CurlScript) (int, error) {
+	var commands []string
+	WalkItems(collection.Item, func(folders []string, item *PostmanItem) {
+		commands = append(commands, "# "+strings.Join(append(append([]string{}, folders...), item.Name), " / ")+"\n"+script.Command(item.Request))
+	})
+
+	var out strings.Builder
+	out.WriteString(script.preamble())
+	out.WriteString(strings.Join(commands, "\n\n"))
+	out.WriteString("\n")
+
+	if err := os.WriteFile(fileName, []byte(out.String()), 0755); err != nil {
+		return 0, fmt.Errorf("error writing cURL script: %v", err)
+	}
+	return len(commands), nil
+}
+
+// Command renders a request as a multi-line curl command
+func (s *CurlScript) Command(req PostmanRequest) string {
+	program := "curl"
+	if s.Shell == "powershell" {
+		// curl is an alias of Invoke-WebRequest in Windows PowerShell
+		program = "curl.exe"
+	}
+
+	method := strings.ToUpper(req.Method)
+	if method == "" {
+		method = "GET"
+	}
+	args := []string{program}
+	if method != "GET" {
+		args = append(args, "-X "+method)
+	}
+	args = append(args, s.quote(ResolvePathVariables(req.URL)))
+
+	// Auth blocks become -u or an Authorization header, replacing any recorded Authorization header
+	authArgs := s.authArgs(req.Auth)
+	for _, header := range req.Header {
+		if header.Disabled || (len(authArgs) > 0 && strings.EqualFold(header.Key, "Authorization")) {
+			continue
+		}
+		args = append(args, "-H "+s.quote(header.Key+": "+header.Value))
+	}
+	args = append(args, authArgs...)
+
+	switch {
+	case len(req.Body.Formdata) > 0:
+		for _, field := range req.Body.Formdata {
+			if field.Disabled {
+				continue
+			}
+			// --form-string keeps values starting with @ or < from being read as files
+			if field.Type == "file" {
+				args = append(args, "-F "+s.quote(field.Key+"=@"+field.Src))
+			} else {
+				args = append(args, "--form-string "+s.quote(field.Key+"="+field.Value))
+			}
+		}
+	case len(req.Body.Urlencoded) > 0:
+		for _, field := range req.Body.Urlencoded {
+			if !field.Disabled {
+				args = append(args, "--data-urlencode "+s.quote(field.Key+"="+field.Value))
+			}
+		}
+	case req.Body.Raw != "":
+		args = append(args, "--data-raw "+s.quote(req.Body.Raw))
+	}
+
+	continuation := " \\\n  "
+	if s.Shell == "powershell" {
+		continuation = " `\n  "
+	}
+	return strings.Join(args, continuation)
+}
+
+// authArgs renders a Basic auth block as -u and a Bearer auth block as an Authorization header
+func (s *CurlScript) authArgs(auth *PostmanAuth) []string {
+	if auth == nil || (auth.Type != "basic" && auth.Type != "bearer") {
+		return nil
+	}
+	if auth.Type == "basic" {
+		if username, password, ok := basicCredentials(auth); ok {
+			return []string{"-u " + s.quote(username+":"+password)}
+		}
+	}
+	return []string{"-H " + s.quote("Authorization: "+authorizationValue(auth))}
+}
+
+// quote quotes a value for the shell, resolving known {{variables}} and referencing the rest as shell variables
+func (s *CurlScript) quote(value string) string {
+	var parts []string
+	last := 0
+	for _, match := range variableRegex.FindAllStringSubmatchIndex(value, -1) {
+		name := strings.TrimSpace(value[match[2]:match[3]])
+		if resolved, ok := s.Resolved[name]; ok {
+			parts = append(parts, s.literal(value[last:match[0]]+resolved))
+		} else {
+			parts = append(parts, s.literal(value[last:match[0]]), s.reference(name))
+		}
+		last = match[1]
+	}
+	parts = append(parts, s.literal(value[last:]))
+
+	if s.Shell == "powershell" {
+		return `"` + strings.Join(parts, "") + `"`
+	}
+	// Adjacent quoted strings join into one word in bash
+	var words []string
+	for _, part := range parts {
+		if part != "''" {
+			words = append(words, part)
+		}
+	}
+	if len(words) == 0 {
+		return "''"
+	}
+	return strings.Join(words, "")
+}
+
+// literal quotes text so the shell passes it through unchanged
+func (s *CurlScript) literal(text string) string {
+	if s.Shell == "powershell" {
+		// Inside a double quoted PowerShell string only the backtick, $ and " are special
+		return strings.NewReplacer("`", "``", "$", "`$", `"`, "`\"").Replace(text)
+	}
+	return "'" + strings.ReplaceAll(text, "'", `'\''`) + "'"
+}
+
+// reference returns a shell variable reference for a Postman variable and records it for the preamble
+func (s *CurlScript) reference(name string) string {
+	variable := shellVariableName(name)
+	s.variables[name] = true
+	if s.Shell == "powershell" {
+		return "$($" + variable + ")"
+	}
+	return `"${` + variable + `}"`
+}
+
+// preamble declares the referenced variables, reading them from the environment with the collection values as defaults
+func (s *CurlScript) preamble() string {
+	names := make([]string, 0, len(s.variables))
+	for name := range s.variables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var out strings.Builder
+	if s.Shell == "powershell" {
+		out.WriteString("# Generated by go2postman\n")
+		if len(names) > 0 {
+			out.WriteString("\n# Variables are read from the environment, falling back to the collection values\n")
+		}
+		for _, name := range names {
+			variable := shellVariableName(name)
+			fmt.Fprintf(&out, "$%s = if ($env:%s) { $env:%s } else { %s }\n", variable, variable, variable, s.powershellString(s.Defaults[name]))
+		}
+	} else {
+		out.WriteString("#!/usr/bin/env bash\n# Generated by go2postman\n")
+		if len(names) > 0 {
+			out.WriteString("\n# Variables are read from the environment, falling back to the collection values\n")
+		}
+		for _, name := range names {
+			variable := shellVariableName(name)
+			fmt.Fprintf(&out, "%s=\"${%s:-%s}\"\n", variable, variable, strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", `\$`, "`", "\\`", "}", `\}`).Replace(s.Defaults[name]))
+		}
+	}
+	out.WriteString("\n")
+	return out.String()
+}
+
+// powershellString quotes a PowerShell string literal
+func (s *CurlScript) powershellString(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
+
+// shellVariableName turns a Postman variable name into a valid shell variable name
+func shellVariableName(name string) string {
+	variable := nonVariableCharRegex.ReplaceAllString(name, "_")
+	if variable == "" || (variable[0] >= '0' && variable[0] <= '9') {
+		variable = "_" + variable
+	}
+	return variable
+}
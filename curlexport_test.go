@@ -0,0 +1,91 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCurlScriptCommand(t *testing.T) {
+	collection := PostmanCollection{Variable: []PostmanVariable{{Key: "token", Value: "abc"}}}
+	tests := []struct {
+		name  string
+		shell string
+		req   PostmanRequest
+		want  []string
+	}{
+		{
+			name:  "form fields starting with @ or < are strings",
+			shell: "bash",
+			req: PostmanRequest{Method: "POST", URL: URLFromString("https://api.example.com/profile"), Body: PostmanBody{Mode: "formdata", Formdata: []PostmanFormParam{
+				{Key: "handle", Value: "@alice"},
+				{Key: "avatar", Value: "<svg/>"},
+				{Key: "photo", Type: "file", Src: "/tmp/photo.png"},
+				{Key: "skipped", Value: "1", Disabled: true},
+			}}},
+			want: []string{
+				"curl",
+				"-X POST",
+				"'https://api.example.com/profile'",
+				"--form-string 'handle=@alice'",
+				"--form-string 'avatar=<svg/>'",
+				"-F 'photo=@/tmp/photo.png'",
+			},
+		},
+		{
+			name:  "quotes and unresolved variables in bash",
+			shell: "bash",
+			req: PostmanRequest{Method: "GET", URL: URLFromString("https://api.example.com/me"), Header: []PostmanHeader{
+				{Key: "Authorization", Value: "Bearer {{token}}"},
+				{Key: "X-Note", Value: "it's"},
+			}},
+			want: []string{
+				"curl",
+				"'https://api.example.com/me'",
+				`-H 'Authorization: Bearer '"${token}"`,
+				`-H 'X-Note: it'\''s'`,
+			},
+		},
+		{
+			name:  "PowerShell calls curl.exe and escapes $",
+			shell: "powershell",
+			req:   PostmanRequest{Method: "POST", URL: URLFromString("https://api.example.com/items"), Body: PostmanBody{Mode: "raw", Raw: `{"price":"$5"}`}},
+			want: []string{
+				"curl.exe",
+				"-X POST",
+				`"https://api.example.com/items"`,
+				"--data-raw \"{`\"price`\":`\"`$5`\"}\"",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			script, err := NewCurlScript(tt.shell, nil, collection)
+			if err != nil {
+				t.Fatal(err)
+			}
+			continuation := " \\\n  "
+			if tt.shell == "powershell" {
+				continuation = " `\n  "
+			}
+			if got := strings.Split(script.Command(tt.req), continuation); strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("command =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestCurlScriptPreamble(t *testing.T) {
+	collection := PostmanCollection{Variable: []PostmanVariable{{Key: "base-url", Value: `https://a/$x"`}}}
+	script, err := NewCurlScript("bash", nil, collection)
+	if err != nil {
+		t.Fatal(err)
+	}
+	script.Command(PostmanRequest{Method: "GET", URL: URLFromString("{{base-url}}/users")})
+
+	want := "#!/usr/bin/env bash\n# Generated by go2postman\n\n# Variables are read from the environment, falling back to the collection values\n" +
+		`base_url="${base_url:-https://a/\$x\"}"` + "\n\n"
+	if got := script.preamble(); got != want {
+		t.Errorf("preamble = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+)
+
+/*
+	###################################### DE-DUPLICATING REQUESTS #####################################################
+*/
+
+// DedupeSummary records how many duplicates were merged into the request kept for an endpoint
+type DedupeSummary struct {
+	Endpoint string
+	Merged   int
+}
+
+// DedupeItems merges equivalent requests, keeping the "first" or most "complete" instance of each
+//
+// The equivalence key is always the method, host and normalised path; keys may add "query" (the sorted query
+// parameter names) and "body" (the JSON key shape of the body). Distinct bodies and responses of the
+// merged variants are kept as saved examples on the surviving request; a variant without a response is only
+// kept when its body differs from those already kept.
+func DedupeItems(items []PostmanItem, keys []string, keep string) ([]PostmanItem, []DedupeSummary, error) {
+	withQuery, withBody := false, false
+	for _, key := range keys {
+		switch strings.TrimSpace(key) {
+		case "path", "":
+		case "query":
+			withQuery = true
+		case "body":
+			withBody = true
+		default:
+			return nil, nil, fmt.Errorf("unknown de-duplication key %q, expected path, query or body", key)
+		}
+	}
+	if keep != "first" && keep != "complete" {
+		return nil, nil, fmt.Errorf("unknown de-duplication keep mode %q, expected first or complete", keep)
+	}
+
+	type group struct {
+		endpoint string
+		members  []int
+	}
+	var groups []*group
+	byKey := map[string]*group{}
+	for i, item := range items {
+		// Intruder templates are never merged, their payload positions differ
+		key := fmt.Sprintf("#%d", i)
+		if len(item.IntruderValues) == 0 {
+			key = EquivalenceKey(item.Request, withQuery, withBody)
+		}
+		g, ok := byKey[key]
+		if !ok {
+			g = &group{endpoint: EndpointLabel(item.Request)}
+			byKey[key] = g
+			groups = append(groups, g)
+		}
+		g.members = append(g.members, i)
+	}
+
+	var summaries []DedupeSummary
+	kept := make([]bool, len(items))
+	for _, g := range groups {
+		best := g.members[0]
+		if keep == "complete" {
+			for _, member := range g.members[1:] {
+				if completeness(items[member]) > completeness(items[best]) {
+					best = member
+				}
+			}
+		}
+
+		survivor := items[best]
+		seen := map[string]bool{variantKey(survivor.Request.Body.Raw, survivor.Response): true}
+		seenBodies := map[string]bool{survivor.Request.Body.Raw: true}
+		for _, member := range g.members {
+			if member == best {
+				continue
+			}
+			variant := items[member]
+			vkey := variantKey(variant.Request.Body.Raw, variant.Response)
+			// Variants without a response are only worth an example when they sent a body not seen yet
+			if seen[vkey] || (len(variant.Response) == 0 && seenBodies[variant.Request.Body.Raw]) {
+				continue
+			}
+			seen[vkey] = true
+			seenBodies[variant.Request.Body.Raw] = true
+
+			if len(variant.Response) > 0 {
+				survivor.Response = append(survivor.Response, variant.Response...)
+			} else {
+				request := CloneRequest(variant.Request)
+				survivor.Response = append(survivor.Response, PostmanResponse{
+					Name:            fmt.Sprintf("Variant of %s", variant.Name),
+					OriginalRequest: &request,
+					Header:          []PostmanHeader{},
+				})
+			}
+		}
+		// The surviving request takes the position of the first instance
+		items[g.members[0]] = survivor
+		kept[g.members[0]] = true
+
+		if len(g.members) > 1 {
+			summaries = append(summaries, DedupeSummary{Endpoint: g.endpoint, Merged: len(g.members) - 1})
+		}
+	}
+
+	var result []PostmanItem
+	for i := range items {
+		if kept[i] {
+			result = append(result, items[i])
+		}
+	}
+	return result, summaries, nil
+}
+
+// EquivalenceKey returns the key requests are compared by: method, host and normalised path, plus optional query names and body shape
+func EquivalenceKey(req PostmanRequest, withQuery, withBody bool) string {
+	key := strings.ToUpper(req.Method) + " " + NormalisedPath(req.URL)
+
+	if withQuery {
+		var names []string
+		for _, param := range req.URL.Query {
+			names = append(names, param.Key)
+		}
+		sort.Strings(names)
+		key += "?" + strings.Join(names, "&")
+	}
+
+	if withBody {
+		key += " " + BodyShape(req.Body.Raw)
+	}
+	return key
+}
+
+// EndpointLabel returns a readable method and normalised path for reports
+func EndpointLabel(req PostmanRequest) string {
+	return strings.ToUpper(req.Method) + " " + NormalisedPath(req.URL)
+}
+
+// NormalisedPath returns the lower case host and port with a cleaned, percent-decoded path
+func NormalisedPath(u PostmanURL) string {
+	host := strings.ToLower(JoinHostPort(strings.Join(u.Host, "."), u.Port, u.Protocol))
+
+	var segments []string
+	for _, segment := range u.Path {
+		if decoded, err := url.PathUnescape(segment); err == nil {
+			segment = decoded
+		}
+		if segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+	return host + path.Clean("/"+strings.Join(segments, "/"))
+}
+
+// BodyShape describes the keys of a JSON or form body without their values
+func BodyShape(body string) string {
+	if schema := InferJSONSchemaFromBody(body); schema != nil {
+		return schemaShape(schema)
+	}
+
+	// Fall back to the sorted field names of a form encoded body
+	values, err := url.ParseQuery(strings.TrimSpace(body))
+	if err != nil || len(values) == 0 || strings.ContainsAny(body, "\r\n<{") {
+		return ""
+	}
+	var names []string
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return "form(" + strings.Join(names, ",") + ")"
+}
+
+// schemaShape renders the object keys and array nesting of a schema, such as {a,b:{c},d:[{e}]}
+func schemaShape(schema *JSONSchema) string {
+	switch {
+	case schema.Properties != nil:
+		var keys []string
+		for key := range schema.Properties {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for i, key := range keys {
+			if child := schemaShape(schema.Properties[key]); child != "" {
+				keys[i] = key + ":" + child
+			}
+		}
+		return "{" + strings.Join(keys, ",") + "}"
+	case schema.Items != nil:
+		return "[" + schemaShape(schema.Items) + "]"
+	case schema.HasType("array"):
+		return "[]"
+	}
+	return ""
+}
+
+// completeness scores how much a request and its recorded response carry
+func completeness(item PostmanItem) int {
+	score := len(item.Request.Header) + len(item.Request.URL.Query) + len(item.Request.Body.Raw)
+	if len(item.Response) > 0 {
+		score += 1000
+	}
+	if item.Request.Auth != nil {
+		score += 10
+	}
+	return score
+}
+
+// variantKey identifies a variant by its request body and recorded response bodies
+func variantKey(body string, responses []PostmanResponse) string {
+	key := body
+	for _, response := range responses {
+		key += "\x00" + fmt.Sprint(response.Code) + "\x00" + response.Body
+	}
+	return key
+}
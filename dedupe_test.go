@@ -0,0 +1,98 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDedupeItems(t *testing.T) {
+	item := func(name, rawURL, body string, code int) PostmanItem {
+		item := PostmanItem{Name: name, Request: PostmanRequest{Method: "POST", URL: URLFromString(rawURL), Body: PostmanBody{Mode: "raw", Raw: body}}}
+		if code != 0 {
+			item.Response = []PostmanResponse{{Code: code, Body: name}}
+		}
+		return item
+	}
+	recorded := func() []PostmanItem {
+		return []PostmanItem{
+			item("first", "https://api.example.com/users?page=1", `{"name":"a"}`, 0),
+			item("other", "https://api.example.com/orders", "", 201),
+			item("same body", "https://API.example.com/users/?page=2", `{"name":"a"}`, 0),
+			item("new body", "https://api.example.com/users", `{"name":"b","age":3}`, 0),
+			item("answered", "https://api.example.com/users?sort=name", `{"name":"c"}`, 200),
+		}
+	}
+
+	tests := []struct {
+		name      string
+		keys      []string
+		keep      string
+		want      []string
+		summaries []DedupeSummary
+	}{
+		{
+			name:      "path keeps the first request",
+			keys:      []string{"path"},
+			keep:      "first",
+			want:      []string{"first: Variant of new body, answered", "other: other"},
+			summaries: []DedupeSummary{{Endpoint: "POST api.example.com/users", Merged: 3}},
+		},
+		{
+			name:      "complete keeps the request with a response in the first position",
+			keys:      []string{"path"},
+			keep:      "complete",
+			want:      []string{"answered: answered, Variant of first, Variant of new body", "other: other"},
+			summaries: []DedupeSummary{{Endpoint: "POST api.example.com/users", Merged: 3}},
+		},
+		{
+			name:      "query and body split the endpoint",
+			keys:      []string{"path", "query", "body"},
+			keep:      "first",
+			want:      []string{"first: ", "other: other", "new body: ", "answered: answered"},
+			summaries: []DedupeSummary{{Endpoint: "POST api.example.com/users", Merged: 1}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items, summaries, err := DedupeItems(recorded(), tt.keys, tt.keep)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, item := range items {
+				var examples []string
+				for _, response := range item.Response {
+					examples = append(examples, response.Name+response.Body)
+				}
+				got = append(got, item.Name+": "+strings.Join(examples, ", "))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("items = %q, want %q", got, tt.want)
+			}
+			if !reflect.DeepEqual(summaries, tt.summaries) {
+				t.Errorf("summaries = %+v, want %+v", summaries, tt.summaries)
+			}
+		})
+	}
+
+	if _, _, err := DedupeItems(recorded(), []string{"headers"}, "first"); err == nil {
+		t.Errorf("DedupeItems accepted an unknown key")
+	}
+}
+
+func TestBodyShape(t *testing.T) {
+	tests := []struct {
+		body, want string
+	}{
+		{`{"b":1,"a":{"c":[{"e":true}],"d":[]}}`, "{a:{c:[{e}],d:[]},b}"},
+		{"name=bob&age=3", "form(age,name)"},
+		{"<xml/>", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := BodyShape(tt.body); got != tt.want {
+			t.Errorf("BodyShape(%q) = %q, want %q", tt.body, got, tt.want)
+		}
+	}
+}
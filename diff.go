@@ -0,0 +1,380 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+/*
+	###################################### DIFFING CAPTURES ############################################################
+*/
+
+// SetDiff lists the names added and removed between two captures of an endpoint
+type SetDiff struct {
+	Added   []string `json:"added,omitempty"`
+	Removed []string `json:"removed,omitempty"`
+}
+
+// ShapeDiff records a change in the JSON body shape of an endpoint
+type ShapeDiff struct {
+	Old string `json:"old"`
+	New string `json:"new"`
+}
+
+// EndpointDiff describes how one endpoint changed between two captures
+type EndpointDiff struct {
+	Endpoint     string     `json:"endpoint"`
+	Query        *SetDiff   `json:"query,omitempty"`
+	Headers      *SetDiff   `json:"headers,omitempty"`
+	Auth         *SetDiff   `json:"auth,omitempty"`
+	RequestBody  *ShapeDiff `json:"requestBody,omitempty"`
+	ResponseBody *ShapeDiff `json:"responseBody,omitempty"`
+}
+
+// DiffReport is the endpoint level comparison of two captures
+type DiffReport struct {
+	Old       string         `json:"old"`
+	New       string         `json:"new"`
+	Added     []string       `json:"added"`
+	Removed   []string       `json:"removed"`
+	Changed   []EndpointDiff `json:"changed"`
+	Unchanged int            `json:"unchanged"`
+}
+
+// endpointProfile gathers what the requests to one endpoint sent and received
+type endpointProfile struct {
+	query, headers, auth        map[string]bool
+	requestBody, responseBodies map[string]bool
+}
+
+// RunDiff implements the diff command: go2postman diff [-format text|json|markdown] [-o file] <old> <new>
+func RunDiff(args []string) error {
+	flags := flag.NewFlagSet("diff", flag.ContinueOnError)
+	format := flags.String("format", "text", `This option sets the report format, "text", "json" or "markdown".`)
+	output := flags.String("o", "", `This option writes the report to a file instead of standard output.`)
+	ignoreHost := flags.Bool("ignore-host", false, `This option aligns endpoints by method and templated path only, for comparing captures of different hosts.`)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "\n    	Usage: ./go2postman diff [options] <old collection.json|burp-dir> <new collection.json|burp-dir>\n\n")
+		flags.VisitAll(func(f *flag.Flag) {
+			fmt.Fprintf(flags.Output(), "\t-%s\t | %s\n", f.Name, f.Usage)
+		})
+		fmt.Fprintf(flags.Output(), "\n")
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 2 {
+		flags.Usage()
+		return fmt.Errorf("diff needs exactly two inputs, got %d", flags.NArg())
+	}
+
+	oldItems, err := LoadItems(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	newItems, err := LoadItems(flags.Arg(1))
+	if err != nil {
+		return err
+	}
+
+	report := DiffItems(oldItems, newItems, *ignoreHost)
+	report.Old, report.New = flags.Arg(0), flags.Arg(1)
+
+	out := io.Writer(os.Stdout)
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return fmt.Errorf("error creating report file: %v", err)
+		}
+		defer file.Close()
+		out = file
+	}
+
+	switch *format {
+	case "text":
+		WriteDiffText(out, report)
+	case "markdown", "md":
+		WriteDiffMarkdown(out, report)
+	case "json":
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	default:
+		return fmt.Errorf("unknown diff format %q, expected text, json or markdown", *format)
+	}
+	return nil
+}
+
+// LoadItems returns the requests of a Postman collection file, a Burp XML or cURL file, or a directory of them
+func LoadItems(input string) ([]PostmanItem, error) {
+	info, err := os.Stat(input)
+	if err != nil {
+		return nil, err
+	}
+	// Progress and warnings go to standard error so a report on standard output stays clean
+	if info.IsDir() {
+		return ProcessDirectory(input, os.Stderr)
+	}
+
+	switch strings.ToLower(filepath.Ext(input)) {
+	case ".json":
+		collection, err := LoadCollection(input)
+		if err != nil {
+			return nil, err
+		}
+		var items []PostmanItem
+		WalkItems(collection.Item, func(_ []string, item *PostmanItem) {
+			items = append(items, *item)
+		})
+		return items, nil
+	case ".xml":
+		return ProcessBurpXML(input, os.Stderr)
+	default:
+		return ProcessCurlFile(input, os.Stderr)
+	}
+}
+
+// DiffItems aligns two sets of requests by method and templated path and compares each endpoint
+func DiffItems(oldItems, newItems []PostmanItem, ignoreHost bool) DiffReport {
+	oldProfiles := profileEndpoints(oldItems, ignoreHost)
+	newProfiles := profileEndpoints(newItems, ignoreHost)
+
+	report := DiffReport{Added: []string{}, Removed: []string{}, Changed: []EndpointDiff{}}
+	for _, endpoint := range sortedEndpoints(oldProfiles) {
+		if _, ok := newProfiles[endpoint]; !ok {
+			report.Removed = append(report.Removed, endpoint)
+		}
+	}
+	for _, endpoint := range sortedEndpoints(newProfiles) {
+		before, ok := oldProfiles[endpoint]
+		if !ok {
+			report.Added = append(report.Added, endpoint)
+			continue
+		}
+		after := newProfiles[endpoint]
+
+		diff := EndpointDiff{
+			Endpoint:     endpoint,
+			Query:        diffSets(before.query, after.query),
+			Headers:      diffSets(before.headers, after.headers),
+			Auth:         diffSets(before.auth, after.auth),
+			RequestBody:  diffShapes(before.requestBody, after.requestBody),
+			ResponseBody: diffShapes(before.responseBodies, after.responseBodies),
+		}
+		if diff.Query == nil && diff.Headers == nil && diff.Auth == nil && diff.RequestBody == nil && diff.ResponseBody == nil {
+			report.Unchanged++
+			continue
+		}
+		report.Changed = append(report.Changed, diff)
+	}
+	return report
+}
+
+// profileEndpoints groups requests by endpoint and collects their query names, header names, auth types and body shapes
+func profileEndpoints(items []PostmanItem, ignoreHost bool) map[string]*endpointProfile {
+	profiles := map[string]*endpointProfile{}
+	for _, item := range items {
+		endpoint := TemplatedPath(item.Request)
+		if ignoreHost {
+			method, target, _ := strings.Cut(endpoint, " ")
+			if slash := strings.Index(target, "/"); slash >= 0 {
+				target = target[slash:]
+			}
+			endpoint = method + " " + target
+		}
+
+		profile, ok := profiles[endpoint]
+		if !ok {
+			profile = &endpointProfile{query: map[string]bool{}, headers: map[string]bool{}, auth: map[string]bool{},
+				requestBody: map[string]bool{}, responseBodies: map[string]bool{}}
+			profiles[endpoint] = profile
+		}
+
+		for _, param := range item.Request.URL.Query {
+			profile.query[param.Key] = true
+		}
+		for _, header := range item.Request.Header {
+			profile.headers[strings.ToLower(header.Key)] = true
+		}
+		for _, auth := range authTypes(item.Request) {
+			profile.auth[auth] = true
+		}
+		if shape := BodyShape(item.Request.Body.Raw); shape != "" {
+			profile.requestBody[shape] = true
+		}
+		for _, response := range item.Response {
+			if shape := BodyShape(response.Body); shape != "" {
+				profile.responseBodies[shape] = true
+			}
+		}
+	}
+	return profiles
+}
+
+// authTypes names the ways a request authenticates: its auth block, Authorization scheme, API key headers and cookies
+func authTypes(req PostmanRequest) []string {
+	var types []string
+	if req.Auth != nil && req.Auth.Type != "" {
+		types = append(types, strings.ToLower(req.Auth.Type))
+	}
+	for _, header := range req.Header {
+		name := strings.ToLower(header.Key)
+		switch {
+		case name == "authorization":
+			// A bare credential, often a {{variable}} after -redact, has no scheme and is sent like an API key
+			scheme, _, found := strings.Cut(strings.TrimSpace(header.Value), " ")
+			if !found || strings.HasPrefix(scheme, "{{") {
+				types = append(types, "api-key:"+name)
+				continue
+			}
+			types = append(types, strings.ToLower(scheme))
+		case name == "cookie":
+			types = append(types, "cookie")
+		case apiKeyHeaders[name]:
+			types = append(types, "api-key:"+name)
+		}
+	}
+	return types
+}
+
+// diffSets returns the names only in after as added and only in before as removed, or nil when they are the same
+func diffSets(before, after map[string]bool) *SetDiff {
+	diff := &SetDiff{}
+	for _, name := range sortedKeys(after) {
+		if !before[name] {
+			diff.Added = append(diff.Added, name)
+		}
+	}
+	for _, name := range sortedKeys(before) {
+		if !after[name] {
+			diff.Removed = append(diff.Removed, name)
+		}
+	}
+	if len(diff.Added) == 0 && len(diff.Removed) == 0 {
+		return nil
+	}
+	return diff
+}
+
+// diffShapes compares the body shapes seen for an endpoint, or returns nil when they are the same
+func diffShapes(before, after map[string]bool) *ShapeDiff {
+	oldShape := strings.Join(sortedKeys(before), " | ")
+	newShape := strings.Join(sortedKeys(after), " | ")
+	if oldShape == newShape {
+		return nil
+	}
+	return &ShapeDiff{Old: oldShape, New: newShape}
+}
+
+// sortedKeys returns the keys of a set in sorted order
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// sortedEndpoints returns the endpoints of a set of profiles in sorted order
+func sortedEndpoints(profiles map[string]*endpointProfile) []string {
+	endpoints := make([]string, 0, len(profiles))
+	for endpoint := range profiles {
+		endpoints = append(endpoints, endpoint)
+	}
+	sort.Strings(endpoints)
+	return endpoints
+}
+
+// WriteDiffText writes a diff report as plain text, with + for added, - for removed and ~ for changed endpoints
+func WriteDiffText(out io.Writer, report DiffReport) {
+	fmt.Fprintf(out, "Comparing %s -> %s\n\n", report.Old, report.New)
+	for _, endpoint := range report.Added {
+		fmt.Fprintf(out, "+ %s\n", endpoint)
+	}
+	for _, endpoint := range report.Removed {
+		fmt.Fprintf(out, "- %s\n", endpoint)
+	}
+	for _, diff := range report.Changed {
+		fmt.Fprintf(out, "~ %s\n", diff.Endpoint)
+		for _, line := range describeEndpointDiff(diff) {
+			fmt.Fprintf(out, "    %s\n", strings.ReplaceAll(line, "`", ""))
+		}
+	}
+	fmt.Fprintf(out, "\n%d added, %d removed, %d changed, %d unchanged\n", len(report.Added), len(report.Removed), len(report.Changed), report.Unchanged)
+}
+
+// WriteDiffMarkdown writes a diff report as a Markdown document
+func WriteDiffMarkdown(out io.Writer, report DiffReport) {
+	fmt.Fprintf(out, "# Endpoint changes\n\n")
+	fmt.Fprintf(out, "`%s` → `%s`: %d added, %d removed, %d changed, %d unchanged\n", report.Old, report.New,
+		len(report.Added), len(report.Removed), len(report.Changed), report.Unchanged)
+
+	for _, section := range []struct {
+		title     string
+		endpoints []string
+	}{{"Added", report.Added}, {"Removed", report.Removed}} {
+		if len(section.endpoints) == 0 {
+			continue
+		}
+		fmt.Fprintf(out, "\n## %s\n\n", section.title)
+		for _, endpoint := range section.endpoints {
+			fmt.Fprintf(out, "- `%s`\n", endpoint)
+		}
+	}
+
+	if len(report.Changed) > 0 {
+		fmt.Fprintf(out, "\n## Changed\n")
+		for _, diff := range report.Changed {
+			fmt.Fprintf(out, "\n### `%s`\n\n", diff.Endpoint)
+			for _, line := range describeEndpointDiff(diff) {
+				fmt.Fprintf(out, "- %s\n", line)
+			}
+		}
+	}
+}
+
+// describeEndpointDiff renders each change of an endpoint as a line, with names and shapes in Markdown code spans
+func describeEndpointDiff(diff EndpointDiff) []string {
+	quote := func(names []string, sign string) []string {
+		quoted := make([]string, len(names))
+		for i, name := range names {
+			quoted[i] = sign + "`" + name + "`"
+		}
+		return quoted
+	}
+
+	var lines []string
+	for _, set := range []struct {
+		label string
+		diff  *SetDiff
+	}{{"query", diff.Query}, {"headers", diff.Headers}, {"auth", diff.Auth}} {
+		if set.diff != nil {
+			changes := append(quote(set.diff.Added, "+"), quote(set.diff.Removed, "-")...)
+			lines = append(lines, fmt.Sprintf("%s: %s", set.label, strings.Join(changes, " ")))
+		}
+	}
+	for _, shape := range []struct {
+		label string
+		diff  *ShapeDiff
+	}{{"request body", diff.RequestBody}, {"response body", diff.ResponseBody}} {
+		if shape.diff != nil {
+			lines = append(lines, fmt.Sprintf("%s: `%s` -> `%s`", shape.label, orNone(shape.diff.Old), orNone(shape.diff.New)))
+		}
+	}
+	return lines
+}
+
+// orNone returns "(none)" for an empty shape
+func orNone(shape string) string {
+	if shape == "" {
+		return "(none)"
+	}
+	return shape
+}
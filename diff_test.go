@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDiffItems(t *testing.T) {
+	item := func(method, rawURL, body string) PostmanItem {
+		return PostmanItem{Request: PostmanRequest{Method: method, URL: URLFromString(rawURL), Body: PostmanBody{Mode: "raw", Raw: body}}}
+	}
+	oldItems := []PostmanItem{
+		item("GET", "https://api.example.com/users?page=1", ""),
+		item("POST", "https://api.example.com/users", `{"name":"a"}`),
+		item("DELETE", "https://api.example.com/users/1", ""),
+	}
+	newItems := []PostmanItem{
+		item("GET", "https://api.example.com/users?page=1&sort=name", ""),
+		item("POST", "https://api.example.com/users", `{"name":"b"}`),
+		item("GET", "https://api.example.com/health", ""),
+	}
+
+	report := DiffItems(oldItems, newItems, false)
+	if !reflect.DeepEqual(report.Added, []string{"GET api.example.com/health"}) {
+		t.Errorf("added = %q", report.Added)
+	}
+	if !reflect.DeepEqual(report.Removed, []string{"DELETE api.example.com/users/{}"}) {
+		t.Errorf("removed = %q", report.Removed)
+	}
+	if report.Unchanged != 1 {
+		t.Errorf("unchanged = %d, want the POST whose body keeps its shape", report.Unchanged)
+	}
+	if len(report.Changed) != 1 || report.Changed[0].Query == nil || !reflect.DeepEqual(report.Changed[0].Query.Added, []string{"sort"}) {
+		t.Errorf("changed = %+v, want the added sort parameter", report.Changed)
+	}
+}
+
+func TestProcessBurpXMLWarnsToProgress(t *testing.T) {
+	fileName := writeBurpXML(t,
+		burpTestItem{url: "https://api.example.com/broken", request: "GET /broken HTTP/1.1\r\nBad header\r\n\r\n"},
+		burpTestItem{url: "https://api.example.com/ok", request: "GET /ok HTTP/1.1\r\nHost: api.example.com\r\n\r\n"},
+	)
+
+	var progress bytes.Buffer
+	items, err := ProcessBurpXML(fileName, &progress)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 1 || items[0].Request.URL.Raw != "https://api.example.com/ok" {
+		t.Errorf("items = %+v, want only the parsable request", items)
+	}
+	if !strings.Contains(progress.String(), "Could not parse HTTP request for item 1") {
+		t.Errorf("progress = %q, want the warning for item 1", progress.String())
+	}
+}
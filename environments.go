@@ -0,0 +1,242 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+/*
+	################################## BASE URL VARIABLES AND ENVIRONMENTS #############################################
+*/
+
+// BaseURL represents a recorded origin that requests now reach through a variable
+type BaseURL struct {
+	Variable string
+	Host     string
+	Origin   string
+}
+
+// nonVariableCharRegex matches characters that are not safe in variable names across the export formats
+var nonVariableCharRegex = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+
+// ExtractBaseURLs replaces the scheme, host and port of every request with {{baseUrl}} ("single") or {{<host>_baseUrl}} ("per-host")
+//
+// In single mode only the most common origin becomes {{baseUrl}}; requests to any other origin keep
+// their literal URL, and those origins are returned so the caller can report them.
+func ExtractBaseURLs(items []PostmanItem, mode string) ([]BaseURL, []string, error) {
+	if mode != "single" && mode != "per-host" {
+		return nil, nil, fmt.Errorf("unknown base URL mode %q, expected single or per-host", mode)
+	}
+
+	// Count origins in first seen order
+	var origins []string
+	hosts := map[string]string{}
+	counts := map[string]int{}
+	WalkItems(items, func(_ []string, item *PostmanItem) {
+		origin, host := requestOrigin(item.Request.URL)
+		if origin == "" {
+			return
+		}
+		if counts[origin] == 0 {
+			origins = append(origins, origin)
+			hosts[origin] = host
+		}
+		counts[origin]++
+	})
+
+	variables := map[string]string{}
+	var bases []BaseURL
+	var literal []string
+	if mode == "single" {
+		best := ""
+		for _, origin := range origins {
+			if best == "" || counts[origin] > counts[best] {
+				best = origin
+			}
+		}
+		if best != "" {
+			variables[best] = "baseUrl"
+			bases = append(bases, BaseURL{Variable: "baseUrl", Host: hosts[best], Origin: best})
+		}
+		for _, origin := range origins {
+			if origin != best {
+				literal = append(literal, origin)
+			}
+		}
+	} else {
+		used := map[string]bool{}
+		for _, origin := range origins {
+			name := strings.Trim(nonVariableCharRegex.ReplaceAllString(hosts[origin], "_"), "_") + "_baseUrl"
+			// The same host over http and https needs two variables
+			if used[name] {
+				name = strings.TrimSuffix(name, "_baseUrl") + "_" + strings.SplitN(origin, ":", 2)[0] + "_baseUrl"
+			}
+			used[name] = true
+			variables[origin] = name
+			bases = append(bases, BaseURL{Variable: name, Host: hosts[origin], Origin: origin})
+		}
+	}
+
+	WalkItems(items, func(_ []string, item *PostmanItem) {
+		u := &item.Request.URL
+		origin, _ := requestOrigin(*u)
+		name, ok := variables[origin]
+		if !ok {
+			return
+		}
+		u.Protocol = ""
+		u.Port = ""
+		u.Host = []string{"{{" + name + "}}"}
+		u.Raw = BuildRawURL(*u)
+	})
+
+	return bases, literal, nil
+}
+
+// requestOrigin returns scheme://host[:port] and the host[:port] of a URL, or empty strings when it has no host
+func requestOrigin(u PostmanURL) (string, string) {
+	host := strings.Join(u.Host, ".")
+	if host == "" || u.Protocol == "" || strings.Contains(host, "{{") {
+		return "", ""
+	}
+	hostPort := JoinHostPort(host, u.Port, u.Protocol)
+	return strings.ToLower(u.Protocol) + "://" + hostPort, hostPort
+}
+
+// BaseURLVariables returns collection variables holding the recorded origins
+func BaseURLVariables(bases []BaseURL) []PostmanVariable {
+	var variables []PostmanVariable
+	for _, base := range bases {
+		variables = append(variables, PostmanVariable{
+			Key:         base.Variable,
+			Value:       base.Origin,
+			Type:        "string",
+			Description: fmt.Sprintf("Recorded origin of %s", base.Host),
+		})
+	}
+	return variables
+}
+
+// ParseEnvironmentRules parses rules such as "staging:api.example.com=https://staging.example.com,prod:..." into values per environment
+func ParseEnvironmentRules(rules string) (map[string]map[string]string, []string, error) {
+	values := map[string]map[string]string{}
+	var names []string
+	for _, rule := range strings.Split(rules, ",") {
+		rule = strings.TrimSpace(rule)
+		if rule == "" {
+			continue
+		}
+
+		envAndHost := strings.SplitN(rule, "=", 2)
+		nameAndHost := strings.SplitN(envAndHost[0], ":", 2)
+		if len(envAndHost) != 2 || len(nameAndHost) != 2 || nameAndHost[0] == "" || nameAndHost[1] == "" {
+			return nil, nil, fmt.Errorf("invalid environment rule %q, expected env:host=url", rule)
+		}
+
+		name := strings.TrimSpace(nameAndHost[0])
+		if values[name] == nil {
+			values[name] = map[string]string{}
+			names = append(names, name)
+		}
+		values[name][strings.ToLower(strings.TrimSpace(nameAndHost[1]))] = strings.TrimRight(strings.TrimSpace(envAndHost[1]), "/")
+	}
+	return values, names, nil
+}
+
+// BuildEnvironments returns a "recorded" environment plus one per rule environment, with unmapped hosts keeping their recorded origin
+func BuildEnvironments(bases []BaseURL, rules map[string]map[string]string, names []string) []PostmanEnvironment {
+	environments := []PostmanEnvironment{NewPostmanEnvironment("recorded")}
+	for _, base := range bases {
+		environments[0].Set(base.Variable, base.Origin, "default")
+	}
+
+	for _, name := range names {
+		environment := NewPostmanEnvironment(name)
+		for _, base := range bases {
+			value := base.Origin
+			if mapped, ok := rules[name][strings.ToLower(base.Host)]; ok {
+				value = mapped
+			} else if mapped, ok := rules[name][strings.ToLower(strings.SplitN(base.Host, ":", 2)[0])]; ok {
+				value = mapped
+			}
+			environment.Set(base.Variable, value, "default")
+		}
+		environments = append(environments, environment)
+	}
+
+	return environments
+}
+
+// NewPostmanEnvironment returns an empty environment with the given name
+func NewPostmanEnvironment(name string) PostmanEnvironment {
+	return PostmanEnvironment{
+		ID:            uuid.New().String(),
+		Name:          name,
+		Values:        []PostmanEnvironmentValue{},
+		Scope:         "environment",
+		ExportedAt:    time.Now(),
+		ExportedUsing: "go2postman",
+	}
+}
+
+// Set adds a value to the environment or replaces an existing value with the same key
+func (env *PostmanEnvironment) Set(key, value, valueType string) {
+	for i := range env.Values {
+		if env.Values[i].Key == key {
+			env.Values[i].Value = value
+			env.Values[i].Type = valueType
+			return
+		}
+	}
+	env.Values = append(env.Values, PostmanEnvironmentValue{Key: key, Value: value, Type: valueType, Enabled: true})
+}
+
+// EnvironmentFileName returns the environment file written next to a collection, e.g. out.staging.postman_environment.json
+func EnvironmentFileName(collectionFile, envName string) string {
+	base := strings.TrimSuffix(collectionFile, filepath.Ext(collectionFile))
+	return fmt.Sprintf("%s.%s.postman_environment.json", base, nonVariableCharRegex.ReplaceAllString(envName, "_"))
+}
+
+// WriteEnvironments writes each environment next to the collection file and returns the file names
+func WriteEnvironments(collectionFile string, environments []PostmanEnvironment) ([]string, error) {
+	var files []string
+	for _, environment := range environments {
+		output, err := json.MarshalIndent(environment, "", "  ")
+		if err != nil {
+			return files, fmt.Errorf("error marshaling environment %s: %v", environment.Name, err)
+		}
+		fileName := EnvironmentFileName(collectionFile, environment.Name)
+		if err := os.WriteFile(fileName, output, 0644); err != nil {
+			return files, fmt.Errorf("error writing environment %s: %v", environment.Name, err)
+		}
+		files = append(files, fileName)
+	}
+	return files, nil
+}
+
+// LoadEnvironment reads a Postman environment file and returns its enabled values by key
+func LoadEnvironment(fileName string) (map[string]string, error) {
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("error reading environment: %v", err)
+	}
+
+	var environment PostmanEnvironment
+	if err := json.Unmarshal(data, &environment); err != nil {
+		return nil, fmt.Errorf("error parsing environment %s: %v", fileName, err)
+	}
+	values := map[string]string{}
+	for _, value := range environment.Values {
+		if value.Enabled {
+			values[value.Key] = value.Value
+		}
+	}
+	return values, nil
+}
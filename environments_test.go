@@ -0,0 +1,129 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestExtractBaseURLs(t *testing.T) {
+	recorded := func() []PostmanItem {
+		return []PostmanItem{
+			{Request: PostmanRequest{Method: "GET", URL: URLFromString("https://cdn.example.com/app.js")}},
+			{Item: []PostmanItem{
+				{Request: PostmanRequest{Method: "GET", URL: URLFromString("https://api.example.com/users?page=1")}},
+				{Request: PostmanRequest{Method: "GET", URL: URLFromString("https://api.example.com:443/me")}},
+			}},
+			{Request: PostmanRequest{Method: "GET", URL: URLFromString("http://api.example.com:8080/health")}},
+			{Request: PostmanRequest{Method: "GET", URL: URLFromString("{{baseUrl}}/kept")}},
+		}
+	}
+	urls := func(items []PostmanItem) []string {
+		var raw []string
+		WalkItems(items, func(_ []string, item *PostmanItem) {
+			raw = append(raw, item.Request.URL.Raw)
+		})
+		return raw
+	}
+
+	tests := []struct {
+		mode    string
+		bases   []BaseURL
+		literal []string
+		urls    []string
+	}{
+		{
+			mode:    "single",
+			bases:   []BaseURL{{Variable: "baseUrl", Host: "api.example.com", Origin: "https://api.example.com"}},
+			literal: []string{"https://cdn.example.com", "http://api.example.com:8080"},
+			urls:    []string{"https://cdn.example.com/app.js", "{{baseUrl}}/users?page=1", "{{baseUrl}}/me", "http://api.example.com:8080/health", "{{baseUrl}}/kept"},
+		},
+		{
+			mode: "per-host",
+			bases: []BaseURL{
+				{Variable: "cdn_example_com_baseUrl", Host: "cdn.example.com", Origin: "https://cdn.example.com"},
+				{Variable: "api_example_com_baseUrl", Host: "api.example.com", Origin: "https://api.example.com"},
+				{Variable: "api_example_com_8080_baseUrl", Host: "api.example.com:8080", Origin: "http://api.example.com:8080"},
+			},
+			urls: []string{"{{cdn_example_com_baseUrl}}/app.js", "{{api_example_com_baseUrl}}/users?page=1", "{{api_example_com_baseUrl}}/me", "{{api_example_com_8080_baseUrl}}/health", "{{baseUrl}}/kept"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			items := recorded()
+			bases, literal, err := ExtractBaseURLs(items, tt.mode)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(bases, tt.bases) || !reflect.DeepEqual(literal, tt.literal) {
+				t.Errorf("bases = %+v, literal = %q, want %+v, %q", bases, literal, tt.bases, tt.literal)
+			}
+			if got := urls(items); !reflect.DeepEqual(got, tt.urls) {
+				t.Errorf("URLs = %q, want %q", got, tt.urls)
+			}
+		})
+	}
+
+	if _, _, err := ExtractBaseURLs(recorded(), "host"); err == nil {
+		t.Errorf("ExtractBaseURLs accepted an unknown mode")
+	}
+}
+
+func TestBuildEnvironments(t *testing.T) {
+	rules, names, err := ParseEnvironmentRules("staging:API.example.com=https://staging.example.com/, prod:api.example.com:8080=http://prod:8080,staging:cdn.example.com=https://cdn.staging")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(names, []string{"staging", "prod"}) {
+		t.Errorf("names = %q, want staging and prod", names)
+	}
+
+	bases := []BaseURL{
+		{Variable: "api", Host: "api.example.com:8080", Origin: "http://api.example.com:8080"},
+		{Variable: "other", Host: "other.example.com", Origin: "https://other.example.com"},
+	}
+	values := map[string]map[string]string{}
+	for _, environment := range BuildEnvironments(bases, rules, names) {
+		values[environment.Name] = map[string]string{}
+		for _, value := range environment.Values {
+			values[environment.Name][value.Key] = value.Value
+		}
+	}
+	want := map[string]map[string]string{
+		"recorded": {"api": "http://api.example.com:8080", "other": "https://other.example.com"},
+		"staging":  {"api": "https://staging.example.com", "other": "https://other.example.com"},
+		"prod":     {"api": "http://prod:8080", "other": "https://other.example.com"},
+	}
+	if !reflect.DeepEqual(values, want) {
+		t.Errorf("environments = %v, want %v", values, want)
+	}
+
+	for _, invalid := range []string{"staging", "staging=https://x", ":host=https://x"} {
+		if _, _, err := ParseEnvironmentRules(invalid); err == nil {
+			t.Errorf("ParseEnvironmentRules(%q) accepted an invalid rule", invalid)
+		}
+	}
+}
+
+func TestWriteAndLoadEnvironment(t *testing.T) {
+	collectionFile := filepath.Join(t.TempDir(), "out.json")
+	environment := NewPostmanEnvironment("my env")
+	environment.Set("baseUrl", "https://old", "default")
+	environment.Set("baseUrl", "https://new", "default")
+	environment.Values = append(environment.Values, PostmanEnvironmentValue{Key: "off", Value: "1"})
+
+	files, err := WriteEnvironments(collectionFile, []PostmanEnvironment{environment})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(filepath.Dir(collectionFile), "out.my_env.postman_environment.json"); len(files) != 1 || files[0] != want {
+		t.Fatalf("files = %q, want %s", files, want)
+	}
+	values, err := LoadEnvironment(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(values, map[string]string{"baseUrl": "https://new"}) {
+		t.Errorf("values = %v, want only the enabled baseUrl", values)
+	}
+}
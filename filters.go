@@ -0,0 +1,370 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+/*
+	###################################### FILTERING REQUESTS ##########################################################
+*/
+
+// FilterOptions holds the comma separated include and exclude lists given on the command line
+type FilterOptions struct {
+	IncludeHost, ExcludeHost     string
+	IncludeMethod, ExcludeMethod string
+	IncludePath, ExcludePath     string
+	IncludeStatus, ExcludeStatus string
+	IncludeMime, ExcludeMime     string
+	IncludeExt, ExcludeExt       string
+	ScopeFile                    string
+}
+
+// ItemFilter is a named test that an item has to pass to be converted
+type ItemFilter struct {
+	Name string
+	Keep func(item PostmanItem) bool
+}
+
+// FilterReport records how many items a filter removed
+type FilterReport struct {
+	Filter  string
+	Removed int
+}
+
+// BuildItemFilters turns the filter options into item filters, in the order they are applied
+//
+// Hosts are glob patterns, or regular expressions when written as /regex/. Paths are regular expressions,
+// statuses are codes or classes such as 4xx, and MIME types and extensions are Burp's <mimetype> and
+// <extension> values. Status, MIME type and scope filters only apply to items that carry the Burp metadata.
+func BuildItemFilters(options FilterOptions) ([]ItemFilter, error) {
+	var filters []ItemFilter
+	add := func(name, list string, include bool, matcher func(pattern string) (func(PostmanItem) (bool, bool), error)) error {
+		var matchers []func(PostmanItem) (bool, bool)
+		for _, pattern := range strings.Split(list, ",") {
+			if pattern = strings.TrimSpace(pattern); pattern == "" {
+				continue
+			}
+			match, err := matcher(pattern)
+			if err != nil {
+				return fmt.Errorf("invalid %s pattern %q: %v", name, pattern, err)
+			}
+			matchers = append(matchers, match)
+		}
+		if len(matchers) == 0 {
+			return nil
+		}
+
+		filters = append(filters, ItemFilter{Name: name, Keep: func(item PostmanItem) bool {
+			for _, match := range matchers {
+				matched, applies := match(item)
+				if !applies {
+					return true
+				}
+				if matched {
+					return include
+				}
+			}
+			return !include
+		}})
+		return nil
+	}
+
+	steps := []struct {
+		name    string
+		list    string
+		include bool
+		matcher func(pattern string) (func(PostmanItem) (bool, bool), error)
+	}{
+		{"include-host", options.IncludeHost, true, hostMatcher},
+		{"exclude-host", options.ExcludeHost, false, hostMatcher},
+		{"include-method", options.IncludeMethod, true, methodMatcher},
+		{"exclude-method", options.ExcludeMethod, false, methodMatcher},
+		{"include-path", options.IncludePath, true, pathMatcher},
+		{"exclude-path", options.ExcludePath, false, pathMatcher},
+		{"include-status", options.IncludeStatus, true, statusMatcher},
+		{"exclude-status", options.ExcludeStatus, false, statusMatcher},
+		{"include-mime", options.IncludeMime, true, mimeMatcher},
+		{"exclude-mime", options.ExcludeMime, false, mimeMatcher},
+		{"include-ext", options.IncludeExt, true, extensionMatcher},
+		{"exclude-ext", options.ExcludeExt, false, extensionMatcher},
+	}
+	for _, step := range steps {
+		if err := add(step.name, step.list, step.include, step.matcher); err != nil {
+			return nil, err
+		}
+	}
+
+	if options.ScopeFile != "" {
+		scope, err := LoadBurpScope(options.ScopeFile)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, ItemFilter{Name: "scope", Keep: scope.InScope})
+	}
+
+	return filters, nil
+}
+
+// FilterItems removes the items failing any filter, reporting each removal against the first filter that failed
+func FilterItems(items []PostmanItem, filters []ItemFilter) ([]PostmanItem, []FilterReport) {
+	removed := make([]int, len(filters))
+	var kept []PostmanItem
+	for _, item := range items {
+		keep := true
+		for f, filter := range filters {
+			if !filter.Keep(item) {
+				removed[f]++
+				keep = false
+				break
+			}
+		}
+		if keep {
+			kept = append(kept, item)
+		}
+	}
+
+	var reports []FilterReport
+	for f, filter := range filters {
+		reports = append(reports, FilterReport{Filter: filter.Name, Removed: removed[f]})
+	}
+	return kept, reports
+}
+
+// hostMatcher matches the request host against a glob, or a regular expression written as /regex/
+func hostMatcher(pattern string) (func(PostmanItem) (bool, bool), error) {
+	if len(pattern) > 2 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
+		re, err := regexp.Compile("(?i)" + pattern[1:len(pattern)-1])
+		if err != nil {
+			return nil, err
+		}
+		return func(item PostmanItem) (bool, bool) {
+			return re.MatchString(itemHost(item)), true
+		}, nil
+	}
+
+	pattern = strings.ToLower(pattern)
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, err
+	}
+	return func(item PostmanItem) (bool, bool) {
+		matched, _ := path.Match(pattern, strings.ToLower(itemHost(item)))
+		return matched, true
+	}, nil
+}
+
+// methodMatcher matches the request method case-insensitively
+func methodMatcher(pattern string) (func(PostmanItem) (bool, bool), error) {
+	return func(item PostmanItem) (bool, bool) {
+		return strings.EqualFold(item.Request.Method, pattern), true
+	}, nil
+}
+
+// pathMatcher matches the request path, without the query string, against a regular expression
+func pathMatcher(pattern string) (func(PostmanItem) (bool, bool), error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	return func(item PostmanItem) (bool, bool) {
+		return re.MatchString("/" + strings.Join(item.Request.URL.Path, "/")), true
+	}, nil
+}
+
+// statusMatcher matches the Burp status code against a code such as 404 or a class such as 4xx
+func statusMatcher(pattern string) (func(PostmanItem) (bool, bool), error) {
+	pattern = strings.ToLower(pattern)
+	if len(pattern) != 3 || strings.Trim(pattern, "0123456789x") != "" {
+		return nil, fmt.Errorf("expected a status code or class such as 404 or 4xx")
+	}
+	return func(item PostmanItem) (bool, bool) {
+		status := itemStatus(item)
+		if status == "" {
+			return false, false
+		}
+		for i := range pattern {
+			if pattern[i] != 'x' && pattern[i] != status[i] {
+				return false, true
+			}
+		}
+		return true, true
+	}, nil
+}
+
+// mimeMatcher matches the Burp MIME type such as JSON, HTML or script case-insensitively
+func mimeMatcher(pattern string) (func(PostmanItem) (bool, bool), error) {
+	return func(item PostmanItem) (bool, bool) {
+		if item.Source == nil || item.Source.Burp == nil || item.Source.Burp.MimeType == "" {
+			return false, false
+		}
+		return strings.EqualFold(item.Source.Burp.MimeType, pattern), true
+	}, nil
+}
+
+// extensionMatcher matches the Burp file extension, or the extension of the last path segment for other items
+func extensionMatcher(pattern string) (func(PostmanItem) (bool, bool), error) {
+	pattern = strings.TrimPrefix(strings.ToLower(pattern), ".")
+	return func(item PostmanItem) (bool, bool) {
+		return itemExtension(item) == pattern, true
+	}, nil
+}
+
+// itemHost returns the host of a request without its port
+func itemHost(item PostmanItem) string {
+	return strings.Join(item.Request.URL.Host, ".")
+}
+
+// itemStatus returns the three digit Burp status of an item, falling back to its first saved example
+func itemStatus(item PostmanItem) string {
+	if item.Source != nil && item.Source.Burp != nil {
+		if status := strings.TrimSpace(item.Source.Burp.Status); len(status) == 3 {
+			return status
+		}
+	}
+	if len(item.Response) > 0 && item.Response[0].Code > 0 {
+		return strconv.Itoa(item.Response[0].Code)
+	}
+	return ""
+}
+
+// itemExtension returns the lower case file extension of a request, or "" when it has none
+func itemExtension(item PostmanItem) string {
+	if item.Source != nil && item.Source.Burp != nil {
+		if extension := strings.ToLower(strings.TrimSpace(item.Source.Burp.Extension)); extension != "" {
+			if extension == "null" {
+				return ""
+			}
+			return extension
+		}
+	}
+	if len(item.Request.URL.Path) == 0 {
+		return ""
+	}
+	return strings.TrimPrefix(strings.ToLower(path.Ext(item.Request.URL.Path[len(item.Request.URL.Path)-1])), ".")
+}
+
+/*
+	###################################### BURP TARGET SCOPE ###########################################################
+*/
+
+// BurpScopeRule is an include or exclude entry of a Burp target scope
+type BurpScopeRule struct {
+	Enabled  bool   `json:"enabled"`
+	Prefix   string `json:"prefix"`
+	Protocol string `json:"protocol"`
+	Host     string `json:"host"`
+	Port     string `json:"port"`
+	File     string `json:"file"`
+
+	host, port, file *regexp.Regexp
+}
+
+// BurpScope is the target scope saved from Burp's project options
+type BurpScope struct {
+	Target struct {
+		Scope struct {
+			AdvancedMode bool            `json:"advanced_mode"`
+			Include      []BurpScopeRule `json:"include"`
+			Exclude      []BurpScopeRule `json:"exclude"`
+		} `json:"scope"`
+	} `json:"target"`
+}
+
+// LoadBurpScope reads a Burp project options or target scope JSON file and compiles its rules
+func LoadBurpScope(fileName string) (*BurpScope, error) {
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("error reading scope file: %v", err)
+	}
+
+	var scope BurpScope
+	if err := json.Unmarshal(data, &scope); err != nil {
+		return nil, fmt.Errorf("error parsing scope file %s: %v", fileName, err)
+	}
+	if len(scope.Target.Scope.Include) == 0 {
+		return nil, fmt.Errorf("scope file %s has no target.scope.include rules", fileName)
+	}
+
+	for _, rules := range [][]BurpScopeRule{scope.Target.Scope.Include, scope.Target.Scope.Exclude} {
+		for i := range rules {
+			if err := rules[i].compile(); err != nil {
+				return nil, fmt.Errorf("error in scope file %s: %v", fileName, err)
+			}
+		}
+	}
+	return &scope, nil
+}
+
+// InScope reports whether an item matches an enabled include rule and no enabled exclude rule
+func (scope *BurpScope) InScope(item PostmanItem) bool {
+	included := false
+	for _, rule := range scope.Target.Scope.Include {
+		if rule.Enabled && rule.matches(item) {
+			included = true
+			break
+		}
+	}
+	if !included {
+		return false
+	}
+	for _, rule := range scope.Target.Scope.Exclude {
+		if rule.Enabled && rule.matches(item) {
+			return false
+		}
+	}
+	return true
+}
+
+// compile prepares the regular expressions of an advanced scope rule
+func (rule *BurpScopeRule) compile() error {
+	var err error
+	for _, field := range []struct {
+		pattern string
+		target  **regexp.Regexp
+	}{{rule.Host, &rule.host}, {rule.Port, &rule.port}, {rule.File, &rule.file}} {
+		if field.pattern == "" {
+			continue
+		}
+		if *field.target, err = regexp.Compile("(?i)" + field.pattern); err != nil {
+			return fmt.Errorf("invalid scope pattern %q: %v", field.pattern, err)
+		}
+	}
+	return nil
+}
+
+// matches reports whether an item falls under a URL prefix rule or an advanced protocol, host, port and file rule
+func (rule BurpScopeRule) matches(item PostmanItem) bool {
+	u := item.Request.URL
+	protocol := strings.ToLower(u.Protocol)
+	host := itemHost(item)
+	port := u.Port
+	if port == "" {
+		port = DefaultPort(protocol)
+	}
+	file := "/" + strings.Join(u.Path, "/")
+
+	if rule.Prefix != "" {
+		target := protocol + "://" + strings.ToLower(JoinHostPort(host, u.Port, protocol)) + file
+		prefix := strings.ToLower(rule.Prefix)
+		return strings.HasPrefix(target, prefix) || strings.HasPrefix(target+"/", prefix)
+	}
+
+	if rule.Protocol != "" && !strings.EqualFold(rule.Protocol, "any") && !strings.EqualFold(rule.Protocol, protocol) {
+		return false
+	}
+	if rule.host != nil && !rule.host.MatchString(host) {
+		return false
+	}
+	if rule.port != nil && !rule.port.MatchString(port) {
+		return false
+	}
+	if rule.file != nil && !rule.file.MatchString(file) {
+		return false
+	}
+	return true
+}
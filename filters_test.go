@@ -0,0 +1,121 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFilterItems(t *testing.T) {
+	item := func(name, method, rawURL, status, mime, extension string) PostmanItem {
+		item := PostmanItem{Name: name, Request: PostmanRequest{Method: method, URL: URLFromString(rawURL)}}
+		if status != "" {
+			item.Source = &ItemSource{Burp: &BurpItem{Status: status, MimeType: mime, Extension: extension}}
+		}
+		return item
+	}
+	items := []PostmanItem{
+		item("users", "GET", "https://api.example.com/v1/users?q=.js", "200", "JSON", "null"),
+		item("login", "POST", "https://auth.example.com/login", "302", "HTML", "null"),
+		item("script", "GET", "https://cdn.example.com/app.js", "200", "script", "js"),
+		item("missing", "GET", "https://api.example.com/v1/missing", "404", "JSON", "null"),
+		item("curl", "DELETE", "https://api.example.com/v1/users/7", "", "", ""),
+	}
+
+	tests := []struct {
+		name    string
+		options FilterOptions
+		kept    []string
+		reports []FilterReport
+	}{
+		{
+			name:    "host glob and regex",
+			options: FilterOptions{IncludeHost: "*.EXAMPLE.com", ExcludeHost: "/^(cdn|auth)\\./"},
+			kept:    []string{"users", "missing", "curl"},
+			reports: []FilterReport{{"include-host", 0}, {"exclude-host", 2}},
+		},
+		{
+			name:    "methods and paths",
+			options: FilterOptions{IncludeMethod: "get, delete", ExcludePath: "^/v1/missing$"},
+			kept:    []string{"users", "script", "curl"},
+			reports: []FilterReport{{"include-method", 1}, {"exclude-path", 1}},
+		},
+		{
+			name:    "status classes only apply to Burp items",
+			options: FilterOptions{ExcludeStatus: "3xx,404"},
+			kept:    []string{"users", "script", "curl"},
+			reports: []FilterReport{{"exclude-status", 2}},
+		},
+		{
+			name:    "MIME types and extensions",
+			options: FilterOptions{IncludeMime: "json,HTML", ExcludeExt: ".JS"},
+			kept:    []string{"users", "login", "missing", "curl"},
+			reports: []FilterReport{{"include-mime", 1}, {"exclude-ext", 0}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filters, err := BuildItemFilters(tt.options)
+			if err != nil {
+				t.Fatal(err)
+			}
+			kept, reports := FilterItems(items, filters)
+			var names []string
+			for _, item := range kept {
+				names = append(names, item.Name)
+			}
+			if !reflect.DeepEqual(names, tt.kept) || !reflect.DeepEqual(reports, tt.reports) {
+				t.Errorf("kept %q with %+v, want %q with %+v", names, reports, tt.kept, tt.reports)
+			}
+		})
+	}
+
+	for _, options := range []FilterOptions{{IncludeStatus: "20"}, {IncludePath: "("}, {ExcludeHost: "["}} {
+		if _, err := BuildItemFilters(options); err == nil {
+			t.Errorf("BuildItemFilters(%+v) accepted an invalid pattern", options)
+		}
+	}
+}
+
+func TestBurpScope(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "scope.json")
+	scope := `{"target":{"scope":{"advanced_mode":true,
+		"include":[
+			{"enabled":true,"prefix":"https://api.example.com/v1"},
+			{"enabled":true,"protocol":"any","host":"^.*\\.internal$","port":"^8080$","file":"^/admin"},
+			{"enabled":false,"prefix":"https://cdn.example.com"}
+		],
+		"exclude":[{"enabled":true,"protocol":"https","host":"^api\\.example\\.com$","file":"logout"}]}}}`
+	if err := os.WriteFile(fileName, []byte(scope), 0644); err != nil {
+		t.Fatal(err)
+	}
+	filters, err := BuildItemFilters(FilterOptions{ScopeFile: fileName})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]bool{
+		"https://api.example.com/v1":            true,
+		"https://API.example.com/v1/users":      true,
+		"https://api.example.com/v1/logout":     false,
+		"https://api.example.com/v2":            false,
+		"http://app.internal:8080/admin/users":  true,
+		"http://app.internal/admin":             false,
+		"https://cdn.example.com/app.js":        false,
+		"https://api.example.com:8443/v1/users": false,
+	}
+	for rawURL, want := range tests {
+		item := PostmanItem{Request: PostmanRequest{Method: "GET", URL: URLFromString(rawURL)}}
+		if got := filters[0].Keep(item); got != want {
+			t.Errorf("in scope(%s) = %v, want %v", rawURL, got, want)
+		}
+	}
+
+	if err := os.WriteFile(fileName, []byte(`{"target":{"scope":{"include":[]}}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadBurpScope(fileName); err == nil {
+		t.Errorf("LoadBurpScope accepted a scope without include rules")
+	}
+}
@@ -20,9 +20,11 @@ import (
 	"encoding/xml"
 	"fmt"
 	"io"
+	"net/url"
 	"os"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 	"flag"
@@ -35,8 +37,30 @@ import (
 	###################################### START MAIN FUNCTION ########################################################### 
 */
 func main() {
+	// The diff command compares two captures instead of converting one
+	if len(os.Args) > 1 && os.Args[1] == "diff" {
+		if err := RunDiff(os.Args[2:]); err != nil {
+			fmt.Printf("[!] Error comparing captures: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	
 	var (
 		curlinPtr, burpdirPtr, postmanOutPtr, startbanner string
+		intruderDataPtr, intruderPayloadsPtr string
+		sortPtr, groupByPtr, dedupePtr, dedupeKeepPtr string
+		baseURLPtr, envRulesPtr, redactPtr string
+		headerProfilePtr, headerIncludePtr, headerExcludePtr string
+		filterOptions FilterOptions
+		groupDepthPtr int
+		testsPtr, correlatePtr, templatePathsPtr, updatePtr bool
+		updateModePtr, harOutPtr, openAPIOutPtr string
+		curlOutPtr, curlShellPtr, curlEnvPtr, burpOutPtr string
+		brunoOutPtr, insomniaOutPtr, httpOutPtr string
+		codeOutPtr, codeLangPtr string
+		k6OutPtr, jmeterOutPtr string
+		rawOutPtr, rawPointPtr, rawMarkerPtr string
 	)
 	startbanner = `	 -=[+] ... Go-2-Postman Postman Generator ... [+]=- `
 
@@ -49,6 +73,66 @@ func main() {
 	// Suffix - setup
 	flag.StringVar(&postmanOutPtr, "postman-out", "postman_out.json", `This option is for the generated a postman output file name.`)
 	flag.StringVar(&postmanOutPtr, "o", "postman_out.json", `This option is for the generated a postman output file name. (short syntax for -postman-out)`)
+	// Exporters - setup
+	flag.StringVar(&harOutPtr, "har-out", "", `This option also writes the collection as a HAR 1.2 file, with folders as pages and Burp recording times.`)
+	flag.StringVar(&openAPIOutPtr, "openapi-out", "", `This option also writes an OpenAPI 3.1 JSON document inferred from the requests and recorded responses.`)
+	flag.StringVar(&burpOutPtr, "burp-out", "", `This option also writes the requests and saved examples as a Burp Suite XML items file.`)
+	flag.StringVar(&brunoOutPtr, "bruno-out", "", `This option also writes the collection as a Bruno collection directory of .bru files, with its environments.`)
+	flag.StringVar(&insomniaOutPtr, "insomnia-out", "", `This option also writes the collection and its environments as an Insomnia v4 export file.`)
+	flag.StringVar(&httpOutPtr, "http-out", "", `This option also writes the collection as .http request files, one per folder, with http-client.env.json environments.`)
+	flag.StringVar(&codeOutPtr, "code-out", "", `This option also generates client tests that replay each request and check its recorded status into a directory, one sub-directory per language.`)
+	flag.StringVar(&codeLangPtr, "code-lang", "go,python,javascript", `This option is a comma separated list of languages generated with -code-out: go (net/http test), python (requests and pytest) and javascript (fetch and node:test).`)
+	flag.StringVar(&k6OutPtr, "k6-out", "", `This option also writes a k6 load test script replaying the requests in recorded order, grouped by folder with status checks.`)
+	flag.StringVar(&jmeterOutPtr, "jmeter-out", "", `This option also writes an Apache JMeter test plan (.jmx) replaying the requests in recorded order, with collection variables in a CSV file next to it.`)
+	flag.StringVar(&rawOutPtr, "raw-out", "", `This option also writes each request as a raw HTTP/1.1 request file for sqlmap -r or ffuf -request into a directory, with an index.json mapping files to requests.`)
+	flag.StringVar(&rawPointPtr, "raw-point", "", `This option places the -raw-marker in the -raw-out files at query:NAME, path:NAME, header:NAME, cookie:NAME, form:NAME or json:PATH, or "each" for one file per parameter.`)
+	flag.StringVar(&rawMarkerPtr, "raw-marker", "FUZZ", `This option is the marker placed by -raw-point; "*" is appended to the value for sqlmap, anything else replaces it.`)
+	flag.StringVar(&curlOutPtr, "curl-out", "", `This option also writes a script with one multi-line curl command per request.`)
+	flag.StringVar(&curlShellPtr, "curl-shell", "", `This option sets the -curl-out script shell, "bash" or "powershell" (default powershell for .ps1 files, otherwise bash).`)
+	flag.StringVar(&curlEnvPtr, "curl-env", "", `This option resolves {{variables}} in the -curl-out script from a Postman environment file instead of leaving shell variables.`)
+	// Update - setup
+	flag.BoolVar(&updatePtr, "update", false, `This option merges the conversion into an existing -postman-out collection instead of overwriting it, matching requests by method and templated URL.`)
+	flag.StringVar(&updateModePtr, "update-mode", "keep", `This option leaves matched requests alone ("keep") or replaces their request while keeping scripts, descriptions and examples ("replace") with -update.`)
+	// Intruder - setup
+	flag.StringVar(&intruderDataPtr, "intruder-data", "", `This option sets the Postman runner data file (.csv or .json) written for Burp Intruder payload positions.`)
+	flag.StringVar(&intruderPayloadsPtr, "intruder-payloads", "", `This option is a comma separated list of payload files, one per payload position, used to fill the runner data file.`)
+	// Filters - setup
+	flag.StringVar(&filterOptions.IncludeHost, "include-host", "", `This option only converts requests to hosts matching a comma separated list of globs or /regex/ patterns, e.g. *.example.com`)
+	flag.StringVar(&filterOptions.ExcludeHost, "exclude-host", "", `This option skips requests to hosts matching a comma separated list of globs or /regex/ patterns, e.g. *.google-analytics.com`)
+	flag.StringVar(&filterOptions.IncludeMethod, "include-method", "", `This option only converts requests using a comma separated list of methods, e.g. GET,POST`)
+	flag.StringVar(&filterOptions.ExcludeMethod, "exclude-method", "", `This option skips requests using a comma separated list of methods, e.g. OPTIONS,HEAD`)
+	flag.StringVar(&filterOptions.IncludePath, "include-path", "", `This option only converts requests whose path matches one of a comma separated list of regular expressions, e.g. ^/api/`)
+	flag.StringVar(&filterOptions.ExcludePath, "exclude-path", "", `This option skips requests whose path matches one of a comma separated list of regular expressions.`)
+	flag.StringVar(&filterOptions.IncludeStatus, "include-status", "", `This option only converts Burp items with a status in a comma separated list of codes or classes, e.g. 200,3xx`)
+	flag.StringVar(&filterOptions.ExcludeStatus, "exclude-status", "", `This option skips Burp items with a status in a comma separated list of codes or classes, e.g. 404,5xx`)
+	flag.StringVar(&filterOptions.IncludeMime, "include-mime", "", `This option only converts Burp items with a MIME type in a comma separated list, e.g. JSON,XML`)
+	flag.StringVar(&filterOptions.ExcludeMime, "exclude-mime", "", `This option skips Burp items with a MIME type in a comma separated list, e.g. script,image,CSS`)
+	flag.StringVar(&filterOptions.IncludeExt, "include-ext", "", `This option only converts requests with a file extension in a comma separated list, e.g. php,aspx`)
+	flag.StringVar(&filterOptions.ExcludeExt, "exclude-ext", "", `This option skips requests with a file extension in a comma separated list, e.g. js,css,png,woff2`)
+	flag.StringVar(&filterOptions.ScopeFile, "scope", "", `This option only converts requests in the target scope of a Burp project options JSON file.`)
+	// Ordering - setup
+	flag.StringVar(&sortPtr, "sort", "original", `This option orders requests by "original" file order, Burp recording "time" across all files, or "host" and path.`)
+	// Path templating - setup
+	flag.BoolVar(&templatePathsPtr, "template-paths", false, `This option rewrites ID, UUID, hash, email, date and varying path segments into :name Postman path variables.`)
+	// De-duplication - setup
+	flag.StringVar(&dedupePtr, "dedupe", "", `This option merges equivalent requests by method, host and path, adding "query" parameter names and/or JSON "body" shape to the key, e.g. path,query,body.`)
+	flag.StringVar(&dedupeKeepPtr, "dedupe-keep", "first", `This option keeps the "first" or the most "complete" instance of each de-duplicated request.`)
+	// Base URL and environments - setup
+	flag.StringVar(&baseURLPtr, "base-url", "", `This option replaces scheme, host and port with {{baseUrl}} ("single") or one {{<host>_baseUrl}} per host ("per-host") and writes Postman environments.`)
+	flag.StringVar(&envRulesPtr, "env", "", `This option adds environments mapping recorded hosts to new base URLs, e.g. staging:api.example.com=https://staging.example.com,prod:api.example.com=https://api.example.com`)
+	// Header profiles - setup
+	flag.StringVar(&headerProfilePtr, "headers", "verbatim", `This option disables noisy headers with the "browser-clean" profile, keeps only authentication and content headers with "minimal", or keeps every header "verbatim".`)
+	flag.StringVar(&headerIncludePtr, "headers-include", "", `This option is a comma separated list of header name patterns always kept enabled, e.g. x-*,referer`)
+	flag.StringVar(&headerExcludePtr, "headers-exclude", "", `This option is a comma separated list of header name patterns always disabled, e.g. sec-*,user-agent`)
+	// Redaction - setup
+	flag.StringVar(&redactPtr, "redact", "", `This option replaces credentials, API keys, cookies, JWTs, AWS keys and password fields with {{variables}}, writing their values to a secrets environment ("env") or discarding them ("drop").`)
+	// Grouping - setup
+	flag.StringVar(&groupByPtr, "group-by", "", `This option nests requests into folders by "host", "host/path-depth", "source-file", "method" or "burp-comment".`)
+	flag.IntVar(&groupDepthPtr, "group-depth", 1, `This option sets how many path segments below the host become folders with -group-by host/path-depth.`)
+	// Tests - setup
+	flag.BoolVar(&testsPtr, "tests", false, `This option adds pm.test assertions for the recorded status, Content-Type and JSON body schema of each Burp response.`)
+	// Correlation - setup
+	flag.BoolVar(&correlatePtr, "correlate", false, `This option replaces tokens and IDs returned by one Burp response and reused by later requests with variables set by a test script.`)
 	// Parse all the flags
 	flag.Usage = func() {
 		flagSet := flag.CommandLine
@@ -58,7 +142,7 @@ func main() {
 			flag := flagSet.Lookup(name)
 			fmt.Printf("\t-%s\t | %s\n", flag.Name, flag.Usage)
 		}
-		longhand := []string{"curl-in", "burp-dir", "postman-out"}
+		longhand := []string{"curl-in", "burp-dir", "postman-out", "har-out", "openapi-out", "burp-out", "bruno-out", "insomnia-out", "http-out", "code-out", "code-lang", "k6-out", "jmeter-out", "raw-out", "raw-point", "raw-marker", "curl-out", "curl-shell", "curl-env", "update", "update-mode", "intruder-data", "intruder-payloads", "include-host", "exclude-host", "include-method", "exclude-method", "include-path", "exclude-path", "include-status", "exclude-status", "include-mime", "exclude-mime", "include-ext", "exclude-ext", "scope", "sort", "template-paths", "dedupe", "dedupe-keep", "base-url", "env", "headers", "headers-include", "headers-exclude", "redact", "group-by", "group-depth", "tests", "correlate"}
 		fmt.Printf("\n    	The following syntax is for longhand operational flags:\n\n")
 		for _, name := range longhand {
 			flag := flagSet.Lookup(name)
@@ -68,6 +152,24 @@ func main() {
 		fmt.Printf("    	./go2postman -c list-of-curl-commands.txt -o postman-out-collection.json\n")
 		fmt.Printf("    	./go2postman -curl-in list-of-curl-commands.txt -postman-out postman-out-collection.json\n")
 		fmt.Printf("    	./go2postman -b BURP_XML_FILES/ -postman-out postman-out-collection.json\n")
+		fmt.Printf("    	./go2postman -b BURP_XML_FILES/ -group-by host -har-out capture.har -postman-out postman-out-collection.json\n")
+		fmt.Printf("    	./go2postman -b BURP_XML_FILES/ -template-paths -openapi-out openapi.json -postman-out postman-out-collection.json\n")
+		fmt.Printf("    	./go2postman -c list-of-curl-commands.txt -burp-out burp-items.xml\n")
+		fmt.Printf("    	./go2postman -b BURP_XML_FILES/ -base-url per-host -bruno-out bruno-collection/ -insomnia-out insomnia.json\n")
+		fmt.Printf("    	./go2postman -b BURP_XML_FILES/ -base-url single -group-by host -http-out http-requests/\n")
+		fmt.Printf("    	./go2postman -b BURP_XML_FILES/ -base-url single -code-out client-tests/ -code-lang go,python\n")
+		fmt.Printf("    	./go2postman -b BURP_XML_FILES/ -base-url single -correlate -k6-out load-test.js -jmeter-out load-test.jmx\n")
+		fmt.Printf("    	./go2postman -b BURP_XML_FILES/ -base-url single -raw-out raw-requests/ -raw-point each -raw-marker '*'\n")
+		fmt.Printf("    	./go2postman -b BURP_XML_FILES/ -redact env -curl-out runbook.sh -curl-env staging.postman_environment.json\n")
+		fmt.Printf("    	./go2postman -b BURP_XML_FILES/ -update -update-mode replace -postman-out postman-out-collection.json\n")
+		fmt.Printf("    	./go2postman -b BURP_XML_FILES/ -scope burp-scope.json -exclude-ext js,css,png -exclude-status 404 -postman-out postman-out-collection.json\n")
+		fmt.Printf("    	./go2postman -b BURP_XML_FILES/ -tests -postman-out postman-out-collection.json\n")
+		fmt.Printf("    	./go2postman -b BURP_XML_FILES/ -base-url per-host -env staging:api.example.com=https://staging.example.com\n")
+		fmt.Printf("    	./go2postman -b BURP_XML_FILES/ -headers browser-clean -headers-exclude user-agent -postman-out postman-out-collection.json\n")
+		fmt.Printf("    	./go2postman -b BURP_XML_FILES/ -redact env -postman-out postman-out-collection.json\n")
+		fmt.Printf("    	./go2postman -b BURP_XML_FILES/ -group-by host/path-depth -group-depth 2 -postman-out postman-out-collection.json\n")
+		fmt.Printf("    	./go2postman -b BURP_XML_FILES/ -intruder-data runner-data.csv -intruder-payloads usernames.txt,passwords.txt\n")
+		fmt.Printf("    	./go2postman diff -format markdown old-collection.json BURP_XML_FILES/\n")
 		fmt.Printf("\n    	** Please note; it is only possible to import a list of commands OR a directory of burp XML files, NOT both! **\n")
 		fmt.Printf("\n\n")
 	}
@@ -83,6 +185,16 @@ func main() {
 		flag.Usage()
 		return
 	}
+
+	// The injection point is checked before anything is converted or written
+	rawPoint, rawEach, err := ParseRawPoint(rawPointPtr)
+	if err == nil && (rawPoint.Location != "" || rawEach) && rawMarkerPtr == "" {
+		err = fmt.Errorf("-raw-point needs a -raw-marker")
+	}
+	if err != nil {
+		fmt.Printf("[!] Error parsing -raw-point: %v\n", err)
+		return
+	}
 	
 	var collection PostmanCollection
 	if (burpdirPtr == "") {
@@ -105,88 +217,12 @@ func main() {
 		outputFile = postmanOutPtr
 		
 		// Process directory recursively
-		err := filepath.Walk(inputDir, func(path string, info os.FileInfo, err error) error {
-			if err != nil {
-				fmt.Printf("[!] Error accessing path %s: %v\n", path, err)
-				return err
-			}
-			
-			if info.IsDir() {
-				return nil
-			}
-			
-			ext := strings.ToLower(filepath.Ext(path))
-			
-			switch {
-			case ext == ".xml":
-				// Check if it's a Burp XML file
-				file, err := os.Open(path)
-				if err != nil {
-					fmt.Printf("[!] Error opening file %s: %v\n", path, err)
-					return nil
-				}
-				
-				// Read the first few bytes to check if it looks like a Burp XML file
-				buffer := make([]byte, 256)
-				_, err = file.Read(buffer)
-				file.Close()
-				
-				if err != nil && err != io.EOF {
-					fmt.Printf("[!] Error reading file %s: %v\n", path, err)
-					return nil
-				}
-				
-				// Check if it contains Burp XML signature
-				content := string(buffer)
-				if strings.Contains(content, "<!DOCTYPE items") || strings.Contains(content, "<items burpVersion") {
-					fmt.Printf("[+] ... Processing Burp XML file: %s\n", path)
-					items, err := ProcessBurpXML(path)
-					if err != nil {
-						fmt.Printf("[!] Error processing Burp XML file %s: %v\n", path, err)
-						return nil
-					}
-					collection.Item = append(collection.Item, items...)
-				}
-				
-			case ext == ".txt", ext == ".curl":
-				// Check if it's a cURL commands file
-				file, err := os.Open(path)
-				if err != nil {
-					fmt.Printf("[!] Error opening file %s: %v\n", path, err)
-					return nil
-				}
-				
-				// Read the first line to check if it's a cURL file
-				scanner := bufio.NewScanner(file)
-				var firstLine string
-				if scanner.Scan() {
-					firstLine = scanner.Text()
-				}
-				file.Close()
-				
-				if err := scanner.Err(); err != nil {
-					fmt.Printf("[!] Error reading file %s: %v\n", path, err)
-					return nil
-				}
-				
-				if strings.HasPrefix(firstLine, "curl ") {
-					fmt.Printf("[+] ... Processing cURL commands file: %s\n", path)
-					items, err := ProcessCurlFile(path)
-					if err != nil {
-						fmt.Printf("[!] Error processing cURL file %s: %v\n", path, err)
-						return nil
-					}
-					collection.Item = append(collection.Item, items...)
-				}
-			}
-			
-			return nil
-		})
-		
+		items, err := ProcessDirectory(inputDir, os.Stdout)
 		if err != nil {
 			fmt.Printf("[!] Error walking directory: %v\n", err)
 			return
 		}
+		collection.Item = append(collection.Item, items...)
 		
 	} else {
 		log.Printf("	%s\n", startbanner)
@@ -199,7 +235,7 @@ func main() {
 		switch {
 		case ext == ".xml":
 			fmt.Printf("[+] ... Processing Burp XML file: %s\n", inputFile)
-			items, err := ProcessBurpXML(inputFile)
+			items, err := ProcessBurpXML(inputFile, os.Stdout)
 			if err != nil {
 				fmt.Printf("[!] Error processing Burp XML file: %v\n", err)
 				return
@@ -208,7 +244,7 @@ func main() {
 			
 		case ext == ".txt", ext == ".curl", ext == "":
 			fmt.Printf("[+] ... Processing cURL commands file: %s\n", inputFile)
-			items, err := ProcessCurlFile(inputFile)
+			items, err := ProcessCurlFile(inputFile, os.Stdout)
 			if err != nil {
 				fmt.Printf("[!] Error processing cURL file: %v\n", err)
 				return
@@ -221,12 +257,178 @@ func main() {
 		}
 	}
 	
+	// Drop the requests removed by the include, exclude and scope filters
+	filters, err := BuildItemFilters(filterOptions)
+	if err != nil {
+		fmt.Printf("[!] Error setting up filters: %v\n", err)
+		return
+	}
+	if len(filters) > 0 {
+		filtered, reports := FilterItems(collection.Item, filters)
+		for _, report := range reports {
+			fmt.Printf("[*] ... Filter -%s removed %d requests\n", report.Filter, report.Removed)
+		}
+		fmt.Printf("[+] ... Filtered %d requests down to %d\n", len(collection.Item), len(filtered))
+		collection.Item = filtered
+	}
+	
 	// Check if we found any items
 	if len(collection.Item) == 0 {
 		fmt.Println("[*] No items were found to convert!")
 		return
 	}
 	
+	// Order the requests before anything that depends on their sequence
+	if err := SortItems(collection.Item, sortPtr); err != nil {
+		fmt.Printf("[!] Error sorting requests: %v\n", err)
+		return
+	}
+	
+	// Turn IDs and other values in paths into path variables
+	if templatePathsPtr {
+		fmt.Printf("[+] ... Templated path variables in %d requests\n", TemplatePaths(collection.Item))
+	}
+	
+	// Merge equivalent requests, keeping the variants as saved examples
+	if dedupePtr != "" {
+		deduped, summaries, err := DedupeItems(collection.Item, strings.Split(dedupePtr, ","), dedupeKeepPtr)
+		if err != nil {
+			fmt.Printf("[!] Error de-duplicating requests: %v\n", err)
+			return
+		}
+		for _, summary := range summaries {
+			fmt.Printf("[+] ... Merged %d duplicates of %s\n", summary.Merged, summary.Endpoint)
+		}
+		fmt.Printf("[+] ... De-duplicated %d requests down to %d\n", len(collection.Item), len(deduped))
+		collection.Item = deduped
+	}
+	
+	// Generate test scripts from the recorded responses
+	if testsPtr {
+		fmt.Printf("[+] ... Added response tests to %d requests\n", AddResponseTests(collection.Item))
+	}
+	
+	// Chain values produced by one response into the requests that reuse them
+	if correlatePtr {
+		rules := CorrelateItems(collection.Item)
+		fmt.Printf("[+] ... Correlated %d values between requests\n", len(rules))
+	}
+	
+	// Disable headers filtered by the header profile and patterns
+	headerFilter, err := NewHeaderFilter(headerProfilePtr, headerIncludePtr, headerExcludePtr)
+	if err != nil {
+		fmt.Printf("[!] Error setting up header filter: %v\n", err)
+		return
+	}
+	if disabled := FilterHeaders(collection.Item, headerFilter); disabled > 0 {
+		fmt.Printf("[+] ... Disabled %d filtered headers\n", disabled)
+	}
+	
+	// Number any Burp Intruder payload positions across the requests
+	intruderValues := NumberIntruderParams(collection.Item)
+	
+	// Nest the requests into folders while their URLs still hold the recorded hosts
+	baseDir := burpdirPtr
+	if baseDir == "" {
+		baseDir = filepath.Dir(curlinPtr)
+	}
+	grouped, err := GroupItems(collection.Item, groupByPtr, groupDepthPtr, baseDir)
+	if err != nil {
+		fmt.Printf("[!] Error grouping requests: %v\n", err)
+		return
+	}
+	collection.Item = grouped
+	
+	// Move the recorded origins into variables and environments
+	var environments []PostmanEnvironment
+	if baseURLPtr != "" {
+		bases, literal, err := ExtractBaseURLs(collection.Item, baseURLPtr)
+		if err != nil {
+			fmt.Printf("[!] Error extracting base URLs: %v\n", err)
+			return
+		}
+		if len(literal) > 0 {
+			fmt.Printf("[*] ... %d other origins keep their literal URLs, use -base-url per-host to cover them\n", len(literal))
+		}
+		rules, envNames, err := ParseEnvironmentRules(envRulesPtr)
+		if err != nil {
+			fmt.Printf("[!] Error parsing environment rules: %v\n", err)
+			return
+		}
+		collection.Variable = append(collection.Variable, BaseURLVariables(bases)...)
+		environments = BuildEnvironments(bases, rules, envNames)
+		fmt.Printf("[+] ... Extracted %d base URL variables\n", len(bases))
+	}
+	
+	// Replace secrets with variables before anything is written
+	if redactPtr != "" {
+		if redactPtr != "env" && redactPtr != "drop" {
+			fmt.Printf("[!] Unknown redaction mode %q, expected env or drop\n", redactPtr)
+			return
+		}
+		redactor := NewRedactor()
+		redactor.RedactItems(collection.Item)
+		if len(intruderValues) > 0 {
+			intruderValues = RedactIntruderValues(redactor, collection.Item, intruderValues)
+		}
+		secrets := NewPostmanEnvironment("secrets")
+		for _, value := range redactor.Values {
+			collection.Variable = append(collection.Variable, PostmanVariable{Key: value.Key, Value: "", Type: "string", Description: "Redacted secret"})
+			secrets.Set(value.Key, value.Value, "secret")
+		}
+		if redactPtr == "env" && len(redactor.Values) > 0 {
+			environments = append(environments, secrets)
+		}
+		fmt.Printf("[+] ... Redacted %d secrets into variables\n", len(redactor.Values))
+	}
+	
+	// Write a runner data file for the payload positions, once any secrets in their values are redacted
+	if len(intruderValues) > 0 {
+		dataFile := intruderDataPtr
+		if dataFile == "" {
+			dataFile = strings.TrimSuffix(outputFile, filepath.Ext(outputFile)) + "_data.csv"
+		}
+		
+		var payloadLists [][]string
+		if intruderPayloadsPtr != "" {
+			lists, err := LoadPayloadLists(intruderPayloadsPtr)
+			if err != nil {
+				fmt.Printf("[!] Error loading payload lists: %v\n", err)
+				return
+			}
+			payloadLists = lists
+		}
+		
+		if err := WriteIntruderData(dataFile, BuildIntruderRows(intruderValues, payloadLists)); err != nil {
+			fmt.Printf("[!] Error writing runner data file: %v\n", err)
+			return
+		}
+		fmt.Printf("[+] ... Wrote %d Intruder payload positions to runner data file: %s\n", len(intruderValues), dataFile)
+	}
+	
+	// Merge into the collection already at the output path, keeping what was edited in Postman
+	if updatePtr {
+		existing, err := LoadCollection(outputFile)
+		switch {
+		case os.IsNotExist(err):
+			fmt.Printf("[*] ... No existing collection at %s, writing a new one\n", outputFile)
+		case err != nil:
+			fmt.Printf("[!] Error loading existing collection: %v\n", err)
+			return
+		default:
+			merged, report, err := MergeCollections(existing, collection, updateModePtr)
+			if err != nil {
+				fmt.Printf("[!] Error updating collection: %v\n", err)
+				return
+			}
+			for _, endpoint := range report.Stale {
+				fmt.Printf("[*] ... Stale request no longer converted: %s\n", endpoint)
+			}
+			fmt.Printf("[+] ... Updated collection: %d added, %d replaced, %d kept, %d stale\n", report.Added, report.Replaced, report.Kept, len(report.Stale))
+			collection = merged
+		}
+	}
+	
 	// Write the collection to the output file
 	output, err := json.MarshalIndent(collection, "", "  ")
 	if err != nil {
@@ -240,7 +442,144 @@ func main() {
 		return
 	}
 	
-	fmt.Printf("[+] ... Successfully converted %d requests to Postman collection: %s\n", len(collection.Item), outputFile)
+	fmt.Printf("[+] ... Successfully converted %d requests to Postman collection: %s\n", CountRequests(collection.Item), outputFile)
+	
+	// Write the environments alongside the collection
+	if len(environments) > 0 {
+		files, err := WriteEnvironments(outputFile, environments)
+		if err != nil {
+			fmt.Printf("[!] Error writing environments: %v\n", err)
+			return
+		}
+		for _, file := range files {
+			fmt.Printf("[+] ... Wrote Postman environment: %s\n", file)
+		}
+	}
+	
+	// Export the collection to other formats
+	if harOutPtr != "" {
+		entries, err := WriteHAR(harOutPtr, collection)
+		if err != nil {
+			fmt.Printf("[!] Error exporting HAR: %v\n", err)
+			return
+		}
+		fmt.Printf("[+] ... Wrote %d entries to HAR file: %s\n", entries, harOutPtr)
+	}
+	if openAPIOutPtr != "" {
+		operations, err := WriteOpenAPI(openAPIOutPtr, collection)
+		if err != nil {
+			fmt.Printf("[!] Error exporting OpenAPI: %v\n", err)
+			return
+		}
+		fmt.Printf("[+] ... Wrote %d operations to OpenAPI document: %s\n", operations, openAPIOutPtr)
+	}
+	if burpOutPtr != "" {
+		burpItems, skipped, err := WriteBurpXML(burpOutPtr, collection)
+		if err != nil {
+			fmt.Printf("[!] Error exporting Burp XML: %v\n", err)
+			return
+		}
+		for _, reason := range skipped {
+			fmt.Printf("[*] ... Skipped %s\n", reason)
+		}
+		fmt.Printf("[+] ... Wrote %d items to Burp XML file: %s\n", burpItems, burpOutPtr)
+	}
+	if brunoOutPtr != "" {
+		requests, err := WriteBruno(brunoOutPtr, collection, environments)
+		if err != nil {
+			fmt.Printf("[!] Error exporting Bruno collection: %v\n", err)
+			return
+		}
+		fmt.Printf("[+] ... Wrote %d requests to Bruno collection: %s\n", requests, brunoOutPtr)
+	}
+	if insomniaOutPtr != "" {
+		requests, err := WriteInsomnia(insomniaOutPtr, collection, environments)
+		if err != nil {
+			fmt.Printf("[!] Error exporting Insomnia collection: %v\n", err)
+			return
+		}
+		fmt.Printf("[+] ... Wrote %d requests to Insomnia export: %s\n", requests, insomniaOutPtr)
+	}
+	if httpOutPtr != "" {
+		files, requests, err := WriteHTTPFiles(httpOutPtr, collection, environments)
+		if err != nil {
+			fmt.Printf("[!] Error exporting .http files: %v\n", err)
+			return
+		}
+		for _, file := range files {
+			fmt.Printf("[+] ... Wrote .http export file: %s\n", file)
+		}
+		fmt.Printf("[+] ... Wrote %d requests to .http files in: %s\n", requests, httpOutPtr)
+	}
+	if codeOutPtr != "" {
+		languages, err := ParseCodeLanguages(codeLangPtr)
+		if err != nil {
+			fmt.Printf("[!] Error generating client code: %v\n", err)
+			return
+		}
+		files, err := GenerateCode(codeOutPtr, collection, languages)
+		if err != nil {
+			fmt.Printf("[!] Error generating client code: %v\n", err)
+			return
+		}
+		for _, file := range files {
+			fmt.Printf("[+] ... Wrote generated client code: %s\n", file)
+		}
+	}
+	if k6OutPtr != "" {
+		requests, err := WriteK6Script(k6OutPtr, collection)
+		if err != nil {
+			fmt.Printf("[!] Error exporting k6 script: %v\n", err)
+			return
+		}
+		fmt.Printf("[+] ... Wrote %d requests to k6 script: %s\n", requests, k6OutPtr)
+	}
+	if jmeterOutPtr != "" {
+		requests, csvFile, err := WriteJMeterPlan(jmeterOutPtr, collection)
+		if err != nil {
+			fmt.Printf("[!] Error exporting JMeter plan: %v\n", err)
+			return
+		}
+		fmt.Printf("[+] ... Wrote %d requests to JMeter test plan: %s\n", requests, jmeterOutPtr)
+		if csvFile != "" {
+			fmt.Printf("[+] ... Wrote JMeter variables file: %s\n", csvFile)
+		}
+	}
+	if rawOutPtr != "" {
+		requests, skipped, err := WriteRawRequests(rawOutPtr, collection, rawPoint, rawEach, rawMarkerPtr)
+		if err != nil {
+			fmt.Printf("[!] Error exporting raw requests: %v\n", err)
+			return
+		}
+		for _, reason := range skipped {
+			fmt.Printf("[*] ... Skipped %s\n", reason)
+		}
+		fmt.Printf("[+] ... Wrote %d raw request files to: %s\n", requests, rawOutPtr)
+	}
+	if curlOutPtr != "" {
+		shell := curlShellPtr
+		if shell == "" {
+			shell = CurlShellForFile(curlOutPtr)
+		}
+		var resolved map[string]string
+		if curlEnvPtr != "" {
+			if resolved, err = LoadEnvironment(curlEnvPtr); err != nil {
+				fmt.Printf("[!] Error exporting cURL commands: %v\n", err)
+				return
+			}
+		}
+		script, err := NewCurlScript(shell, resolved, collection)
+		if err != nil {
+			fmt.Printf("[!] Error exporting cURL commands: %v\n", err)
+			return
+		}
+		commands, err := WriteCurlScript(curlOutPtr, collection, script)
+		if err != nil {
+			fmt.Printf("[!] Error exporting cURL commands: %v\n", err)
+			return
+		}
+		fmt.Printf("[+] ... Wrote %d cURL commands to %s script: %s\n", commands, shell, curlOutPtr)
+	}
 }
 /* 
 	###################################### END MAIN FUNCTION ########################################################### 
@@ -249,6 +588,90 @@ func main() {
 	################################### FILE PROCESSING FUNCTIONS ######################################################
 */
 
+// ProcessDirectory walks a directory recursively and converts every Burp XML and cURL commands file in it
+func ProcessDirectory(inputDir string, progress io.Writer) ([]PostmanItem, error) {
+	var all []PostmanItem
+	err := filepath.Walk(inputDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			fmt.Fprintf(progress, "[!] Error accessing path %s: %v\n", path, err)
+			return err
+		}
+		
+		if info.IsDir() {
+			return nil
+		}
+		
+		ext := strings.ToLower(filepath.Ext(path))
+		
+		switch {
+		case ext == ".xml":
+			// Check if it's a Burp XML file
+			file, err := os.Open(path)
+			if err != nil {
+				fmt.Fprintf(progress, "[!] Error opening file %s: %v\n", path, err)
+				return nil
+			}
+			
+			// Read the first few bytes to check if it looks like a Burp XML file
+			buffer := make([]byte, 256)
+			_, err = file.Read(buffer)
+			file.Close()
+			
+			if err != nil && err != io.EOF {
+				fmt.Fprintf(progress, "[!] Error reading file %s: %v\n", path, err)
+				return nil
+			}
+			
+			// Check if it contains Burp XML signature
+			content := string(buffer)
+			if strings.Contains(content, "<!DOCTYPE items") || strings.Contains(content, "<items burpVersion") {
+				fmt.Fprintf(progress, "[+] ... Processing Burp XML file: %s\n", path)
+				items, err := ProcessBurpXML(path, progress)
+				if err != nil {
+					fmt.Fprintf(progress, "[!] Error processing Burp XML file %s: %v\n", path, err)
+					return nil
+				}
+				all = append(all, items...)
+			}
+			
+		case ext == ".txt", ext == ".curl":
+			// Check if it's a cURL commands file
+			file, err := os.Open(path)
+			if err != nil {
+				fmt.Fprintf(progress, "[!] Error opening file %s: %v\n", path, err)
+				return nil
+			}
+			
+			// Read the first line to check if it's a cURL file
+			scanner := bufio.NewScanner(file)
+			var firstLine string
+			if scanner.Scan() {
+				firstLine = scanner.Text()
+			}
+			file.Close()
+			
+			if err := scanner.Err(); err != nil {
+				fmt.Fprintf(progress, "[!] Error reading file %s: %v\n", path, err)
+				return nil
+			}
+			
+			if strings.HasPrefix(firstLine, "curl ") {
+				fmt.Fprintf(progress, "[+] ... Processing cURL commands file: %s\n", path)
+				items, err := ProcessCurlFile(path, progress)
+				if err != nil {
+					fmt.Fprintf(progress, "[!] Error processing cURL file %s: %v\n", path, err)
+					return nil
+				}
+				all = append(all, items...)
+			}
+		}
+		
+		return nil
+	})
+	
+	return all, err
+}
+
 // ParseCurlCommand parses a cURL command and returns a PostmanItem
 func ParseCurlCommand(curlCmd string, index int) (PostmanItem, error) {
 	item := PostmanItem{
@@ -441,7 +864,10 @@ func ParseCurlCommand(curlCmd string, index int) (PostmanItem, error) {
 }
 
 // ParseHttpRequest parses an HTTP request string and returns a PostmanItem
-func ParseHttpRequest(reqStr string, index int, name string) (PostmanItem, error) {
+//
+// When burp is not nil its protocol, host and port decide where the request is sent, rather than the
+// request line or Host header.
+func ParseHttpRequest(reqStr string, index int, name string, burp *BurpItem) (PostmanItem, error) {
 	item := PostmanItem{
 		Name: name,
 	}
@@ -449,138 +875,120 @@ func ParseHttpRequest(reqStr string, index int, name string) (PostmanItem, error
 	// Initialize request structure
 	item.Request.Header = []PostmanHeader{}
 	
-	// Split the request into lines
-	lines := strings.Split(reqStr, "\n")
-	if len(lines) == 0 {
-		return item, fmt.Errorf("empty request")
-	}
+	// Convert Burp Intruder payload positions into {{param_N}} variables
+	reqStr = ReplaceIntruderMarkers(reqStr, &item.IntruderValues)
 	
-	// Parse the first line (method, path, HTTP version)
-	firstLine := strings.TrimSpace(lines[0])
-	firstLineParts := strings.Split(firstLine, " ")
-	if len(firstLineParts) < 2 {
-		return item, fmt.Errorf("invalid request line: %s", firstLine)
+	// Decode the request; Intruder templates keep the Content-Length of the unmarked request
+	rawReq, err := ParseRawHTTPRequest([]byte(reqStr), len(item.IntruderValues) == 0)
+	if err != nil {
+		return item, fmt.Errorf("invalid HTTP request: %v", err)
 	}
 	
-	method := firstLineParts[0]
-	path := firstLineParts[1]
+	method := rawReq.Method
+	path := rawReq.Target
 	item.Request.Method = method
 	
-	// Parse headers
-	var bodyStartIndex int
-	var host string
+	// Parse headers, keeping their order and any duplicates
+	host := rawReq.Get("Host")
+	if host == "" {
+		host = rawReq.PseudoHeader(":authority")
+	}
 	
-	for i := 1; i < len(lines); i++ {
-		line := strings.TrimSpace(lines[i])
-		if line == "" {
-			bodyStartIndex = i + 1
-			break
+	// A chunked body is saved decoded, so its framing headers are made to match it as for responses
+	chunked := false
+	for _, rawHeader := range rawReq.Headers {
+		if strings.EqualFold(rawHeader.Name, "Transfer-Encoding") {
+			codings := strings.Split(strings.ToLower(rawHeader.Value), ",")
+			chunked = strings.TrimSpace(codings[len(codings)-1]) == "chunked"
 		}
-		
-		headerParts := strings.SplitN(line, ":", 2)
-		if len(headerParts) == 2 {
-			key := strings.TrimSpace(headerParts[0])
-			value := strings.TrimSpace(headerParts[1])
-			
-			header := PostmanHeader{
-				Key:   key,
-				Value: value,
-				Type:  "text",
-			}
-			item.Request.Header = append(item.Request.Header, header)
-			
-			if strings.ToLower(key) == "host" {
-				host = value
+	}
+	hasLength := false
+	
+	for _, rawHeader := range rawReq.Headers {
+		key := rawHeader.Name
+		value := rawHeader.Value
+		if chunked {
+			switch strings.ToLower(key) {
+			case "transfer-encoding":
+				continue
+			case "content-length":
+				if hasLength {
+					continue
+				}
+				hasLength, value = true, strconv.Itoa(len(rawReq.Body))
 			}
-			
-			// Check for Authorization header
-			if strings.ToLower(key) == "authorization" {
-				if strings.HasPrefix(value, "Bearer ") {
-					item.Request.Auth = &PostmanAuth{
-						Type: "bearer",
-						Bearer: []PostmanAuthDetail{
-							{
-								Key:   "token",
-								Value: strings.TrimPrefix(value, "Bearer "),
-								Type:  "string",
-							},
+		}
+		
+		header := PostmanHeader{
+			Key:   key,
+			Value: value,
+			Type:  "text",
+		}
+		item.Request.Header = append(item.Request.Header, header)
+		
+		// Check for Authorization header
+		if strings.ToLower(key) == "authorization" {
+			if strings.HasPrefix(value, "Bearer ") {
+				item.Request.Auth = &PostmanAuth{
+					Type: "bearer",
+					Bearer: []PostmanAuthDetail{
+						{
+							Key:   "token",
+							Value: strings.TrimPrefix(value, "Bearer "),
+							Type:  "string",
 						},
-					}
-				} else if strings.HasPrefix(value, "Basic ") {
-					item.Request.Auth = &PostmanAuth{
-						Type: "basic",
-						Basic: []PostmanAuthDetail{
-							{
-								Key:   "password",
-								Value: strings.TrimPrefix(value, "Basic "),
-								Type:  "string",
-							},
+					},
+				}
+			} else if strings.HasPrefix(value, "Basic ") {
+				item.Request.Auth = &PostmanAuth{
+					Type: "basic",
+					Basic: []PostmanAuthDetail{
+						{
+							Key:   "password",
+							Value: strings.TrimPrefix(value, "Basic "),
+							Type:  "string",
 						},
-					}
+					},
 				}
 			}
 		}
 	}
 	
+	if chunked && !hasLength {
+		item.Request.Header = append(item.Request.Header, PostmanHeader{Key: "Content-Length", Value: strconv.Itoa(len(rawReq.Body)), Type: "text"})
+	}
+	
 	// Extract request body
-	var body string
-	if bodyStartIndex > 0 && bodyStartIndex < len(lines) {
-		body = strings.Join(lines[bodyStartIndex:], "\n")
-		if body != "" {
-			// Determine content type
-			contentType := "text/plain"
-			for _, header := range item.Request.Header {
-				if strings.ToLower(header.Key) == "content-type" {
-					contentType = header.Value
-					break
-				}
-			}
-			
-			item.Request.Body = PostmanBody{
-				Mode: "raw",
-				Raw:  body,
-			}
-			
-			// Set language based on content type
-			if strings.Contains(contentType, "json") {
-				item.Request.Body.Options = map[string]interface{}{
-					"raw": map[string]interface{}{
-						"language": "json",
-					},
-				}
-			} else if strings.Contains(contentType, "xml") {
-				item.Request.Body.Options = map[string]interface{}{
-					"raw": map[string]interface{}{
-						"language": "xml",
-					},
-				}
-			} else if strings.Contains(contentType, "javascript") {
-				item.Request.Body.Options = map[string]interface{}{
-					"raw": map[string]interface{}{
-						"language": "javascript",
-					},
-				}
-			} else if strings.Contains(contentType, "html") {
-				item.Request.Body.Options = map[string]interface{}{
-					"raw": map[string]interface{}{
-						"language": "html",
-					},
-				}
-			} else {
-				item.Request.Body.Options = map[string]interface{}{
-					"raw": map[string]interface{}{
-						"language": "text",
-					},
-				}
+	if len(rawReq.Body) > 0 {
+		body := string(rawReq.Body)
+		
+		// Determine content type
+		contentType := "text/plain"
+		for _, header := range item.Request.Header {
+			if strings.ToLower(header.Key) == "content-type" {
+				contentType = header.Value
+				break
 			}
 		}
+		
+		item.Request.Body = PostmanBody{
+			Mode: "raw",
+			Raw:  body,
+		}
+		
+		// Set language based on content type
+		item.Request.Body.Options = map[string]interface{}{
+			"raw": map[string]interface{}{
+				"language": BodyLanguage(contentType),
+			},
+		}
 	}
 	
 	// Construct URL
 	var protocol string
 	
-	// Check if the request is secure (HTTPS)
-	if strings.Contains(path, "https://") {
+	// Absolute-form targets, as sent to a proxy, carry the scheme and host; origin-form targets start with /
+	if strings.HasPrefix(path, "https://") {
 		protocol = "https"
 		path = strings.TrimPrefix(path, "https://")
 		// Extract host from URL if present
@@ -593,7 +1001,7 @@ func ParseHttpRequest(reqStr string, index int, name string) (PostmanItem, error
 				path = "/"
 			}
 		}
-	} else if strings.Contains(path, "http://") {
+	} else if strings.HasPrefix(path, "http://") {
 		protocol = "http"
 		path = strings.TrimPrefix(path, "http://")
 		// Extract host from URL if present
@@ -606,6 +1014,9 @@ func ParseHttpRequest(reqStr string, index int, name string) (PostmanItem, error
 				path = "/"
 			}
 		}
+	} else if scheme := rawReq.PseudoHeader(":scheme"); scheme != "" {
+		// HTTP/2 requests carry the scheme as a pseudo-header
+		protocol = scheme
 	} else {
 		// Default to HTTPS if not specified
 		protocol = "https"
@@ -648,8 +1059,26 @@ func ParseHttpRequest(reqStr string, index int, name string) (PostmanItem, error
 		pathComponents = filteredComponents
 	}
 	
+	// Burp metadata is the source of truth for scheme, host and port
+	hostName, port := SplitHostPort(host)
+	if burp != nil {
+		if targetProtocol, targetHost, targetPort, ok := BurpTarget(*burp); ok {
+			if host != "" && !SameAuthority(host, targetHost, targetPort, targetProtocol) {
+				item.Description = appendLine(item.Description, fmt.Sprintf(
+					"Warning: the Host header %q does not match the Burp target %s; the header was kept as sent.",
+					host, JoinHostPort(targetHost, targetPort, targetProtocol)))
+			}
+			protocol = targetProtocol
+			hostName = targetHost
+			port = targetPort
+		}
+	}
+	if port == DefaultPort(protocol) {
+		port = ""
+	}
+	
 	// Construct full URL
-	fullURL := fmt.Sprintf("%s://%s", protocol, host)
+	fullURL := fmt.Sprintf("%s://%s", protocol, JoinHostPort(hostName, port, protocol))
 	if len(pathComponents) > 0 {
 		fullURL += "/" + strings.Join(pathComponents, "/")
 	}
@@ -670,7 +1099,8 @@ func ParseHttpRequest(reqStr string, index int, name string) (PostmanItem, error
 	item.Request.URL = PostmanURL{
 		Raw:      fullURL,
 		Protocol: protocol,
-		Host:     strings.Split(host, "."),
+		Host:     strings.Split(hostName, "."),
+		Port:     port,
 		Path:     pathComponents,
 		Query:    queryParams,
 	}
@@ -680,14 +1110,14 @@ func ParseHttpRequest(reqStr string, index int, name string) (PostmanItem, error
 		lastComponent := pathComponents[len(pathComponents)-1]
 		item.Name = fmt.Sprintf("%s %s", method, lastComponent)
 	} else {
-		item.Name = fmt.Sprintf("%s %s", method, host)
+		item.Name = fmt.Sprintf("%s %s", method, hostName)
 	}
 	
 	return item, nil
 }
 
-// ProcessBurpXML processes a Burp XML file and returns PostmanItems
-func ProcessBurpXML(filePath string) ([]PostmanItem, error) {
+// ProcessBurpXML processes a Burp XML file and returns PostmanItems, writing warnings about skipped items to progress
+func ProcessBurpXML(filePath string, progress io.Writer) ([]PostmanItem, error) {
 	xmlFile, err := os.Open(filePath)
 	if err != nil {
 		return nil, fmt.Errorf("error opening Burp XML file: %v", err)
@@ -709,7 +1139,7 @@ func ProcessBurpXML(filePath string) ([]PostmanItem, error) {
 			// Decode base64 request
 			data, err := base64.StdEncoding.DecodeString(item.Request.Content)
 			if err != nil {
-				fmt.Printf("Warning: Could not decode base64 request for item %d: %v\n", i+1, err)
+				fmt.Fprintf(progress, "Warning: Could not decode base64 request for item %d: %v\n", i+1, err)
 				continue
 			}
 			reqData = string(data)
@@ -732,20 +1162,42 @@ func ProcessBurpXML(filePath string) ([]PostmanItem, error) {
 		}
 		
 		name := fmt.Sprintf("%s %s", item.Method, resourceName)
-		postmanItem, err := ParseHttpRequest(reqData, i+1, name)
+		postmanItem, err := ParseHttpRequest(reqData, i+1, name, &burpItems.Items[i])
 		if err != nil {
-			fmt.Printf("Warning: Could not parse HTTP request for item %d: %v\n", i+1, err)
+			fmt.Fprintf(progress, "Warning: Could not parse HTTP request for item %d: %v\n", i+1, err)
 			continue
 		}
 		
+		// Keep the Burp metadata for ordering, grouping and filtering
+		postmanItem.Source = &ItemSource{
+			File:       filePath,
+			Burp:       &burpItems.Items[i],
+			ExportTime: burpItems.ExportTime,
+		}
+		if recorded := strings.TrimSpace(item.Time); recorded != "" {
+			postmanItem.Source.Time, _ = ParseBurpTime(recorded)
+			postmanItem.Description = appendLine(postmanItem.Description, fmt.Sprintf("Recorded by Burp: %s", recorded))
+		}
+		
+		// Attach the recorded response as a saved example
+		if strings.TrimSpace(item.Response.Content) != "" {
+			response, err := BuildBurpResponse(item, postmanItem.Request)
+			if err != nil {
+				fmt.Fprintf(progress, "Warning: Could not parse HTTP response for item %d: %v\n", i+1, err)
+			} else {
+				postmanItem.Response = append(postmanItem.Response, *response)
+			}
+		}
+		
 		items = append(items, postmanItem)
 	}
 	
 	return items, nil
 }
 
-// ProcessCurlFile processes a file containing cURL commands and returns PostmanItems
-func ProcessCurlFile(filePath string) ([]PostmanItem, error) {
+// ProcessCurlFile processes a file containing cURL commands and returns PostmanItems, writing warnings about
+// skipped lines to progress
+func ProcessCurlFile(filePath string, progress io.Writer) ([]PostmanItem, error) {
 	file, err := os.Open(filePath)
 	if err != nil {
 		return nil, fmt.Errorf("error opening cURL file: %v", err)
@@ -761,7 +1213,7 @@ func ProcessCurlFile(filePath string) ([]PostmanItem, error) {
 		if strings.HasPrefix(curlCmd, "curl ") {
 			item, err := ParseCurlCommand(curlCmd, index)
 			if err != nil {
-				fmt.Printf("Warning: Could not parse cURL command at line %d: %v\n", index, err)
+				fmt.Fprintf(progress, "Warning: Could not parse cURL command at line %d: %v\n", index, err)
 				continue
 			}
 			items = append(items, item)
@@ -842,6 +1294,125 @@ func ParseURL(urlStr string) (PostmanURL, error) {
 	
 	return result, nil
 }
+
+// BurpTarget returns the protocol, host and port a Burp item was sent to, from its metadata or its URL
+func BurpTarget(burp BurpItem) (protocol, host, port string, ok bool) {
+	protocol = strings.ToLower(strings.TrimSpace(burp.Protocol))
+	host = strings.Trim(strings.TrimSpace(burp.Host), "[]")
+	port = strings.TrimSpace(burp.Port)
+	
+	// Fall back to the <url> element for anything the metadata does not carry
+	if (protocol == "" || host == "") && burp.URL != "" {
+		if u, err := url.Parse(strings.TrimSpace(burp.URL)); err == nil && u.Host != "" {
+			if protocol == "" {
+				protocol = strings.ToLower(u.Scheme)
+			}
+			if host == "" {
+				host = u.Hostname()
+				port = u.Port()
+			}
+		}
+	}
+	
+	if protocol == "" || host == "" {
+		return "", "", "", false
+	}
+	if port == "" {
+		port = DefaultPort(protocol)
+	}
+	return protocol, host, port, true
+}
+
+// SplitHostPort splits a Host header value into host and port, allowing for bracketed IPv6 addresses
+func SplitHostPort(hostport string) (string, string) {
+	if strings.HasPrefix(hostport, "[") {
+		if end := strings.Index(hostport, "]"); end > 0 {
+			return hostport[1:end], strings.TrimPrefix(hostport[end+1:], ":")
+		}
+	}
+	if colon := strings.LastIndex(hostport, ":"); colon >= 0 && strings.Count(hostport, ":") == 1 {
+		return hostport[:colon], hostport[colon+1:]
+	}
+	return hostport, ""
+}
+
+// JoinHostPort joins a host and port for a URL, leaving out the default port for the protocol
+func JoinHostPort(host, port, protocol string) string {
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	if port == "" || port == DefaultPort(protocol) {
+		return host
+	}
+	return host + ":" + port
+}
+
+// DefaultPort returns the default port for http and https
+func DefaultPort(protocol string) string {
+	switch strings.ToLower(protocol) {
+	case "http":
+		return "80"
+	case "https":
+		return "443"
+	}
+	return ""
+}
+
+// SameAuthority reports whether a Host header value refers to the given host and port
+func SameAuthority(hostHeader, host, port, protocol string) bool {
+	headerHost, headerPort := SplitHostPort(hostHeader)
+	if headerPort == "" {
+		headerPort = DefaultPort(protocol)
+	}
+	return strings.EqualFold(headerHost, host) && headerPort == port
+}
+
+// appendLine appends a line of text to a description
+func appendLine(description, line string) string {
+	if description == "" {
+		return line
+	}
+	return description + "\n" + line
+}
+
+// RewriteRequestStrings applies fn to every user visible string in a request: URL, headers, body and auth
+func RewriteRequestStrings(req *PostmanRequest, fn func(string) string) {
+	req.URL.Raw = fn(req.URL.Raw)
+	for i := range req.URL.Host {
+		req.URL.Host[i] = fn(req.URL.Host[i])
+	}
+	for i := range req.URL.Path {
+		req.URL.Path[i] = fn(req.URL.Path[i])
+	}
+	for i := range req.URL.Query {
+		req.URL.Query[i].Key = fn(req.URL.Query[i].Key)
+		req.URL.Query[i].Value = fn(req.URL.Query[i].Value)
+	}
+	for i := range req.URL.Variable {
+		req.URL.Variable[i].Value = fn(req.URL.Variable[i].Value)
+	}
+	for i := range req.Header {
+		req.Header[i].Key = fn(req.Header[i].Key)
+		req.Header[i].Value = fn(req.Header[i].Value)
+	}
+	req.Body.Raw = fn(req.Body.Raw)
+	for i := range req.Body.Urlencoded {
+		req.Body.Urlencoded[i].Key = fn(req.Body.Urlencoded[i].Key)
+		req.Body.Urlencoded[i].Value = fn(req.Body.Urlencoded[i].Value)
+	}
+	for i := range req.Body.Formdata {
+		req.Body.Formdata[i].Key = fn(req.Body.Formdata[i].Key)
+		req.Body.Formdata[i].Value = fn(req.Body.Formdata[i].Value)
+	}
+	if req.Auth != nil {
+		for i := range req.Auth.Bearer {
+			req.Auth.Bearer[i].Value = fn(req.Auth.Bearer[i].Value)
+		}
+		for i := range req.Auth.Basic {
+			req.Auth.Basic[i].Value = fn(req.Auth.Basic[i].Value)
+		}
+	}
+}
 /* 
 	####################################### DATA STRUCTURES ############################################################ 
 */
@@ -854,13 +1425,42 @@ type PostmanCollection struct {
 		PostmanID   string    `json:"_postman_id"`
 		Updated     time.Time `json:"updatedAt"`
 	} `json:"info"`
-	Item []PostmanItem `json:"item"`
+	Item     []PostmanItem     `json:"item"`
+	Variable []PostmanVariable `json:"variable,omitempty"`
+
+	// Original holds the JSON the collection was loaded from, so fields not modelled here are written back
+	Original json.RawMessage `json:"-"`
 }
 
 // PostmanItem represents a request in the Postman collection
+//
+// An item with a non-nil Item list is a folder; it carries no request of its own.
 type PostmanItem struct {
-	Name    string         `json:"name"`
-	Request PostmanRequest `json:"request"`
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	Item        []PostmanItem  `json:"item,omitempty"`
+	Auth        *PostmanAuth   `json:"auth,omitempty"`
+	Variable    []PostmanVariable `json:"variable,omitempty"`
+	Request     PostmanRequest `json:"request"`
+	Response    []PostmanResponse `json:"response,omitempty"`
+	Event       []PostmanEvent    `json:"event,omitempty"`
+	
+	// IntruderValues holds the original values of any Burp Intruder payload positions
+	IntruderValues []string `json:"-"`
+	// Extract holds the values this item's response produces for later requests
+	Extract []CorrelationRule `json:"-"`
+	// Source holds the Burp metadata the item was converted from, if any
+	Source *ItemSource `json:"-"`
+	// Original holds the JSON the item was loaded from, so fields not modelled here are written back
+	Original json.RawMessage `json:"-"`
+}
+
+// ItemSource represents where a converted item came from; it is not written to the collection
+type ItemSource struct {
+	File       string
+	Burp       *BurpItem
+	ExportTime string
+	Time       time.Time
 }
 
 // PostmanRequest represents the request details
@@ -872,11 +1472,64 @@ type PostmanRequest struct {
 	Auth   *PostmanAuth      `json:"auth,omitempty"`
 }
 
+// PostmanResponse represents a saved example response for a request
+type PostmanResponse struct {
+	Name            string          `json:"name"`
+	OriginalRequest *PostmanRequest `json:"originalRequest,omitempty"`
+	Status          string          `json:"status"`
+	Code            int             `json:"code,omitempty"`
+	PreviewLanguage string          `json:"_postman_previewlanguage,omitempty"`
+	Header          []PostmanHeader `json:"header"`
+	Body            string          `json:"body"`
+
+	// Original holds the JSON the example was loaded from, so fields not modelled here are written back
+	Original json.RawMessage `json:"-"`
+}
+
+// PostmanEvent represents a script attached to an item, such as a test script
+type PostmanEvent struct {
+	Listen string        `json:"listen"`
+	Script PostmanScript `json:"script"`
+}
+
+// PostmanScript represents the source of an event script
+type PostmanScript struct {
+	Type string   `json:"type"`
+	Exec []string `json:"exec"`
+}
+
+// PostmanEnvironment represents a Postman environment file
+type PostmanEnvironment struct {
+	ID            string                    `json:"id"`
+	Name          string                    `json:"name"`
+	Values        []PostmanEnvironmentValue `json:"values"`
+	Scope         string                    `json:"_postman_variable_scope"`
+	ExportedAt    time.Time                 `json:"_postman_exported_at"`
+	ExportedUsing string                    `json:"_postman_exported_using"`
+}
+
+// PostmanEnvironmentValue represents a single value in a Postman environment
+type PostmanEnvironmentValue struct {
+	Key     string `json:"key"`
+	Value   string `json:"value"`
+	Type    string `json:"type"`
+	Enabled bool   `json:"enabled"`
+}
+
+// PostmanVariable represents a variable defined on a collection, folder or URL
+type PostmanVariable struct {
+	Key         string `json:"key"`
+	Value       string `json:"value"`
+	Type        string `json:"type,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
 // PostmanHeader represents a header in the request
 type PostmanHeader struct {
-	Key   string `json:"key"`
-	Value string `json:"value"`
-	Type  string `json:"type"`
+	Key      string `json:"key"`
+	Value    string `json:"value"`
+	Type     string `json:"type"`
+	Disabled bool   `json:"disabled,omitempty"`
 }
 
 // PostmanQueryParam represents a query parameter
@@ -887,9 +1540,20 @@ type PostmanQueryParam struct {
 
 // PostmanBody represents the request body
 type PostmanBody struct {
-	Mode    string                 `json:"mode"`
-	Raw     string                 `json:"raw,omitempty"`
-	Options map[string]interface{} `json:"options,omitempty"`
+	Mode       string                 `json:"mode"`
+	Raw        string                 `json:"raw,omitempty"`
+	Urlencoded []PostmanFormParam     `json:"urlencoded,omitempty"`
+	Formdata   []PostmanFormParam     `json:"formdata,omitempty"`
+	Options    map[string]interface{} `json:"options,omitempty"`
+}
+
+// PostmanFormParam represents a urlencoded or multipart form field, with type "file" and src for file uploads
+type PostmanFormParam struct {
+	Key      string `json:"key"`
+	Value    string `json:"value,omitempty"`
+	Type     string `json:"type,omitempty"`
+	Src      string `json:"src,omitempty"`
+	Disabled bool   `json:"disabled,omitempty"`
 }
 
 // PostmanURL represents the URL details
@@ -897,8 +1561,10 @@ type PostmanURL struct {
 	Raw      string            `json:"raw"`
 	Protocol string            `json:"protocol"`
 	Host     []string          `json:"host"`
+	Port     string            `json:"port,omitempty"`
 	Path     []string          `json:"path"`
 	Query    []PostmanQueryParam `json:"query,omitempty"`
+	Variable []PostmanVariable   `json:"variable,omitempty"`
 }
 
 // PostmanAuth represents authentication details
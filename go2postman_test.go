@@ -0,0 +1,209 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// burpTestItem is one recorded request and response written by writeBurpXML
+type burpTestItem struct {
+	url, request, response, time string
+}
+
+// writeBurpXML writes the items as a base64 Burp XML export in a temporary directory and returns its path
+func writeBurpXML(t *testing.T, items ...burpTestItem) string {
+	t.Helper()
+	var out strings.Builder
+	out.WriteString(`<?xml version="1.0"?>` + "\n" + `<items burpVersion="2023.1" exportTime="Mon Oct 19 10:00:00 UTC 2026">` + "\n")
+	for _, item := range items {
+		u, err := ParseURL(item.url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		method, _, _ := strings.Cut(item.request, " ")
+		port := u.Port
+		if port == "" {
+			port = DefaultPort(u.Protocol)
+		}
+		fmt.Fprintf(&out, "<item><time>%s</time><url><![CDATA[%s]]></url><host ip=\"127.0.0.1\">%s</host><port>%s</port>"+
+			"<protocol>%s</protocol><method>%s</method><path><![CDATA[/%s]]></path><extension>null</extension>"+
+			"<request base64=\"true\"><![CDATA[%s]]></request><status></status><responselength></responselength><mimetype></mimetype>"+
+			"<response base64=\"true\"><![CDATA[%s]]></response><comment></comment></item>\n",
+			item.time, item.url, strings.Join(u.Host, "."), port, u.Protocol, method, strings.Join(u.Path, "/"),
+			base64.StdEncoding.EncodeToString([]byte(item.request)), base64.StdEncoding.EncodeToString([]byte(item.response)))
+	}
+	out.WriteString("</items>\n")
+
+	fileName := filepath.Join(t.TempDir(), "burp.xml")
+	if err := os.WriteFile(fileName, []byte(out.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return fileName
+}
+
+func TestParseHttpRequestTargets(t *testing.T) {
+	tests := []struct {
+		name        string
+		raw         string
+		burp        *BurpItem
+		url         string
+		description string
+	}{
+		{
+			name: "origin-form with a URL in the query",
+			raw:  "GET /login?next=https://evil.example/ HTTP/1.1\r\nHost: api.example.com\r\n\r\n",
+			url:  "https://api.example.com/login?next=https://evil.example/",
+		},
+		{
+			name: "absolute-form target",
+			raw:  "GET http://api.example.com:8080/users?page=2 HTTP/1.1\r\nHost: api.example.com:8080\r\n\r\n",
+			url:  "http://api.example.com:8080/users?page=2",
+		},
+		{
+			name: "HTTP/2 scheme pseudo-header",
+			raw:  "GET /me?return=http://x/ HTTP/2\r\n:scheme: http\r\n:authority: api.example.com\r\n\r\n",
+			url:  "http://api.example.com/me?return=http://x/",
+		},
+		{
+			name:        "Host header differing from the Burp target",
+			raw:         "GET /login?next=https://x/ HTTP/1.1\r\nHost: other.example.com\r\n\r\n",
+			burp:        &BurpItem{Host: "api.example.com", Port: "443", Protocol: "https", URL: "https://api.example.com/login?next=https://x/"},
+			url:         "https://api.example.com/login?next=https://x/",
+			description: `the Host header "other.example.com" does not match the Burp target api.example.com`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			item, err := ParseHttpRequest(tt.raw, 1, "request", tt.burp)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if item.Request.URL.Raw != tt.url {
+				t.Errorf("URL = %q, want %q", item.Request.URL.Raw, tt.url)
+			}
+			if !strings.Contains(item.Description, tt.description) {
+				t.Errorf("description = %q, want it to contain %q", item.Description, tt.description)
+			}
+		})
+	}
+}
+
+func TestParseHttpRequestDecodesChunkedFraming(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		headers [][2]string
+		body    string
+	}{
+		{
+			name:    "chunked without Content-Length",
+			raw:     "POST /upload HTTP/1.1\r\nHost: api.example.com\r\nTransfer-Encoding: chunked\r\nContent-Type: text/plain\r\n\r\n5\r\nhello\r\n6\r\n world\r\n0\r\n\r\n",
+			headers: [][2]string{{"Host", "api.example.com"}, {"Content-Type", "text/plain"}, {"Content-Length", "11"}},
+			body:    "hello world",
+		},
+		{
+			name:    "chunked with a stale Content-Length",
+			raw:     "POST /upload HTTP/1.1\r\nHost: api.example.com\r\nContent-Length: 99\r\nTransfer-Encoding: chunked\r\n\r\n3\r\nabc\r\n0\r\n\r\n",
+			headers: [][2]string{{"Host", "api.example.com"}, {"Content-Length", "3"}},
+			body:    "abc",
+		},
+		{
+			name:    "Content-Length framing is kept",
+			raw:     "POST /upload HTTP/1.1\r\nHost: api.example.com\r\nContent-Length: 3\r\n\r\nabc",
+			headers: [][2]string{{"Host", "api.example.com"}, {"Content-Length", "3"}},
+			body:    "abc",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			item, err := ParseHttpRequest(tt.raw, 1, "request", nil)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var headers [][2]string
+			for _, header := range item.Request.Header {
+				headers = append(headers, [2]string{header.Key, header.Value})
+			}
+			if fmt.Sprint(headers) != fmt.Sprint(tt.headers) {
+				t.Errorf("headers = %q, want %q", headers, tt.headers)
+			}
+			if item.Request.Body.Raw != tt.body {
+				t.Errorf("body = %q, want %q", item.Request.Body.Raw, tt.body)
+			}
+		})
+	}
+}
+
+func TestBurpTarget(t *testing.T) {
+	tests := []struct {
+		name                 string
+		burp                 BurpItem
+		protocol, host, port string
+		ok                   bool
+	}{
+		{"metadata", BurpItem{Protocol: "HTTPS", Host: "api.example.com", Port: "8443"}, "https", "api.example.com", "8443", true},
+		{"default port", BurpItem{Protocol: "http", Host: "api.example.com"}, "http", "api.example.com", "80", true},
+		{"bracketed IPv6 host", BurpItem{Protocol: "http", Host: "[::1]", Port: "8080"}, "http", "::1", "8080", true},
+		{"URL fallback", BurpItem{URL: "https://api.example.com:9443/x"}, "https", "api.example.com", "9443", true},
+		{"missing target", BurpItem{Port: "443"}, "", "", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			protocol, host, port, ok := BurpTarget(tt.burp)
+			if protocol != tt.protocol || host != tt.host || port != tt.port || ok != tt.ok {
+				t.Errorf("BurpTarget = %q, %q, %q, %v, want %q, %q, %q, %v", protocol, host, port, ok, tt.protocol, tt.host, tt.port, tt.ok)
+			}
+		})
+	}
+}
+
+func TestParseHttpRequestUsesBurpMetadata(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		burp BurpItem
+		url  string
+		port string
+	}{
+		{
+			name: "non-default port from metadata",
+			raw:  "GET /users HTTP/1.1\r\nHost: api.example.com:8443\r\n\r\n",
+			burp: BurpItem{Protocol: "https", Host: "api.example.com", Port: "8443"},
+			url:  "https://api.example.com:8443/users",
+			port: "8443",
+		},
+		{
+			name: "plain HTTP on the default port",
+			raw:  "GET /users HTTP/1.1\r\nHost: api.example.com:80\r\n\r\n",
+			burp: BurpItem{Protocol: "http", Host: "api.example.com", Port: "80"},
+			url:  "http://api.example.com/users",
+		},
+		{
+			name: "IPv6 target",
+			raw:  "GET / HTTP/1.1\r\nHost: [::1]:8080\r\n\r\n",
+			burp: BurpItem{Protocol: "http", Host: "::1", Port: "8080"},
+			url:  "http://[::1]:8080",
+			port: "8080",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			item, err := ParseHttpRequest(tt.raw, 1, "request", &tt.burp)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if item.Request.URL.Raw != tt.url || item.Request.URL.Port != tt.port {
+				t.Errorf("URL = %q port %q, want %q port %q", item.Request.URL.Raw, item.Request.URL.Port, tt.url, tt.port)
+			}
+			if item.Description != "" {
+				t.Errorf("description = %q, want no Host warning", item.Description)
+			}
+		})
+	}
+}
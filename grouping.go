@@ -0,0 +1,187 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+/*
+	###################################### FOLDERS AND ITEM GROUPS #####################################################
+*/
+
+// IsFolder reports whether the item is an item group holding other items rather than a request
+func (item PostmanItem) IsFolder() bool {
+	return item.Item != nil
+}
+
+// MarshalJSON writes folders with their items and no request, and requests without an item list
+//
+// Items loaded from an existing collection keep the fields this tool does not model.
+func (item PostmanItem) MarshalJSON() ([]byte, error) {
+	if item.Original == nil {
+		return marshalItem(item)
+	}
+	var loaded PostmanItem
+	if err := json.Unmarshal(item.Original, &loaded); err != nil {
+		return nil, err
+	}
+	return marshalPreserving(item.Original, func() ([]byte, error) { return marshalItem(loaded) }, func() ([]byte, error) { return marshalItem(item) })
+}
+
+// marshalItem writes the modelled fields of an item
+func marshalItem(item PostmanItem) ([]byte, error) {
+	type plainItem PostmanItem
+	aux := struct {
+		plainItem
+		Item    *[]PostmanItem  `json:"item,omitempty"`
+		Request *PostmanRequest `json:"request,omitempty"`
+	}{plainItem: plainItem(item)}
+
+	if item.IsFolder() {
+		aux.Item = &item.Item
+	} else {
+		aux.Request = &item.Request
+	}
+	return json.Marshal(aux)
+}
+
+// WalkItems calls fn for every request in a tree of items, passing the names of the folders containing it
+func WalkItems(items []PostmanItem, fn func(folders []string, item *PostmanItem)) {
+	walkItems(items, nil, fn)
+}
+
+func walkItems(items []PostmanItem, folders []string, fn func(folders []string, item *PostmanItem)) {
+	for i := range items {
+		if items[i].IsFolder() {
+			walkItems(items[i].Item, append(folders[:len(folders):len(folders)], items[i].Name), fn)
+			continue
+		}
+		fn(folders, &items[i])
+	}
+}
+
+// CountRequests returns the number of requests in a tree of items
+func CountRequests(items []PostmanItem) int {
+	count := 0
+	WalkItems(items, func(_ []string, _ *PostmanItem) {
+		count++
+	})
+	return count
+}
+
+// GroupItems nests a flat list of requests into folders using one of the grouping strategies
+//
+// Strategies are "host", "host/path-depth" (host then the first depth path segments), "source-file"
+// (the directory tree below baseDir), "method" and "burp-comment". Folders holding nothing but a single
+// sub-folder are collapsed into it.
+func GroupItems(items []PostmanItem, strategy string, depth int, baseDir string) ([]PostmanItem, error) {
+	var folderPath func(item PostmanItem) []string
+
+	switch strategy {
+	case "", "none":
+		return items, nil
+
+	case "host":
+		folderPath = func(item PostmanItem) []string {
+			return []string{itemHostLabel(item)}
+		}
+
+	case "host/path-depth":
+		if depth < 1 {
+			return nil, fmt.Errorf("path depth must be at least 1, got %d", depth)
+		}
+		folderPath = func(item PostmanItem) []string {
+			path := []string{itemHostLabel(item)}
+			segments := item.Request.URL.Path
+			// The last segment names the request itself, so it never becomes a folder
+			for i := 0; i < depth && i < len(segments)-1; i++ {
+				path = append(path, segments[i])
+			}
+			return path
+		}
+
+	case "source-file":
+		folderPath = func(item PostmanItem) []string {
+			if item.Source == nil || item.Source.File == "" {
+				return nil
+			}
+			rel, err := filepath.Rel(baseDir, item.Source.File)
+			if err != nil || strings.HasPrefix(rel, "..") {
+				rel = filepath.Base(item.Source.File)
+			}
+			rel = strings.TrimSuffix(rel, filepath.Ext(rel))
+			return strings.Split(filepath.ToSlash(rel), "/")
+		}
+
+	case "method":
+		folderPath = func(item PostmanItem) []string {
+			return []string{strings.ToUpper(item.Request.Method)}
+		}
+
+	case "burp-comment":
+		folderPath = func(item PostmanItem) []string {
+			if item.Source == nil || item.Source.Burp == nil || strings.TrimSpace(item.Source.Burp.Comment) == "" {
+				return nil
+			}
+			return []string{strings.TrimSpace(item.Source.Burp.Comment)}
+		}
+
+	default:
+		return nil, fmt.Errorf("unknown grouping %q, expected host, host/path-depth, source-file, method or burp-comment", strategy)
+	}
+
+	var root []PostmanItem
+	for _, item := range items {
+		root = insertIntoFolder(root, folderPath(item), item)
+	}
+
+	return collapseFolders(root), nil
+}
+
+// insertIntoFolder adds an item below the named folders, creating them in first seen order
+func insertIntoFolder(items []PostmanItem, folders []string, item PostmanItem) []PostmanItem {
+	if len(folders) == 0 {
+		return append(items, item)
+	}
+
+	for i := range items {
+		if items[i].IsFolder() && items[i].Name == folders[0] {
+			items[i].Item = insertIntoFolder(items[i].Item, folders[1:], item)
+			return items
+		}
+	}
+
+	folder := PostmanItem{
+		Name: folders[0],
+		Item: insertIntoFolder([]PostmanItem{}, folders[1:], item),
+	}
+	return append(items, folder)
+}
+
+// collapseFolders merges folders that contain exactly one sub-folder and nothing else
+func collapseFolders(items []PostmanItem) []PostmanItem {
+	for i := range items {
+		if !items[i].IsFolder() {
+			continue
+		}
+		items[i].Item = collapseFolders(items[i].Item)
+		for len(items[i].Item) == 1 && items[i].Item[0].IsFolder() && items[i].Description == "" &&
+			items[i].Auth == nil && len(items[i].Variable) == 0 {
+			child := items[i].Item[0]
+			child.Name = items[i].Name + "/" + child.Name
+			items[i] = child
+		}
+	}
+	return items
+}
+
+// itemHostLabel returns the host and non-default port of a request for use as a folder name
+func itemHostLabel(item PostmanItem) string {
+	host := strings.Join(item.Request.URL.Host, ".")
+	if host == "" {
+		return "(no host)"
+	}
+	return JoinHostPort(host, item.Request.URL.Port, item.Request.URL.Protocol)
+}
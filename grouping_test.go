@@ -0,0 +1,104 @@
+package main
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// outline renders a tree of items as folder[children] with request names, for comparing groupings
+func outline(items []PostmanItem) string {
+	var parts []string
+	for _, item := range items {
+		if item.IsFolder() {
+			parts = append(parts, item.Name+"["+outline(item.Item)+"]")
+		} else {
+			parts = append(parts, item.Name)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+func TestGroupItems(t *testing.T) {
+	baseDir := filepath.Join("exports", "burp")
+	item := func(name, method, rawURL, file, comment string) PostmanItem {
+		item := PostmanItem{Name: name, Request: PostmanRequest{Method: method, URL: URLFromString(rawURL)}}
+		if file != "" {
+			item.Source = &ItemSource{File: filepath.Join(baseDir, file), Burp: &BurpItem{Comment: comment}}
+		}
+		return item
+	}
+	recorded := func() []PostmanItem {
+		return []PostmanItem{
+			item("users", "GET", "https://api.example.com/v1/users", "shop/login.xml", "login flow"),
+			item("orders", "post", "https://api.example.com/v1/shop/orders", "shop/login.xml", ""),
+			item("asset", "GET", "https://cdn.example.com:8443/app.js", "shop/cart/items.xml", " assets "),
+			item("curl", "GET", "https://api.example.com/health", "", ""),
+		}
+	}
+
+	tests := []struct {
+		strategy string
+		depth    int
+		want     string
+	}{
+		{"none", 0, "users orders asset curl"},
+		{"host", 0, "api.example.com[users orders curl] cdn.example.com:8443[asset]"},
+		{"host/path-depth", 2, "api.example.com[v1[users shop[orders]] curl] cdn.example.com:8443[asset]"},
+		{"source-file", 0, "shop[login[users orders] cart/items[asset]] curl"},
+		{"method", 0, "GET[users asset curl] POST[orders]"},
+		{"burp-comment", 0, "login flow[users] orders assets[asset] curl"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.strategy, func(t *testing.T) {
+			grouped, err := GroupItems(recorded(), tt.strategy, tt.depth, baseDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := outline(grouped); got != tt.want {
+				t.Errorf("grouping = %s, want %s", got, tt.want)
+			}
+			if count := CountRequests(grouped); count != 4 {
+				t.Errorf("CountRequests = %d, want 4", count)
+			}
+		})
+	}
+
+	if _, err := GroupItems(recorded(), "host/path-depth", 0, baseDir); err == nil {
+		t.Errorf("GroupItems accepted a path depth of 0")
+	}
+	if _, err := GroupItems(recorded(), "size", 0, baseDir); err == nil {
+		t.Errorf("GroupItems accepted an unknown grouping")
+	}
+}
+
+func TestWalkItemsAndMarshal(t *testing.T) {
+	items := []PostmanItem{
+		{Name: "a", Item: []PostmanItem{{Name: "b", Item: []PostmanItem{{Name: "one"}}}, {Name: "two"}}},
+		{Name: "empty", Item: []PostmanItem{}},
+		{Name: "three"},
+	}
+	var walked []string
+	WalkItems(items, func(folders []string, item *PostmanItem) {
+		walked = append(walked, strings.Join(append(folders, item.Name), "/"))
+	})
+	if got := strings.Join(walked, " "); got != "a/b/one a/two three" {
+		t.Errorf("walked = %s, want a/b/one a/two three", got)
+	}
+
+	folder, err := json.Marshal(items[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	request, err := json.Marshal(items[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(folder), `"item":[]`) || strings.Contains(string(folder), `"request"`) {
+		t.Errorf("folder = %s, want an item list and no request", folder)
+	}
+	if !strings.Contains(string(request), `"request":`) || strings.Contains(string(request), `"item"`) {
+		t.Errorf("request = %s, want a request and no item list", request)
+	}
+}
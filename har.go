@@ -0,0 +1,438 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+/*
+	###################################### HAR EXPORT ##################################################################
+*/
+
+// HAR is the top level of an HTTP Archive 1.2 file
+type HAR struct {
+	Log HARLog `json:"log"`
+}
+
+// HARLog holds the pages and entries of an HTTP Archive
+type HARLog struct {
+	Version string     `json:"version"`
+	Creator HARCreator `json:"creator"`
+	Pages   []HARPage  `json:"pages"`
+	Entries []HAREntry `json:"entries"`
+}
+
+// HARCreator names the tool that wrote the archive
+type HARCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// HARPage groups the entries of one collection folder
+type HARPage struct {
+	StartedDateTime string         `json:"startedDateTime"`
+	ID              string         `json:"id"`
+	Title           string         `json:"title"`
+	PageTimings     HARPageTimings `json:"pageTimings"`
+}
+
+// HARPageTimings are page load timings, which are unknown for converted requests
+type HARPageTimings struct {
+	OnContentLoad int `json:"onContentLoad"`
+	OnLoad        int `json:"onLoad"`
+}
+
+// HAREntry is one request and its response
+type HAREntry struct {
+	PageRef         string      `json:"pageref,omitempty"`
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            int         `json:"time"`
+	Request         HARRequest  `json:"request"`
+	Response        HARResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         HARTimings  `json:"timings"`
+	Comment         string      `json:"comment,omitempty"`
+}
+
+// HARRequest is the request of an entry
+type HARRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []HARCookie    `json:"cookies"`
+	Headers     []HARNameValue `json:"headers"`
+	QueryString []HARNameValue `json:"queryString"`
+	PostData    *HARPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+// HARResponse is the response of an entry, left with status 0 when no response was recorded
+type HARResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []HARCookie    `json:"cookies"`
+	Headers     []HARNameValue `json:"headers"`
+	Content     HARContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+// HARNameValue is a header or query string pair
+type HARNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// HARCookie is a request or response cookie
+type HARCookie struct {
+	Name     string `json:"name"`
+	Value    string `json:"value"`
+	Path     string `json:"path,omitempty"`
+	Domain   string `json:"domain,omitempty"`
+	Expires  string `json:"expires,omitempty"`
+	HTTPOnly bool   `json:"httpOnly,omitempty"`
+	Secure   bool   `json:"secure,omitempty"`
+}
+
+// HARPostData is the body of a request
+type HARPostData struct {
+	MimeType string     `json:"mimeType"`
+	Text     string     `json:"text"`
+	Params   []HARParam `json:"params,omitempty"`
+}
+
+// HARParam is a posted form field; file uploads carry the file name instead of the content
+type HARParam struct {
+	Name        string `json:"name"`
+	Value       string `json:"value,omitempty"`
+	FileName    string `json:"fileName,omitempty"`
+	ContentType string `json:"contentType,omitempty"`
+}
+
+// HARContent is the body of a response
+type HARContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+}
+
+// HARTimings are the phases of an entry; converted requests only know when they started
+type HARTimings struct {
+	Send    int `json:"send"`
+	Wait    int `json:"wait"`
+	Receive int `json:"receive"`
+}
+
+// harBoundary separates the parts of multipart bodies whose Content-Type carries no boundary
+const harBoundary = "go2postmanBoundary"
+
+// variableRegex matches a {{variable}} reference
+var variableRegex = regexp.MustCompile(`\{\{([^{}]+)\}\}`)
+
+// ExportHAR builds an HTTP Archive from a collection, with one entry per saved example and a page per folder
+//
+// Collection variables with a value are resolved so every entry has a concrete URL. Entries are stamped
+// with the Burp recording time where known, otherwise the Burp export time or the collection update time.
+func ExportHAR(collection PostmanCollection) HAR {
+	har := HAR{Log: HARLog{
+		Version: "1.2",
+		Creator: HARCreator{Name: "go2postman", Version: "1.0"},
+		Pages:   []HARPage{},
+		Entries: []HAREntry{},
+	}}
+
+	variables := map[string]string{}
+	for _, variable := range collection.Variable {
+		if variable.Value != "" {
+			variables[variable.Key] = variable.Value
+		}
+	}
+
+	pages := map[string]string{}
+	WalkItems(collection.Item, func(folders []string, item *PostmanItem) {
+		started := collection.Info.Updated
+		if recorded, ok := itemTime(*item); ok {
+			started = recorded
+		}
+
+		pageRef := ""
+		if len(folders) > 0 {
+			title := ResolveVariables(strings.Join(folders, "/"), variables)
+			if pageRef = pages[title]; pageRef == "" {
+				pageRef = fmt.Sprintf("page_%d", len(pages)+1)
+				pages[title] = pageRef
+				har.Log.Pages = append(har.Log.Pages, HARPage{
+					StartedDateTime: started.Format(time.RFC3339Nano),
+					ID:              pageRef,
+					Title:           title,
+				})
+			}
+		}
+
+		httpVersion := itemHTTPVersion(*item)
+		entry := HAREntry{
+			PageRef:         pageRef,
+			StartedDateTime: started.Format(time.RFC3339Nano),
+			Comment:         item.Name,
+		}
+		if len(item.Response) == 0 {
+			entry.Request = harRequest(item.Request, httpVersion, variables)
+			entry.Response = HARResponse{HTTPVersion: httpVersion, Cookies: []HARCookie{}, Headers: []HARNameValue{}, HeadersSize: -1, BodySize: -1}
+			har.Log.Entries = append(har.Log.Entries, entry)
+			return
+		}
+
+		for _, response := range item.Response {
+			request := item.Request
+			if response.OriginalRequest != nil {
+				request = *response.OriginalRequest
+			}
+			entry.Request = harRequest(request, httpVersion, variables)
+			entry.Response = harResponse(response, httpVersion)
+			har.Log.Entries = append(har.Log.Entries, entry)
+		}
+	})
+
+	return har
+}
+
+// WriteHAR writes a collection to an HTTP Archive file and returns the number of entries
+func WriteHAR(fileName string, collection PostmanCollection) (int, error) {
+	har := ExportHAR(collection)
+	output, err := json.MarshalIndent(har, "", "  ")
+	if err != nil {
+		return 0, fmt.Errorf("error marshaling HAR: %v", err)
+	}
+	if err := os.WriteFile(fileName, output, 0644); err != nil {
+		return 0, fmt.Errorf("error writing HAR file: %v", err)
+	}
+	return len(har.Log.Entries), nil
+}
+
+// harRequest converts a Postman request, resolving variables into concrete values
+func harRequest(req PostmanRequest, httpVersion string, variables map[string]string) HARRequest {
+	resolve := func(s string) string {
+		return ResolveVariables(s, variables)
+	}
+
+	result := HARRequest{
+		Method:      strings.ToUpper(req.Method),
+		URL:         resolve(ResolvePathVariables(req.URL)),
+		HTTPVersion: httpVersion,
+		Cookies:     []HARCookie{},
+		Headers:     []HARNameValue{},
+		QueryString: []HARNameValue{},
+		HeadersSize: -1,
+		BodySize:    0,
+	}
+
+	contentType := ""
+	for _, header := range req.Header {
+		if header.Disabled {
+			continue
+		}
+		value := resolve(header.Value)
+		result.Headers = append(result.Headers, HARNameValue{Name: header.Key, Value: value})
+		switch strings.ToLower(header.Key) {
+		case "cookie":
+			result.Cookies = append(result.Cookies, parseRequestCookies(value)...)
+		case "content-type":
+			contentType = value
+		}
+	}
+	for _, param := range req.URL.Query {
+		result.QueryString = append(result.QueryString, HARNameValue{Name: resolve(param.Key), Value: resolve(param.Value)})
+	}
+
+	switch {
+	case len(req.Body.Formdata) > 0:
+		result.PostData = harMultipart(req.Body.Formdata, contentType, resolve)
+		result.BodySize = len(result.PostData.Text)
+	case len(req.Body.Urlencoded) > 0:
+		if contentType == "" {
+			contentType = "application/x-www-form-urlencoded"
+		}
+		result.PostData = &HARPostData{MimeType: contentType}
+		var pairs []string
+		for _, field := range req.Body.Urlencoded {
+			if field.Disabled {
+				continue
+			}
+			name, value := resolve(field.Key), resolve(field.Value)
+			result.PostData.Params = append(result.PostData.Params, HARParam{Name: name, Value: value})
+			pairs = append(pairs, url.QueryEscape(name)+"="+url.QueryEscape(value))
+		}
+		result.PostData.Text = strings.Join(pairs, "&")
+		result.BodySize = len(result.PostData.Text)
+	default:
+		if body := resolve(req.Body.Raw); body != "" {
+			result.PostData = &HARPostData{MimeType: contentType, Text: body}
+			if strings.Contains(strings.ToLower(contentType), "application/x-www-form-urlencoded") {
+				for _, pair := range strings.Split(body, "&") {
+					name, value, _ := strings.Cut(pair, "=")
+					result.PostData.Params = append(result.PostData.Params, HARParam{Name: name, Value: value})
+				}
+			}
+			result.BodySize = len(body)
+		}
+	}
+	return result
+}
+
+// harMultipart lists the fields of a multipart body and rebuilds its text; uploads are referenced by file name
+// since the file content is not part of the collection
+func harMultipart(fields []PostmanFormParam, contentType string, resolve func(string) string) *HARPostData {
+	boundary := harBoundary
+	if _, params, err := mime.ParseMediaType(contentType); err == nil && params["boundary"] != "" {
+		boundary = params["boundary"]
+	} else {
+		contentType = "multipart/form-data; boundary=" + boundary
+	}
+
+	postData := &HARPostData{MimeType: contentType}
+	var text strings.Builder
+	for _, field := range fields {
+		if field.Disabled {
+			continue
+		}
+		name := resolve(field.Key)
+		fmt.Fprintf(&text, "--%s\r\n", boundary)
+		if field.Type == "file" {
+			fileName := filepath.Base(field.Src)
+			postData.Params = append(postData.Params, HARParam{Name: name, FileName: fileName, ContentType: "application/octet-stream"})
+			fmt.Fprintf(&text, "Content-Disposition: form-data; name=\"%s\"; filename=\"%s\"\r\nContent-Type: application/octet-stream\r\n\r\n\r\n", name, fileName)
+			continue
+		}
+		value := resolve(field.Value)
+		postData.Params = append(postData.Params, HARParam{Name: name, Value: value})
+		fmt.Fprintf(&text, "Content-Disposition: form-data; name=\"%s\"\r\n\r\n%s\r\n", name, value)
+	}
+	fmt.Fprintf(&text, "--%s--\r\n", boundary)
+	postData.Text = text.String()
+	return postData
+}
+
+// harResponse converts a saved example
+func harResponse(response PostmanResponse, httpVersion string) HARResponse {
+	result := HARResponse{
+		Status:      response.Code,
+		StatusText:  response.Status,
+		HTTPVersion: httpVersion,
+		Cookies:     []HARCookie{},
+		Headers:     []HARNameValue{},
+		Content:     HARContent{Size: len(response.Body), Text: response.Body},
+		HeadersSize: -1,
+		BodySize:    len(response.Body),
+	}
+	for _, header := range response.Header {
+		result.Headers = append(result.Headers, HARNameValue{Name: header.Key, Value: header.Value})
+		switch strings.ToLower(header.Key) {
+		case "set-cookie":
+			result.Cookies = append(result.Cookies, parseSetCookie(header.Value))
+		case "content-type":
+			result.Content.MimeType = header.Value
+		case "location":
+			result.RedirectURL = header.Value
+		}
+	}
+	return result
+}
+
+// parseRequestCookies splits a Cookie header into its cookies
+func parseRequestCookies(value string) []HARCookie {
+	var cookies []HARCookie
+	for _, pair := range strings.Split(value, ";") {
+		name, cookieValue, found := strings.Cut(strings.TrimSpace(pair), "=")
+		if found {
+			cookies = append(cookies, HARCookie{Name: name, Value: cookieValue})
+		}
+	}
+	return cookies
+}
+
+// parseSetCookie parses a Set-Cookie header and its attributes
+func parseSetCookie(value string) HARCookie {
+	parts := strings.Split(value, ";")
+	name, cookieValue, _ := strings.Cut(strings.TrimSpace(parts[0]), "=")
+	cookie := HARCookie{Name: name, Value: cookieValue}
+	for _, attribute := range parts[1:] {
+		key, attributeValue, _ := strings.Cut(strings.TrimSpace(attribute), "=")
+		switch strings.ToLower(key) {
+		case "path":
+			cookie.Path = attributeValue
+		case "domain":
+			cookie.Domain = attributeValue
+		case "expires":
+			if expires, err := time.Parse(time.RFC1123, attributeValue); err == nil {
+				cookie.Expires = expires.Format(time.RFC3339)
+			}
+		case "httponly":
+			cookie.HTTPOnly = true
+		case "secure":
+			cookie.Secure = true
+		}
+	}
+	return cookie
+}
+
+// itemHTTPVersion returns the protocol version from the recorded Burp request line, or HTTP/1.1
+func itemHTTPVersion(item PostmanItem) string {
+	if item.Source == nil || item.Source.Burp == nil {
+		return "HTTP/1.1"
+	}
+	data := item.Source.Burp.Request.Content
+	if item.Source.Burp.Request.Base64 == "true" {
+		decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(data))
+		if err != nil {
+			return "HTTP/1.1"
+		}
+		data = string(decoded)
+	}
+	requestLine, _, _ := strings.Cut(data, "\n")
+	fields := strings.Fields(requestLine)
+	if len(fields) == 3 && strings.HasPrefix(fields[2], "HTTP/") {
+		return fields[2]
+	}
+	return "HTTP/1.1"
+}
+
+// ResolveVariables replaces {{variable}} references that have a value, leaving unknown ones in place
+func ResolveVariables(s string, variables map[string]string) string {
+	return variableRegex.ReplaceAllStringFunc(s, func(reference string) string {
+		if value, ok := variables[strings.TrimSpace(reference[2:len(reference)-2])]; ok {
+			return value
+		}
+		return reference
+	})
+}
+
+// ResolvePathVariables returns the raw URL with :name path segments replaced by their values
+func ResolvePathVariables(u PostmanURL) string {
+	if len(u.Variable) == 0 && u.Raw != "" {
+		return u.Raw
+	}
+	resolved := u
+	resolved.Path = append([]string{}, u.Path...)
+	for p, segment := range resolved.Path {
+		if !strings.HasPrefix(segment, ":") {
+			continue
+		}
+		for _, variable := range u.Variable {
+			if variable.Key == segment[1:] {
+				resolved.Path[p] = variable.Value
+			}
+		}
+	}
+	return BuildRawURL(resolved)
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"encoding/base64"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestExportHAR(t *testing.T) {
+	updated := time.Date(2026, 10, 19, 10, 0, 0, 0, time.UTC)
+	login := PostmanRequest{
+		Method: "post",
+		URL:    URLFromString("{{baseUrl}}/users/:id/login?next={{next}}"),
+		Header: []PostmanHeader{{Key: "Cookie", Value: "sid={{sid}}; theme=dark"}, {Key: "X-Off", Value: "1", Disabled: true}},
+		Body:   PostmanBody{Mode: "urlencoded", Urlencoded: []PostmanFormParam{{Key: "user", Value: "bob smith"}, {Key: "skip", Value: "1", Disabled: true}}},
+	}
+	login.URL.Variable = []PostmanVariable{{Key: "id", Value: "7"}}
+	collection := PostmanCollection{
+		Variable: []PostmanVariable{{Key: "baseUrl", Value: "https://api.example.com"}, {Key: "sid", Value: "abc"}, {Key: "next", Value: ""}},
+		Item: []PostmanItem{
+			{Name: "auth", Item: []PostmanItem{{
+				Name:    "login",
+				Request: login,
+				Source: &ItemSource{
+					Time: updated.Add(-time.Hour),
+					Burp: &BurpItem{Request: BurpRequestData{Base64: "true", Content: base64.StdEncoding.EncodeToString([]byte("POST /login HTTP/2\r\n\r\n"))}},
+				},
+				Response: []PostmanResponse{{
+					Code:   302,
+					Status: "Found",
+					Header: []PostmanHeader{{Key: "Location", Value: "/home"}, {Key: "Set-Cookie", Value: "sid=new; Path=/; HttpOnly; Secure; Expires=Wed, 21 Oct 2026 07:28:00 GMT"}},
+				}, {
+					Code: 401,
+				}},
+			}}},
+			{Name: "upload", Request: PostmanRequest{
+				Method: "PUT",
+				URL:    URLFromString("https://files.example.com/upload"),
+				Body:   PostmanBody{Mode: "formdata", Formdata: []PostmanFormParam{{Key: "note", Value: "hi"}, {Key: "file", Type: "file", Src: "/tmp/a.png"}}},
+			}},
+		},
+	}
+
+	collection.Info.Updated = updated
+
+	har := ExportHAR(collection)
+	if len(har.Log.Pages) != 1 || har.Log.Pages[0].Title != "auth" || har.Log.Pages[0].StartedDateTime != "2026-10-19T09:00:00Z" {
+		t.Errorf("pages = %+v, want one auth page at the recording time", har.Log.Pages)
+	}
+	if len(har.Log.Entries) != 3 {
+		t.Fatalf("entries = %d, want one per saved example plus the upload", len(har.Log.Entries))
+	}
+
+	first := har.Log.Entries[0]
+	if first.PageRef != "page_1" || first.Request.HTTPVersion != "HTTP/2" || first.Request.Method != "POST" {
+		t.Errorf("entry = %s %s %s, want POST HTTP/2 on page_1", first.PageRef, first.Request.Method, first.Request.HTTPVersion)
+	}
+	if first.Request.URL != "https://api.example.com/users/7/login?next={{next}}" {
+		t.Errorf("URL = %s, want path variables and variables with a value resolved", first.Request.URL)
+	}
+	if want := []HARCookie{{Name: "sid", Value: "abc"}, {Name: "theme", Value: "dark"}}; !reflect.DeepEqual(first.Request.Cookies, want) {
+		t.Errorf("cookies = %+v, want %+v", first.Request.Cookies, want)
+	}
+	if len(first.Request.Headers) != 1 || first.Request.PostData.Text != "user=bob+smith" || first.Request.BodySize != 14 {
+		t.Errorf("request = %+v, want one header and the enabled form fields", first.Request)
+	}
+	if want := (HARCookie{Name: "sid", Value: "new", Path: "/", Expires: "2026-10-21T07:28:00Z", HTTPOnly: true, Secure: true}); !reflect.DeepEqual(first.Response.Cookies, []HARCookie{want}) {
+		t.Errorf("response cookies = %+v, want %+v", first.Response.Cookies, want)
+	}
+	if first.Response.RedirectURL != "/home" || har.Log.Entries[1].Response.Status != 401 {
+		t.Errorf("responses = %+v, %+v", first.Response, har.Log.Entries[1].Response)
+	}
+
+	upload := har.Log.Entries[2]
+	wantText := "--go2postmanBoundary\r\nContent-Disposition: form-data; name=\"note\"\r\n\r\nhi\r\n" +
+		"--go2postmanBoundary\r\nContent-Disposition: form-data; name=\"file\"; filename=\"a.png\"\r\nContent-Type: application/octet-stream\r\n\r\n\r\n" +
+		"--go2postmanBoundary--\r\n"
+	if upload.PageRef != "" || upload.StartedDateTime != "2026-10-19T10:00:00Z" || upload.Request.HTTPVersion != "HTTP/1.1" {
+		t.Errorf("upload entry = %+v, want no page, the collection time and HTTP/1.1", upload)
+	}
+	if upload.Request.PostData.MimeType != "multipart/form-data; boundary=go2postmanBoundary" || upload.Request.PostData.Text != wantText {
+		t.Errorf("upload body = %q %q", upload.Request.PostData.MimeType, upload.Request.PostData.Text)
+	}
+	if upload.Response.BodySize != -1 {
+		t.Errorf("unanswered response = %+v, want an empty response", upload.Response)
+	}
+}
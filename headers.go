@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+/*
+	###################################### HEADER PROFILES #############################################################
+*/
+
+// noisyHeaders are headers added by browsers and proxies that add clutter or break replay
+var noisyHeaders = []string{
+	"host", "connection", "keep-alive", "proxy-connection", "content-length", "transfer-encoding", "te", "upgrade",
+	"accept-encoding", "accept-language", "cache-control", "pragma", "priority", "dnt", "upgrade-insecure-requests",
+	"if-none-match", "if-modified-since", "if-match", "if-unmodified-since", "if-range", "sec-fetch-*", "sec-ch-ua*",
+	"sec-gpc", "sec-purpose", "purpose",
+}
+
+// minimalHeaders are the headers kept by the minimal profile, together with the API key headers
+var minimalHeaders = []string{
+	"authorization", "cookie", "content-type", "accept", "origin", "x-requested-with", "x-csrf-token", "x-xsrf-token",
+}
+
+// HeaderFilter decides which request headers stay enabled
+type HeaderFilter struct {
+	Profile string
+	Include []string
+	Exclude []string
+}
+
+// NewHeaderFilter validates a profile and comma separated include and exclude name patterns such as "x-*,accept"
+func NewHeaderFilter(profile, include, exclude string) (*HeaderFilter, error) {
+	switch profile {
+	case "", "verbatim", "browser-clean", "minimal":
+	default:
+		return nil, fmt.Errorf("unknown header profile %q, expected minimal, browser-clean or verbatim", profile)
+	}
+
+	filter := &HeaderFilter{Profile: profile, Include: splitPatterns(include), Exclude: splitPatterns(exclude)}
+	for _, pattern := range append(append([]string{}, filter.Include...), filter.Exclude...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid header pattern %q: %v", pattern, err)
+		}
+	}
+	return filter, nil
+}
+
+// Enabled reports whether a header should stay enabled; exclude patterns win over include patterns, which win over the profile
+func (f *HeaderFilter) Enabled(name string) bool {
+	name = strings.ToLower(strings.TrimSpace(name))
+	switch {
+	case matchesAny(name, f.Exclude):
+		return false
+	case matchesAny(name, f.Include):
+		return true
+	}
+
+	switch f.Profile {
+	case "browser-clean":
+		return !matchesAny(name, noisyHeaders)
+	case "minimal":
+		return matchesAny(name, minimalHeaders) || apiKeyHeaders[name]
+	}
+	return true
+}
+
+// FilterHeaders marks filtered headers as disabled on every request and saved example, returning how many request headers were disabled
+//
+// Disabled headers stay in the collection so they can be switched back on in Postman.
+func FilterHeaders(items []PostmanItem, filter *HeaderFilter) int {
+	disabled := 0
+	for i := range items {
+		disabled += filter.disableHeaders(&items[i].Request)
+		for j := range items[i].Response {
+			if items[i].Response[j].OriginalRequest != nil {
+				filter.disableHeaders(items[i].Response[j].OriginalRequest)
+			}
+		}
+	}
+	return disabled
+}
+
+// disableHeaders marks the filtered headers of one request as disabled
+func (f *HeaderFilter) disableHeaders(req *PostmanRequest) int {
+	disabled := 0
+	for i := range req.Header {
+		if !req.Header[i].Disabled && !f.Enabled(req.Header[i].Key) {
+			req.Header[i].Disabled = true
+			disabled++
+		}
+	}
+	return disabled
+}
+
+// splitPatterns splits a comma separated list of patterns into lower case entries
+func splitPatterns(list string) []string {
+	var patterns []string
+	for _, pattern := range strings.Split(list, ",") {
+		if pattern = strings.ToLower(strings.TrimSpace(pattern)); pattern != "" {
+			patterns = append(patterns, pattern)
+		}
+	}
+	return patterns
+}
+
+// matchesAny reports whether a lower case name matches one of the glob patterns
+func matchesAny(name string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHeaderFilter(t *testing.T) {
+	headers := []string{"Host", "Accept-Encoding", "Sec-Fetch-Mode", "sec-ch-ua-platform", "Authorization", "Content-Type", "X-Api-Key", "X-Trace-Id", "User-Agent"}
+	tests := []struct {
+		profile, include, exclude string
+		want                      string
+	}{
+		{"verbatim", "", "", "Host Accept-Encoding Sec-Fetch-Mode sec-ch-ua-platform Authorization Content-Type X-Api-Key X-Trace-Id User-Agent"},
+		{"browser-clean", "", "", "Authorization Content-Type X-Api-Key X-Trace-Id User-Agent"},
+		{"minimal", "", "", "Authorization Content-Type X-Api-Key"},
+		{"minimal", " X-Trace-*, user-agent", "", "Authorization Content-Type X-Api-Key X-Trace-Id User-Agent"},
+		{"browser-clean", "x-*", "x-api-key,x-trace-id", "Authorization Content-Type User-Agent"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.profile+" "+tt.include+" "+tt.exclude, func(t *testing.T) {
+			filter, err := NewHeaderFilter(tt.profile, tt.include, tt.exclude)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var enabled []string
+			for _, header := range headers {
+				if filter.Enabled(header) {
+					enabled = append(enabled, header)
+				}
+			}
+			if got := strings.Join(enabled, " "); got != tt.want {
+				t.Errorf("enabled = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := NewHeaderFilter("strict", "", ""); err == nil {
+		t.Errorf("NewHeaderFilter accepted an unknown profile")
+	}
+	if _, err := NewHeaderFilter("", "x-[", ""); err == nil {
+		t.Errorf("NewHeaderFilter accepted an invalid pattern")
+	}
+}
+
+func TestFilterHeaders(t *testing.T) {
+	request := func() PostmanRequest {
+		return PostmanRequest{Header: []PostmanHeader{{Key: "Host", Value: "a"}, {Key: "Accept", Value: "*/*"}, {Key: "DNT", Value: "1", Disabled: true}}}
+	}
+	original := request()
+	items := []PostmanItem{{Request: request(), Response: []PostmanResponse{{OriginalRequest: &original}, {}}}}
+
+	filter, _ := NewHeaderFilter("browser-clean", "", "")
+	if disabled := FilterHeaders(items, filter); disabled != 1 {
+		t.Errorf("FilterHeaders disabled %d headers, want 1", disabled)
+	}
+	for _, req := range []PostmanRequest{items[0].Request, original} {
+		if len(req.Header) != 3 || !req.Header[0].Disabled || req.Header[1].Disabled || !req.Header[2].Disabled {
+			t.Errorf("headers = %+v, want Host disabled and kept", req.Header)
+		}
+	}
+}
@@ -0,0 +1,264 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+/*
+	###################################### HTTP REQUEST FILE EXPORT ####################################################
+*/
+
+// httpFileBoundary separates the parts of multipart bodies written to .http files
+const httpFileBoundary = "WebAppBoundary"
+
+// binaryContentTypes are Content-Type prefixes whose bodies are written to a file and referenced with < ./file
+var binaryContentTypes = []string{"application/octet-stream", "application/pdf", "application/zip", "application/gzip", "image/", "audio/", "video/", "font/"}
+
+// WriteHTTPFiles writes a collection as .http request files for the JetBrains HTTP Client and VS Code REST Client
+//
+// Each folder becomes one file named after its path, with requests at the top level in a file named after
+// the collection. Environments are written to http-client.env.json, with secrets in http-client.private.env.json
+// so they can be kept out of version control, and collection variables that no environment sets become @name
+// definitions at the top of every file. It returns the files written and the number of requests.
+func WriteHTTPFiles(dir string, collection PostmanCollection, environments []PostmanEnvironment) ([]string, int, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, 0, fmt.Errorf("error creating .http directory: %v", err)
+	}
+
+	public, private := map[string]map[string]string{}, map[string]map[string]string{}
+	secrets := map[string]string{}
+	provided := map[string]bool{}
+	for _, environment := range environments {
+		for _, value := range environment.Values {
+			if !value.Enabled {
+				continue
+			}
+			provided[value.Key] = true
+			if value.Type == "secret" {
+				secrets[value.Key] = value.Value
+				if private[environment.Name] == nil {
+					private[environment.Name] = map[string]string{}
+				}
+				private[environment.Name][value.Key] = value.Value
+				continue
+			}
+			if public[environment.Name] == nil {
+				public[environment.Name] = map[string]string{}
+			}
+			public[environment.Name][value.Key] = value.Value
+		}
+	}
+
+	// Private values only apply to the public environment of the same name, so secrets are added to each
+	if len(public) > 0 && len(secrets) > 0 {
+		private = map[string]map[string]string{}
+		for name := range public {
+			private[name] = secrets
+		}
+	}
+
+	var files []string
+	for _, envFile := range []struct {
+		name   string
+		values map[string]map[string]string
+	}{{"http-client.env.json", public}, {"http-client.private.env.json", private}} {
+		if len(envFile.values) == 0 {
+			continue
+		}
+		output, err := json.MarshalIndent(envFile.values, "", "  ")
+		if err != nil {
+			return files, 0, fmt.Errorf("error marshaling %s: %v", envFile.name, err)
+		}
+		fileName := filepath.Join(dir, envFile.name)
+		if err := os.WriteFile(fileName, append(output, '\n'), 0644); err != nil {
+			return files, 0, fmt.Errorf("error writing %s: %v", envFile.name, err)
+		}
+		files = append(files, fileName)
+	}
+
+	var definitions strings.Builder
+	for _, variable := range collection.Variable {
+		if !provided[variable.Key] {
+			fmt.Fprintf(&definitions, "@%s = %s\n", variable.Key, variable.Value)
+		}
+	}
+	if definitions.Len() > 0 {
+		definitions.WriteString("\n")
+	}
+
+	// Requests are written to the file of their folder, in the order the folders are first seen
+	var order []string
+	requests := map[string][]string{}
+	names := map[string]map[string]bool{}
+	used := map[string]bool{}
+	fileNames := map[string]string{}
+	bodies := 0
+	var bodyErr error
+	WalkItems(collection.Item, func(folders []string, item *PostmanItem) {
+		key := strings.Join(folders, "/")
+		if _, ok := requests[key]; !ok {
+			order = append(order, key)
+			label := collection.Info.Name
+			if len(folders) > 0 {
+				label = strings.Join(folders, " - ")
+			}
+			fileNames[key] = uniqueFileName(SafeFileName(label), used)
+			names[key] = map[string]bool{}
+			requests[key] = nil
+		}
+
+		// Binary bodies are saved next to the .http file and referenced
+		bodyFile := ""
+		if isBinaryBody(item.Request) {
+			bodies++
+			bodyFile = fmt.Sprintf("%s.body-%d.bin", strings.ReplaceAll(fileNames[key], " ", "_"), bodies)
+			if err := os.WriteFile(filepath.Join(dir, bodyFile), []byte(item.Request.Body.Raw), 0644); err != nil && bodyErr == nil {
+				bodyErr = fmt.Errorf("error writing request body for %s: %v", item.Name, err)
+			}
+		}
+		requests[key] = append(requests[key], httpFileRequest(*item, requestIdentifier(item.Name, names[key]), bodyFile))
+	})
+	if bodyErr != nil {
+		return files, 0, bodyErr
+	}
+
+	count := 0
+	for _, key := range order {
+		fileName := filepath.Join(dir, fileNames[key]+".http")
+		content := definitions.String() + strings.Join(requests[key], "\n")
+		if err := os.WriteFile(fileName, []byte(content), 0644); err != nil {
+			return files, count, fmt.Errorf("error writing %s: %v", fileName, err)
+		}
+		files = append(files, fileName)
+		count += len(requests[key])
+	}
+	sort.Strings(files)
+	return files, count, nil
+}
+
+// httpFileRequest renders one request, starting with its ### separator and name
+func httpFileRequest(item PostmanItem, name, bodyFile string) string {
+	req := item.Request
+	var out strings.Builder
+	fmt.Fprintf(&out, "### %s\n", item.Name)
+	for _, line := range strings.Split(strings.TrimSpace(item.Description), "\n") {
+		if line != "" {
+			fmt.Fprintf(&out, "# %s\n", line)
+		}
+	}
+	fmt.Fprintf(&out, "# @name %s\n", name)
+
+	method := strings.ToUpper(req.Method)
+	if method == "" {
+		method = "GET"
+	}
+	fmt.Fprintf(&out, "%s %s\n", method, ResolvePathVariables(req.URL))
+
+	hasAuthorization, hasContentType := false, false
+	for _, header := range req.Header {
+		if header.Disabled {
+			// Disabled headers are kept as comments so they can be switched back on
+			fmt.Fprintf(&out, "# %s: %s\n", header.Key, header.Value)
+			continue
+		}
+		switch {
+		case strings.EqualFold(header.Key, "Authorization"):
+			hasAuthorization = true
+		case strings.EqualFold(header.Key, "Content-Type"):
+			hasContentType = true
+			if len(req.Body.Formdata) > 0 {
+				// The boundary has to match the parts written below
+				fmt.Fprintf(&out, "%s: multipart/form-data; boundary=%s\n", header.Key, httpFileBoundary)
+				continue
+			}
+		}
+		fmt.Fprintf(&out, "%s: %s\n", header.Key, header.Value)
+	}
+	if authorization := authorizationValue(req.Auth); authorization != "" && !hasAuthorization {
+		fmt.Fprintf(&out, "Authorization: %s\n", authorization)
+	}
+
+	switch {
+	case len(req.Body.Formdata) > 0:
+		if !hasContentType {
+			fmt.Fprintf(&out, "Content-Type: multipart/form-data; boundary=%s\n", httpFileBoundary)
+		}
+		out.WriteString("\n")
+		for _, field := range req.Body.Formdata {
+			if field.Disabled {
+				continue
+			}
+			fmt.Fprintf(&out, "--%s\n", httpFileBoundary)
+			if field.Type == "file" {
+				fmt.Fprintf(&out, "Content-Disposition: form-data; name=\"%s\"; filename=\"%s\"\n\n< %s\n", field.Key, filepath.Base(field.Src), httpFilePath(field.Src))
+			} else {
+				fmt.Fprintf(&out, "Content-Disposition: form-data; name=\"%s\"\n\n%s\n", field.Key, field.Value)
+			}
+		}
+		fmt.Fprintf(&out, "--%s--\n", httpFileBoundary)
+	case len(req.Body.Urlencoded) > 0:
+		if !hasContentType {
+			out.WriteString("Content-Type: application/x-www-form-urlencoded\n")
+		}
+		var pairs []string
+		for _, field := range req.Body.Urlencoded {
+			if !field.Disabled {
+				pairs = append(pairs, url.QueryEscape(field.Key)+"="+url.QueryEscape(field.Value))
+			}
+		}
+		fmt.Fprintf(&out, "\n%s\n", strings.Join(pairs, "&"))
+	case bodyFile != "":
+		fmt.Fprintf(&out, "\n< ./%s\n", bodyFile)
+	case req.Body.Raw != "":
+		fmt.Fprintf(&out, "\n%s\n", strings.TrimRight(req.Body.Raw, "\r\n"))
+	}
+	return out.String()
+}
+
+// requestIdentifier returns a unique camelCase identifier for a request name such as "GET users", e.g. getUsers
+func requestIdentifier(name string, used map[string]bool) string {
+	method, rest, _ := strings.Cut(strings.TrimSpace(name), " ")
+	return uniqueVariableName(strings.ToLower(method)+" "+rest, used)
+}
+
+// isBinaryBody reports whether a raw body cannot be written inline, by its Content-Type or content
+func isBinaryBody(req PostmanRequest) bool {
+	body := req.Body.Raw
+	if body == "" {
+		return false
+	}
+	for _, header := range req.Header {
+		if header.Disabled || !strings.EqualFold(header.Key, "Content-Type") {
+			continue
+		}
+		for _, prefix := range binaryContentTypes {
+			if strings.HasPrefix(strings.ToLower(strings.TrimSpace(header.Value)), prefix) {
+				return true
+			}
+		}
+	}
+	if !utf8.ValidString(body) {
+		return true
+	}
+	for _, r := range body {
+		if r < 0x20 && r != '\t' && r != '\r' && r != '\n' {
+			return true
+		}
+	}
+	return false
+}
+
+// httpFilePath returns a file reference relative to the .http file unless it is absolute
+func httpFilePath(src string) string {
+	if filepath.IsAbs(src) || strings.HasPrefix(src, ".") {
+		return filepath.ToSlash(src)
+	}
+	return "./" + filepath.ToSlash(src)
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestHTTPFileRequest(t *testing.T) {
+	tests := []struct {
+		name     string
+		item     PostmanItem
+		bodyFile string
+		want     string
+	}{
+		{
+			name: "headers, disabled headers and auth",
+			item: PostmanItem{Name: "GET me", Description: "Current user\n", Request: PostmanRequest{
+				Method: "get",
+				URL:    URLFromString("{{baseUrl}}/me"),
+				Header: []PostmanHeader{{Key: "Accept", Value: "application/json"}, {Key: "X-Debug", Value: "1", Disabled: true}},
+				Auth:   &PostmanAuth{Type: "bearer", Bearer: []PostmanAuthDetail{{Key: "token", Value: "{{token}}", Type: "string"}}},
+			}},
+			want: "### GET me\n# Current user\n# @name getMe\nGET {{baseUrl}}/me\nAccept: application/json\n# X-Debug: 1\nAuthorization: Bearer {{token}}\n",
+		},
+		{
+			name: "multipart uses the fixed boundary",
+			item: PostmanItem{Name: "POST upload", Request: PostmanRequest{
+				Method: "POST",
+				URL:    URLFromString("https://api.example.com/upload"),
+				Header: []PostmanHeader{{Key: "Content-Type", Value: "multipart/form-data; boundary=----recorded"}},
+				Body: PostmanBody{Mode: "formdata", Formdata: []PostmanFormParam{
+					{Key: "title", Value: "cat"},
+					{Key: "photo", Type: "file", Src: "images/cat.png"},
+					{Key: "skipped", Value: "1", Disabled: true},
+				}},
+			}},
+			want: "### POST upload\n# @name postUpload\nPOST https://api.example.com/upload\n" +
+				"Content-Type: multipart/form-data; boundary=WebAppBoundary\n\n" +
+				"--WebAppBoundary\nContent-Disposition: form-data; name=\"title\"\n\ncat\n" +
+				"--WebAppBoundary\nContent-Disposition: form-data; name=\"photo\"; filename=\"cat.png\"\n\n< ./images/cat.png\n" +
+				"--WebAppBoundary--\n",
+		},
+		{
+			name: "urlencoded body",
+			item: PostmanItem{Name: "POST login", Request: PostmanRequest{
+				Method: "POST",
+				URL:    URLFromString("https://api.example.com/login"),
+				Body:   PostmanBody{Mode: "urlencoded", Urlencoded: []PostmanFormParam{{Key: "user", Value: "a b"}, {Key: "pass", Value: "x&y"}}},
+			}},
+			want: "### POST login\n# @name postLogin\nPOST https://api.example.com/login\nContent-Type: application/x-www-form-urlencoded\n\nuser=a+b&pass=x%26y\n",
+		},
+		{
+			name:     "binary body referenced from a file",
+			item:     PostmanItem{Name: "PUT blob", Request: PostmanRequest{Method: "PUT", URL: URLFromString("https://api.example.com/blob"), Body: PostmanBody{Mode: "raw", Raw: "\x00\x01"}}},
+			bodyFile: "api.body-1.bin",
+			want:     "### PUT blob\n# @name putBlob\nPUT https://api.example.com/blob\n\n< ./api.body-1.bin\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name := requestIdentifier(tt.item.Name, map[string]bool{})
+			if got := httpFileRequest(tt.item, name, tt.bodyFile); got != tt.want {
+				t.Errorf("request =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsBinaryBody(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		want        bool
+	}{
+		{"empty", "application/octet-stream", "", false},
+		{"JSON text", "application/json", `{"a":1}`, false},
+		{"binary content type", "image/png", "PNG", true},
+		{"invalid UTF-8", "", "\xff\xfe", true},
+		{"control characters", "text/plain", "a\x00b", true},
+		{"line breaks and tabs", "text/plain", "a\tb\r\nc", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := PostmanRequest{Body: PostmanBody{Mode: "raw", Raw: tt.body}}
+			if tt.contentType != "" {
+				req.Header = []PostmanHeader{{Key: "Content-Type", Value: tt.contentType}}
+			}
+			if got := isBinaryBody(req); got != tt.want {
+				t.Errorf("isBinaryBody = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteHTTPFiles(t *testing.T) {
+	dir := t.TempDir()
+	collection := PostmanCollection{
+		Variable: []PostmanVariable{{Key: "baseUrl", Value: "https://api.example.com"}, {Key: "page", Value: "1"}},
+		Item: []PostmanItem{
+			{Name: "GET health", Request: PostmanRequest{Method: "GET", URL: URLFromString("{{baseUrl}}/health")}},
+			{Name: "users", Item: []PostmanItem{
+				{Name: "GET users", Request: PostmanRequest{Method: "GET", URL: URLFromString("{{baseUrl}}/users?page={{page}}")}},
+				{Name: "GET users", Request: PostmanRequest{Method: "GET", URL: URLFromString("{{baseUrl}}/users?page=2")}},
+				{Name: "PUT avatar", Request: PostmanRequest{
+					Method: "PUT",
+					URL:    URLFromString("{{baseUrl}}/users/avatar"),
+					Header: []PostmanHeader{{Key: "Content-Type", Value: "image/png"}},
+					Body:   PostmanBody{Mode: "raw", Raw: "\x89PNG"},
+				}},
+			}},
+		},
+	}
+	collection.Info.Name = "My API"
+	environments := []PostmanEnvironment{
+		{Name: "dev", Values: []PostmanEnvironmentValue{
+			{Key: "baseUrl", Value: "http://localhost:8080", Type: "default", Enabled: true},
+			{Key: "token", Value: "secret-dev", Type: "secret", Enabled: true},
+			{Key: "unused", Value: "x", Type: "default", Enabled: false},
+		}},
+		{Name: "prod", Values: []PostmanEnvironmentValue{{Key: "baseUrl", Value: "https://api.example.com", Type: "default", Enabled: true}}},
+	}
+
+	files, count, err := WriteHTTPFiles(dir, collection, environments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 4 {
+		t.Errorf("count = %d, want 4", count)
+	}
+	var names []string
+	for _, file := range files {
+		names = append(names, filepath.Base(file))
+	}
+	wantNames := []string{"My API.http", "http-client.env.json", "http-client.private.env.json", "users.http"}
+	if !reflect.DeepEqual(names, wantNames) {
+		t.Errorf("files = %q, want %q", names, wantNames)
+	}
+
+	read := func(name string) string {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+
+	// Secrets are kept apart and apply to every public environment
+	if got, want := read("http-client.private.env.json"), "{\n  \"dev\": {\n    \"token\": \"secret-dev\"\n  },\n  \"prod\": {\n    \"token\": \"secret-dev\"\n  }\n}\n"; got != want {
+		t.Errorf("private environment =\n%s\nwant\n%s", got, want)
+	}
+
+	// Only collection variables that no environment sets are defined in the files
+	users := read("users.http")
+	if !strings.HasPrefix(users, "@page = 1\n\n### GET users\n") {
+		t.Errorf("users.http does not start with the page definition:\n%s", users)
+	}
+	for _, want := range []string{"# @name getUsers\n", "# @name getUsers2\n", "\n< ./users.body-1.bin\n"} {
+		if !strings.Contains(users, want) {
+			t.Errorf("users.http does not contain %q:\n%s", want, users)
+		}
+	}
+	if body := read("users.body-1.bin"); body != "\x89PNG" {
+		t.Errorf("body file = %q, want the raw body", body)
+	}
+}
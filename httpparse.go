@@ -0,0 +1,314 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+/*
+	##################################### RAW HTTP MESSAGE PARSER ######################################################
+*/
+
+// HTTPHeader represents a single header field exactly as it appeared in a raw HTTP message
+type HTTPHeader struct {
+	Name  string
+	Value string
+
+	// offset is the position of the header line within the raw message
+	offset int
+}
+
+// RawHTTPRequest represents an HTTP request decoded byte for byte from a Burp item
+type RawHTTPRequest struct {
+	Method  string
+	Target  string
+	Proto   string
+	Pseudo  []HTTPHeader
+	Headers []HTTPHeader
+	Body    []byte
+}
+
+// HTTPParseError reports the byte offset at which a raw HTTP message could not be parsed
+type HTTPParseError struct {
+	Offset int
+	Msg    string
+}
+
+func (e *HTTPParseError) Error() string {
+	return fmt.Sprintf("%s at byte offset %d", e.Msg, e.Offset)
+}
+
+// Get returns the value of the first header with the given name, ignoring case
+func (r *RawHTTPRequest) Get(name string) string {
+	return headerValue(r.Headers, name)
+}
+
+// PseudoHeader returns the value of an HTTP/2 pseudo-header such as :authority
+func (r *RawHTTPRequest) PseudoHeader(name string) string {
+	return headerValue(r.Pseudo, name)
+}
+
+// IsHTTP2 reports whether the request was rendered by Burp as an HTTP/2 request
+func (r *RawHTTPRequest) IsHTTP2() bool {
+	return strings.HasPrefix(r.Proto, "HTTP/2") || len(r.Pseudo) > 0
+}
+
+// ParseRawHTTPRequest parses a raw HTTP/1.x or Burp rendered HTTP/2 request
+//
+// Header order and duplicate headers are preserved and obs-fold continuation lines are joined to the
+// previous header. The body is framed by Transfer-Encoding or, when honourLength is set, Content-Length;
+// otherwise everything after the header block is the body.
+func ParseRawHTTPRequest(data []byte, honourLength bool) (*RawHTTPRequest, error) {
+	req := &RawHTTPRequest{}
+
+	startLine, pseudo, headers, bodyOffset, err := parseHTTPHead(data)
+	if err != nil {
+		return nil, err
+	}
+	req.Pseudo = pseudo
+	req.Headers = headers
+
+	if startLine != "" {
+		if err := parseRequestLine(startLine, skipLeadingNewlines(data), req); err != nil {
+			return nil, err
+		}
+	} else {
+		// HTTP/2 requests shown with pseudo-headers only carry no request line
+		req.Method = req.PseudoHeader(":method")
+		req.Target = req.PseudoHeader(":path")
+		req.Proto = "HTTP/2"
+		if req.Method == "" {
+			return nil, &HTTPParseError{Offset: skipLeadingNewlines(data), Msg: "missing request line and :method pseudo-header"}
+		}
+		if req.Target == "" {
+			req.Target = "/"
+		}
+	}
+
+	body, err := frameHTTPBody(data, bodyOffset, headers, honourLength)
+	if err != nil {
+		return nil, err
+	}
+	req.Body = body
+
+	return req, nil
+}
+
+// parseRequestLine splits a request line into method, request target and protocol version
+func parseRequestLine(line string, offset int, req *RawHTTPRequest) error {
+	methodEnd := strings.IndexAny(line, " \t")
+	if methodEnd <= 0 {
+		return &HTTPParseError{Offset: offset, Msg: fmt.Sprintf("invalid request line %q", line)}
+	}
+	for i := 0; i < methodEnd; i++ {
+		if !isTokenChar(line[i]) {
+			return &HTTPParseError{Offset: offset + i, Msg: fmt.Sprintf("invalid character %q in method", line[i])}
+		}
+	}
+	req.Method = line[:methodEnd]
+
+	rest := strings.TrimLeft(line[methodEnd:], " \t")
+	if rest == "" {
+		return &HTTPParseError{Offset: offset + len(line), Msg: "missing request target"}
+	}
+
+	// The version is the last token; anything between it and the method is the target
+	if versionStart := strings.LastIndexAny(rest, " \t"); versionStart > 0 && strings.HasPrefix(rest[versionStart+1:], "HTTP/") {
+		req.Proto = rest[versionStart+1:]
+		rest = strings.TrimRight(rest[:versionStart], " \t")
+	}
+	req.Target = rest
+
+	return nil
+}
+
+// parseHTTPHead reads the start line and header block of a raw HTTP message
+func parseHTTPHead(data []byte) (startLine string, pseudo, headers []HTTPHeader, bodyOffset int, err error) {
+	pos := skipLeadingNewlines(data)
+	if pos >= len(data) {
+		return "", nil, nil, 0, &HTTPParseError{Offset: pos, Msg: "empty message"}
+	}
+
+	first := true
+	for pos < len(data) {
+		line, next := readHTTPLine(data, pos)
+
+		if first {
+			first = false
+			if !strings.HasPrefix(line, ":") {
+				startLine = line
+				pos = next
+				continue
+			}
+		}
+
+		// A blank line ends the header block
+		if line == "" {
+			return startLine, pseudo, headers, next, nil
+		}
+
+		// obs-fold continuation lines are joined to the previous header with a single space
+		if line[0] == ' ' || line[0] == '\t' {
+			if len(headers) == 0 {
+				return "", nil, nil, 0, &HTTPParseError{Offset: pos, Msg: "continuation line without a preceding header"}
+			}
+			last := &headers[len(headers)-1]
+			last.Value = strings.TrimRight(last.Value+" "+strings.Trim(line, " \t"), " \t")
+			pos = next
+			continue
+		}
+
+		isPseudo := line[0] == ':'
+		colon := strings.IndexByte(line[1:], ':') + 1
+		if colon <= 0 {
+			return "", nil, nil, 0, &HTTPParseError{Offset: pos, Msg: fmt.Sprintf("header line without a colon %q", line)}
+		}
+		name := line[:colon]
+		for i := 0; i < len(name); i++ {
+			if !isTokenChar(name[i]) && !(isPseudo && i == 0) {
+				return "", nil, nil, 0, &HTTPParseError{Offset: pos + i, Msg: fmt.Sprintf("invalid character %q in header name", name[i])}
+			}
+		}
+
+		header := HTTPHeader{Name: name, Value: strings.Trim(line[colon+1:], " \t"), offset: pos}
+		if isPseudo {
+			pseudo = append(pseudo, header)
+		} else {
+			headers = append(headers, header)
+		}
+		pos = next
+	}
+
+	// Burp allows a request without a trailing blank line when there is no body
+	return startLine, pseudo, headers, len(data), nil
+}
+
+// frameHTTPBody returns the message body framed by Transfer-Encoding or Content-Length
+func frameHTTPBody(data []byte, offset int, headers []HTTPHeader, honourLength bool) ([]byte, error) {
+	if offset >= len(data) {
+		return nil, nil
+	}
+
+	// Transfer-Encoding takes precedence over Content-Length
+	for _, header := range headers {
+		if strings.EqualFold(header.Name, "Transfer-Encoding") {
+			codings := strings.Split(strings.ToLower(header.Value), ",")
+			if strings.TrimSpace(codings[len(codings)-1]) == "chunked" {
+				return decodeChunked(data, offset)
+			}
+		}
+	}
+
+	if !honourLength {
+		return data[offset:], nil
+	}
+
+	length := -1
+	for _, header := range headers {
+		if !strings.EqualFold(header.Name, "Content-Length") {
+			continue
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(header.Value))
+		if err != nil || n < 0 {
+			return nil, &HTTPParseError{Offset: header.offset, Msg: fmt.Sprintf("invalid Content-Length %q", header.Value)}
+		}
+		if length >= 0 && n != length {
+			return nil, &HTTPParseError{Offset: header.offset, Msg: "conflicting Content-Length headers"}
+		}
+		length = n
+	}
+
+	// Keep what is present when Burp saved fewer bytes than declared
+	if length < 0 || offset+length > len(data) {
+		return data[offset:], nil
+	}
+	return data[offset : offset+length], nil
+}
+
+// decodeChunked decodes a chunked transfer coded body starting at offset
+func decodeChunked(data []byte, offset int) ([]byte, error) {
+	var body []byte
+	pos := offset
+	for {
+		if pos >= len(data) {
+			return nil, &HTTPParseError{Offset: pos, Msg: "chunked body ended without a last chunk"}
+		}
+		line, next := readHTTPLine(data, pos)
+
+		// Chunk extensions follow a semicolon and are ignored
+		sizeStr := line
+		if semi := strings.IndexByte(sizeStr, ';'); semi >= 0 {
+			sizeStr = sizeStr[:semi]
+		}
+		size, err := strconv.ParseInt(strings.TrimSpace(sizeStr), 16, 64)
+		if err != nil || size < 0 {
+			return nil, &HTTPParseError{Offset: pos, Msg: fmt.Sprintf("invalid chunk size %q", line)}
+		}
+
+		if size == 0 {
+			// Skip any trailer fields up to the final blank line
+			pos = next
+			for pos < len(data) {
+				trailer, after := readHTTPLine(data, pos)
+				pos = after
+				if trailer == "" {
+					break
+				}
+			}
+			return body, nil
+		}
+
+		if int64(len(data)-next) < size {
+			return nil, &HTTPParseError{Offset: next, Msg: fmt.Sprintf("chunk of %d bytes exceeds remaining data", size)}
+		}
+		body = append(body, data[next:next+int(size)]...)
+		pos = next + int(size)
+
+		// Each chunk is terminated by CRLF
+		if bytes.HasPrefix(data[pos:], []byte("\r\n")) {
+			pos += 2
+		} else if bytes.HasPrefix(data[pos:], []byte("\n")) {
+			pos++
+		} else if pos < len(data) {
+			return nil, &HTTPParseError{Offset: pos, Msg: "missing CRLF after chunk data"}
+		}
+	}
+}
+
+// readHTTPLine returns the line starting at pos without its CRLF or LF terminator and the offset of the next line
+func readHTTPLine(data []byte, pos int) (string, int) {
+	end := bytes.IndexByte(data[pos:], '\n')
+	if end < 0 {
+		return strings.TrimSuffix(string(data[pos:]), "\r"), len(data)
+	}
+	return strings.TrimSuffix(string(data[pos:pos+end]), "\r"), pos + end + 1
+}
+
+// skipLeadingNewlines returns the offset of the first byte after any empty lines preceding the start line
+func skipLeadingNewlines(data []byte) int {
+	pos := 0
+	for pos < len(data) && (data[pos] == '\r' || data[pos] == '\n') {
+		pos++
+	}
+	return pos
+}
+
+// isTokenChar reports whether c is a valid RFC 9110 token character
+func isTokenChar(c byte) bool {
+	if c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' {
+		return true
+	}
+	return strings.IndexByte("!#$%&'*+-.^_`|~", c) >= 0
+}
+
+// headerValue returns the value of the first header with the given name, ignoring case
+func headerValue(headers []HTTPHeader, name string) string {
+	for _, header := range headers {
+		if strings.EqualFold(header.Name, name) {
+			return header.Value
+		}
+	}
+	return ""
+}
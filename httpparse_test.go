@@ -0,0 +1,199 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseRawHTTPRequest(t *testing.T) {
+	tests := []struct {
+		name         string
+		raw          string
+		honourLength bool
+		method       string
+		target       string
+		proto        string
+		pseudo       [][2]string
+		headers      [][2]string
+		body         string
+	}{
+		{
+			name:    "CRLF line endings",
+			raw:     "GET /users?page=2 HTTP/1.1\r\nHost: api.example.com\r\nAccept: */*\r\n\r\n",
+			method:  "GET",
+			target:  "/users?page=2",
+			proto:   "HTTP/1.1",
+			headers: [][2]string{{"Host", "api.example.com"}, {"Accept", "*/*"}},
+		},
+		{
+			name:    "bare LF line endings",
+			raw:     "GET / HTTP/1.1\nHost: api.example.com\n\n",
+			method:  "GET",
+			target:  "/",
+			proto:   "HTTP/1.1",
+			headers: [][2]string{{"Host", "api.example.com"}},
+		},
+		{
+			name:    "leading blank lines",
+			raw:     "\r\n\r\nGET / HTTP/1.1\r\nHost: a\r\n\r\n",
+			method:  "GET",
+			target:  "/",
+			proto:   "HTTP/1.1",
+			headers: [][2]string{{"Host", "a"}},
+		},
+		{
+			name:    "no trailing blank line",
+			raw:     "GET / HTTP/1.1\r\nHost: a",
+			method:  "GET",
+			target:  "/",
+			proto:   "HTTP/1.1",
+			headers: [][2]string{{"Host", "a"}},
+		},
+		{
+			name:    "target containing spaces",
+			raw:     "GET /search?q=a b HTTP/1.1\r\nHost: a\r\n\r\n",
+			method:  "GET",
+			target:  "/search?q=a b",
+			proto:   "HTTP/1.1",
+			headers: [][2]string{{"Host", "a"}},
+		},
+		{
+			name:    "obs-fold continuation lines",
+			raw:     "GET / HTTP/1.1\r\nHost: a\r\nX-Long: first\r\n  second\r\n\tthird\r\n\r\n",
+			method:  "GET",
+			target:  "/",
+			proto:   "HTTP/1.1",
+			headers: [][2]string{{"Host", "a"}, {"X-Long", "first second third"}},
+		},
+		{
+			name:    "duplicate headers keep their order",
+			raw:     "GET / HTTP/1.1\r\nHost: a\r\nCookie: a=1\r\nAccept: */*\r\nCookie: b=2\r\n\r\n",
+			method:  "GET",
+			target:  "/",
+			proto:   "HTTP/1.1",
+			headers: [][2]string{{"Host", "a"}, {"Cookie", "a=1"}, {"Accept", "*/*"}, {"Cookie", "b=2"}},
+		},
+		{
+			name:         "Content-Length frames the body",
+			raw:          "POST / HTTP/1.1\r\nHost: a\r\nContent-Length: 5\r\n\r\nhello trailing",
+			honourLength: true,
+			method:       "POST",
+			target:       "/",
+			proto:        "HTTP/1.1",
+			headers:      [][2]string{{"Host", "a"}, {"Content-Length", "5"}},
+			body:         "hello",
+		},
+		{
+			name:         "Content-Length longer than the saved body",
+			raw:          "POST / HTTP/1.1\r\nHost: a\r\nContent-Length: 50\r\n\r\nhello",
+			honourLength: true,
+			method:       "POST",
+			target:       "/",
+			proto:        "HTTP/1.1",
+			headers:      [][2]string{{"Host", "a"}, {"Content-Length", "50"}},
+			body:         "hello",
+		},
+		{
+			name:    "Content-Length ignored for Intruder templates",
+			raw:     "POST / HTTP/1.1\r\nHost: a\r\nContent-Length: 2\r\n\r\n{{param_1}}",
+			method:  "POST",
+			target:  "/",
+			proto:   "HTTP/1.1",
+			headers: [][2]string{{"Host", "a"}, {"Content-Length", "2"}},
+			body:    "{{param_1}}",
+		},
+		{
+			name:         "chunked body with extensions and trailers",
+			raw:          "POST / HTTP/1.1\r\nHost: a\r\nTransfer-Encoding: chunked\r\nContent-Length: 3\r\n\r\n5;ext=1\r\nhello\r\n6\r\n world\r\n0\r\nX-Trailer: 1\r\n\r\n",
+			honourLength: true,
+			method:       "POST",
+			target:       "/",
+			proto:        "HTTP/1.1",
+			headers:      [][2]string{{"Host", "a"}, {"Transfer-Encoding", "chunked"}, {"Content-Length", "3"}},
+			body:         "hello world",
+		},
+		{
+			name:    "HTTP/2 request line with pseudo-headers",
+			raw:     "GET /me HTTP/2\r\n:authority: api.example.com\r\n:scheme: https\r\nAccept: */*\r\n\r\n",
+			method:  "GET",
+			target:  "/me",
+			proto:   "HTTP/2",
+			pseudo:  [][2]string{{":authority", "api.example.com"}, {":scheme", "https"}},
+			headers: [][2]string{{"Accept", "*/*"}},
+		},
+		{
+			name:    "HTTP/2 pseudo-headers only",
+			raw:     ":method: POST\r\n:path: /login\r\n:authority: api.example.com\r\nContent-Type: text/plain\r\n\r\nhi",
+			method:  "POST",
+			target:  "/login",
+			proto:   "HTTP/2",
+			pseudo:  [][2]string{{":method", "POST"}, {":path", "/login"}, {":authority", "api.example.com"}},
+			headers: [][2]string{{"Content-Type", "text/plain"}},
+			body:    "hi",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := ParseRawHTTPRequest([]byte(tt.raw), tt.honourLength)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if req.Method != tt.method || req.Target != tt.target || req.Proto != tt.proto {
+				t.Errorf("request line = %q %q %q, want %q %q %q", req.Method, req.Target, req.Proto, tt.method, tt.target, tt.proto)
+			}
+			if got := headerPairs(req.Pseudo); !reflect.DeepEqual(got, tt.pseudo) {
+				t.Errorf("pseudo-headers = %q, want %q", got, tt.pseudo)
+			}
+			if got := headerPairs(req.Headers); !reflect.DeepEqual(got, tt.headers) {
+				t.Errorf("headers = %q, want %q", got, tt.headers)
+			}
+			if string(req.Body) != tt.body {
+				t.Errorf("body = %q, want %q", req.Body, tt.body)
+			}
+		})
+	}
+}
+
+func TestParseRawHTTPRequestErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		raw    string
+		offset int
+	}{
+		{"empty message", "\r\n\r\n", 4},
+		{"header line without a colon", "GET / HTTP/1.1\r\nHost a\r\n\r\n", 16},
+		{"invalid character in header name", "GET / HTTP/1.1\r\nX Bad: 1\r\n\r\n", 17},
+		{"continuation line without a header", ":method: GET\r\n folded\r\n\r\n", 14},
+		{"invalid character in method", "G(T / HTTP/1.1\r\n\r\n", 1},
+		{"missing request target", "GET\r\nHost: a\r\n\r\n", 0},
+		{"missing :method pseudo-header", ":path: /\r\n\r\n", 0},
+		{"invalid Content-Length", "POST / HTTP/1.1\r\nContent-Length: abc\r\n\r\nx", 17},
+		{"conflicting Content-Length", "POST / HTTP/1.1\r\nContent-Length: 1\r\nContent-Length: 2\r\n\r\nxy", 36},
+		{"invalid chunk size", "POST / HTTP/1.1\r\nTransfer-Encoding: chunked\r\n\r\nzz\r\n", 47},
+		{"chunk longer than the data", "POST / HTTP/1.1\r\nTransfer-Encoding: chunked\r\n\r\n10\r\nabc", 51},
+		{"chunked body without a last chunk", "POST / HTTP/1.1\r\nTransfer-Encoding: chunked\r\n\r\n3\r\nabc\r\n", 55},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseRawHTTPRequest([]byte(tt.raw), true)
+			var parseErr *HTTPParseError
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("error = %v, want an HTTPParseError", err)
+			}
+			if parseErr.Offset != tt.offset {
+				t.Errorf("offset = %d, want %d (%v)", parseErr.Offset, tt.offset, err)
+			}
+		})
+	}
+}
+
+func headerPairs(headers []HTTPHeader) [][2]string {
+	var pairs [][2]string
+	for _, header := range headers {
+		pairs = append(pairs, [2]string{header.Name, header.Value})
+	}
+	return pairs
+}
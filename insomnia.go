@@ -0,0 +1,223 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+/*
+	###################################### INSOMNIA EXPORT #############################################################
+*/
+
+// InsomniaExport is an Insomnia v4 export file
+type InsomniaExport struct {
+	Type      string             `json:"_type"`
+	Format    int                `json:"__export_format"`
+	Date      string             `json:"__export_date"`
+	Source    string             `json:"__export_source"`
+	Resources []InsomniaResource `json:"resources"`
+}
+
+// InsomniaResource is a workspace, environment, request group or request; fields not used by a type are left out
+type InsomniaResource struct {
+	ID             string            `json:"_id"`
+	Type           string            `json:"_type"`
+	ParentID       *string           `json:"parentId"`
+	Name           string            `json:"name"`
+	Description    string            `json:"description,omitempty"`
+	Scope          string            `json:"scope,omitempty"`
+	Data           map[string]string `json:"data,omitempty"`
+	Method         string            `json:"method,omitempty"`
+	URL            string            `json:"url,omitempty"`
+	Body           *InsomniaBody     `json:"body,omitempty"`
+	Parameters     []InsomniaParam   `json:"parameters,omitempty"`
+	Headers        []InsomniaParam   `json:"headers,omitempty"`
+	Authentication *InsomniaAuth     `json:"authentication,omitempty"`
+	MetaSortKey    int               `json:"metaSortKey,omitempty"`
+}
+
+// InsomniaBody is the body of a request, as text or form parameters
+type InsomniaBody struct {
+	MimeType string          `json:"mimeType,omitempty"`
+	Text     string          `json:"text,omitempty"`
+	Params   []InsomniaParam `json:"params,omitempty"`
+}
+
+// InsomniaParam is a query parameter, header or form field
+type InsomniaParam struct {
+	Name     string `json:"name"`
+	Value    string `json:"value"`
+	Type     string `json:"type,omitempty"`
+	FileName string `json:"fileName,omitempty"`
+	Disabled bool   `json:"disabled,omitempty"`
+}
+
+// InsomniaAuth is the authentication of a request
+type InsomniaAuth struct {
+	Type     string `json:"type"`
+	Token    string `json:"token,omitempty"`
+	Prefix   string `json:"prefix,omitempty"`
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+}
+
+// ExportInsomnia builds an Insomnia v4 export with a workspace, request groups for folders and environments
+//
+// Collection variables become the base environment and each environment a sub environment of it.
+// {{variables}} are rewritten to Insomnia's {{ _.name }} template syntax.
+func ExportInsomnia(collection PostmanCollection, environments []PostmanEnvironment) InsomniaExport {
+	export := InsomniaExport{
+		Type:   "export",
+		Format: 4,
+		Date:   time.Now().UTC().Format(time.RFC3339),
+		Source: "go2postman",
+	}
+
+	workspaceID := insomniaID("wrk")
+	export.Resources = append(export.Resources, InsomniaResource{
+		ID:          workspaceID,
+		Type:        "workspace",
+		Name:        collection.Info.Name,
+		Description: collection.Info.Description,
+		Scope:       "collection",
+	})
+
+	baseID := insomniaID("env")
+	base := InsomniaResource{ID: baseID, Type: "environment", ParentID: &workspaceID, Name: "Base Environment", Data: map[string]string{}}
+	for _, variable := range collection.Variable {
+		base.Data[variable.Key] = variable.Value
+	}
+	export.Resources = append(export.Resources, base)
+	for e, environment := range environments {
+		sub := InsomniaResource{ID: insomniaID("env"), Type: "environment", ParentID: &baseID, Name: environment.Name, Data: map[string]string{}, MetaSortKey: (e + 1) * 100}
+		for _, value := range environment.Values {
+			if value.Enabled {
+				sub.Data[value.Key] = value.Value
+			}
+		}
+		export.Resources = append(export.Resources, sub)
+	}
+
+	export.Resources = append(export.Resources, insomniaResources(collection.Item, workspaceID)...)
+	return export
+}
+
+// WriteInsomnia writes a collection as an Insomnia v4 export file and returns the number of requests
+func WriteInsomnia(fileName string, collection PostmanCollection, environments []PostmanEnvironment) (int, error) {
+	export := ExportInsomnia(collection, environments)
+	output, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		return 0, fmt.Errorf("error marshaling Insomnia export: %v", err)
+	}
+	if err := os.WriteFile(fileName, output, 0644); err != nil {
+		return 0, fmt.Errorf("error writing Insomnia export: %v", err)
+	}
+	return CountRequests(collection.Item), nil
+}
+
+// insomniaResources converts one level of the collection, sorted in collection order
+func insomniaResources(items []PostmanItem, parentID string) []InsomniaResource {
+	var resources []InsomniaResource
+	for i, item := range items {
+		parent := parentID
+		if item.IsFolder() {
+			folder := InsomniaResource{ID: insomniaID("fld"), Type: "request_group", ParentID: &parent, Name: item.Name, Description: item.Description, MetaSortKey: (i + 1) * 100}
+			resources = append(resources, folder)
+			resources = append(resources, insomniaResources(item.Item, folder.ID)...)
+			continue
+		}
+		request := insomniaRequest(item.Request)
+		request.ID = insomniaID("req")
+		request.ParentID = &parent
+		request.Name = item.Name
+		request.Description = item.Description
+		request.MetaSortKey = (i + 1) * 100
+		resources = append(resources, request)
+	}
+	return resources
+}
+
+// insomniaRequest converts the request details of an item
+func insomniaRequest(req PostmanRequest) InsomniaResource {
+	rawURL, _, _ := strings.Cut(ResolvePathVariables(req.URL), "?")
+	request := InsomniaResource{
+		Type:       "request",
+		Method:     strings.ToUpper(req.Method),
+		URL:        insomniaTemplate(rawURL),
+		Body:       &InsomniaBody{},
+		Parameters: []InsomniaParam{},
+		Headers:    []InsomniaParam{},
+	}
+	for _, param := range req.URL.Query {
+		request.Parameters = append(request.Parameters, InsomniaParam{Name: insomniaTemplate(param.Key), Value: insomniaTemplate(param.Value)})
+	}
+
+	// Auth blocks replace any recorded Authorization header
+	if req.Auth != nil {
+		switch req.Auth.Type {
+		case "bearer":
+			request.Authentication = &InsomniaAuth{Type: "bearer", Token: insomniaTemplate(authValue(req.Auth, "token"))}
+		case "basic":
+			if username, password, ok := basicCredentials(req.Auth); ok {
+				request.Authentication = &InsomniaAuth{Type: "basic", Username: insomniaTemplate(username), Password: insomniaTemplate(password)}
+			}
+		}
+	}
+	contentType := ""
+	for _, header := range req.Header {
+		if request.Authentication != nil && strings.EqualFold(header.Key, "Authorization") {
+			continue
+		}
+		if len(req.Body.Formdata) > 0 && strings.EqualFold(header.Key, "Content-Type") {
+			// Insomnia sets the multipart boundary itself
+			continue
+		}
+		if strings.EqualFold(header.Key, "Content-Type") && !header.Disabled {
+			contentType = header.Value
+		}
+		request.Headers = append(request.Headers, InsomniaParam{Name: header.Key, Value: insomniaTemplate(header.Value), Disabled: header.Disabled})
+	}
+
+	switch {
+	case len(req.Body.Formdata) > 0:
+		request.Body.MimeType = "multipart/form-data"
+		for _, field := range req.Body.Formdata {
+			param := InsomniaParam{Name: insomniaTemplate(field.Key), Value: insomniaTemplate(field.Value), Disabled: field.Disabled}
+			if field.Type == "file" {
+				param.Type, param.Value, param.FileName = "file", "", field.Src
+			}
+			request.Body.Params = append(request.Body.Params, param)
+		}
+	case len(req.Body.Urlencoded) > 0:
+		request.Body.MimeType = "application/x-www-form-urlencoded"
+		for _, field := range req.Body.Urlencoded {
+			request.Body.Params = append(request.Body.Params, InsomniaParam{Name: insomniaTemplate(field.Key), Value: insomniaTemplate(field.Value), Disabled: field.Disabled})
+		}
+	case req.Body.Raw != "":
+		mimeType, _, _ := strings.Cut(contentType, ";")
+		request.Body.MimeType = strings.TrimSpace(mimeType)
+		request.Body.Text = insomniaTemplate(req.Body.Raw)
+	}
+	return request
+}
+
+// insomniaTemplate rewrites {{name}} references to Insomnia's {{ _.name }} environment syntax
+func insomniaTemplate(s string) string {
+	return variableRegex.ReplaceAllStringFunc(s, func(reference string) string {
+		name := strings.TrimSpace(reference[2 : len(reference)-2])
+		if nonVariableCharRegex.MatchString(name) {
+			return fmt.Sprintf("{{ _[%q] }}", name)
+		}
+		return "{{ _." + name + " }}"
+	})
+}
+
+// insomniaID returns a new resource ID with Insomnia's type prefix
+func insomniaID(prefix string) string {
+	return prefix + "_" + strings.ReplaceAll(uuid.New().String(), "-", "")
+}
@@ -0,0 +1,238 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+/*
+	################################### BURP INTRUDER PAYLOAD MARKERS ##################################################
+*/
+
+// IntruderMarker is the character Burp Intruder places either side of a payload position
+const IntruderMarker = "§"
+
+// intruderParamRegex matches the {{param_N}} variables that payload positions are converted into
+var intruderParamRegex = regexp.MustCompile(`\{\{param_(\d+)\}\}`)
+
+// ReplaceIntruderMarkers replaces each §value§ pair in s with a {{param_N}} variable and records the original values
+func ReplaceIntruderMarkers(s string, values *[]string) string {
+	s = normaliseIntruderMarkers(s)
+	if strings.Count(s, IntruderMarker) < 2 {
+		return s
+	}
+
+	var out strings.Builder
+	for {
+		start := strings.Index(s, IntruderMarker)
+		if start < 0 {
+			break
+		}
+		end := strings.Index(s[start+len(IntruderMarker):], IntruderMarker)
+		if end < 0 {
+			break
+		}
+		end += start + len(IntruderMarker)
+
+		*values = append(*values, s[start+len(IntruderMarker):end])
+		out.WriteString(s[:start])
+		out.WriteString(fmt.Sprintf("{{param_%d}}", len(*values)))
+		s = s[end+len(IntruderMarker):]
+	}
+	out.WriteString(s)
+
+	return out.String()
+}
+
+// normaliseIntruderMarkers converts single byte ISO-8859-1 section signs into their UTF-8 form
+func normaliseIntruderMarkers(s string) string {
+	if utf8.ValidString(s) || !strings.Contains(s, "\xa7") {
+		return s
+	}
+	return strings.ReplaceAll(s, "\xa7", IntruderMarker)
+}
+
+// NumberIntruderParams renumbers payload variables so they are unique across the items and returns their original values in order
+func NumberIntruderParams(items []PostmanItem) []string {
+	var originals []string
+	for i := range items {
+		if len(items[i].IntruderValues) == 0 {
+			continue
+		}
+
+		offset := len(originals)
+		if offset > 0 {
+			renumber := func(s string) string {
+				return intruderParamRegex.ReplaceAllStringFunc(s, func(match string) string {
+					n, _ := strconv.Atoi(intruderParamRegex.FindStringSubmatch(match)[1])
+					return fmt.Sprintf("{{param_%d}}", n+offset)
+				})
+			}
+			// Saved examples hold a copy of the request, which has to use the same numbers
+			RewriteRequestStrings(&items[i].Request, renumber)
+			for r := range items[i].Response {
+				if items[i].Response[r].OriginalRequest != nil {
+					RewriteRequestStrings(items[i].Response[r].OriginalRequest, renumber)
+				}
+			}
+		}
+		originals = append(originals, items[i].IntruderValues...)
+	}
+
+	return originals
+}
+
+// RedactIntruderValues redacts the original values of payload positions as the redactor would in the requests they
+// were marked in, so the runner data file holds {{variables}} rather than the tokens and passwords themselves
+func RedactIntruderValues(r *Redactor, items []PostmanItem, values []string) []string {
+	resolve := func(s string) string {
+		return intruderParamRegex.ReplaceAllStringFunc(s, func(match string) string {
+			n, _ := strconv.Atoi(intruderParamRegex.FindStringSubmatch(match)[1])
+			if n < 1 || n > len(values) {
+				return match
+			}
+			return values[n-1]
+		})
+	}
+	WalkItems(items, func(_ []string, item *PostmanItem) {
+		if len(item.IntruderValues) == 0 {
+			return
+		}
+		req := CloneRequest(item.Request)
+		RewriteRequestStrings(&req, resolve)
+		r.redactRequest(&req)
+	})
+
+	// Values holding a secret found above have it replaced, longest first so tokens containing others win
+	var secrets []string
+	for value := range r.names {
+		secrets = append(secrets, value)
+	}
+	sort.Slice(secrets, func(i, j int) bool {
+		return len(secrets[i]) > len(secrets[j])
+	})
+	redacted := make([]string, len(values))
+	for i, value := range values {
+		for _, secret := range secrets {
+			value = replaceBounded(value, secret, "{{"+r.names[secret]+"}}")
+		}
+		redacted[i] = r.redactPatterns(value)
+	}
+	return redacted
+}
+
+// LoadPayloadLists reads a comma separated list of payload files, one payload per line
+func LoadPayloadLists(fileList string) ([][]string, error) {
+	var lists [][]string
+	for _, path := range strings.Split(fileList, ",") {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("error reading payload list %s: %v", path, err)
+		}
+
+		var payloads []string
+		for _, line := range strings.Split(string(data), "\n") {
+			line = strings.TrimRight(line, "\r")
+			if line != "" {
+				payloads = append(payloads, line)
+			}
+		}
+		lists = append(lists, payloads)
+	}
+
+	return lists, nil
+}
+
+// BuildIntruderRows builds runner data rows with the original values first and one further row per payload line
+//
+// A single payload list is applied to every position, otherwise list N fills param_N; positions without a
+// payload for a given row keep their original value.
+func BuildIntruderRows(originals []string, lists [][]string) [][]string {
+	rows := [][]string{originals}
+
+	rowCount := 0
+	for _, list := range lists {
+		if len(list) > rowCount {
+			rowCount = len(list)
+		}
+	}
+
+	for r := 0; r < rowCount; r++ {
+		row := make([]string, len(originals))
+		for p := range originals {
+			row[p] = originals[p]
+
+			list := []string(nil)
+			if len(lists) == 1 {
+				list = lists[0]
+			} else if p < len(lists) {
+				list = lists[p]
+			}
+			if r < len(list) {
+				row[p] = list[r]
+			}
+		}
+		rows = append(rows, row)
+	}
+
+	return rows
+}
+
+// WriteIntruderData writes runner data rows as CSV or JSON depending on the file extension
+func WriteIntruderData(filePath string, rows [][]string) error {
+	if len(rows) == 0 {
+		return nil
+	}
+
+	columns := make([]string, len(rows[0]))
+	for i := range columns {
+		columns[i] = fmt.Sprintf("param_%d", i+1)
+	}
+
+	if strings.ToLower(filepath.Ext(filePath)) == ".json" {
+		var records []map[string]string
+		for _, row := range rows {
+			record := map[string]string{}
+			for i, column := range columns {
+				record[column] = row[i]
+			}
+			records = append(records, record)
+		}
+
+		output, err := json.MarshalIndent(records, "", "  ")
+		if err != nil {
+			return fmt.Errorf("error marshaling runner data: %v", err)
+		}
+		return os.WriteFile(filePath, output, 0644)
+	}
+
+	file, err := os.Create(filePath)
+	if err != nil {
+		return fmt.Errorf("error creating runner data file: %v", err)
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	if err := writer.Write(columns); err != nil {
+		return fmt.Errorf("error writing runner data file: %v", err)
+	}
+	writer.WriteAll(rows)
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("error writing runner data file: %v", err)
+	}
+
+	return nil
+}
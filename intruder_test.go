@@ -0,0 +1,102 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestReplaceIntruderMarkers(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+		values         []string
+	}{
+		{"two positions", "user=§bob§&pass=§secret§", "user={{param_1}}&pass={{param_2}}", []string{"bob", "secret"}},
+		{"empty position", "id=§§", "id={{param_1}}", []string{""}},
+		{"ISO-8859-1 markers", "id=\xa7123\xa7&x=\xe9", "id={{param_1}}&x=\xe9", []string{"123"}},
+		{"unpaired marker", "price=5§", "price=5§", nil},
+		{"third marker left as is", "§a§ and §", "{{param_1}} and §", []string{"a"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var values []string
+			if got := ReplaceIntruderMarkers(tt.in, &values); got != tt.want || !reflect.DeepEqual(values, tt.values) {
+				t.Errorf("ReplaceIntruderMarkers(%q) = %q, %q, want %q, %q", tt.in, got, values, tt.want, tt.values)
+			}
+		})
+	}
+}
+
+func TestNumberIntruderParams(t *testing.T) {
+	item := func(rawURL string, values ...string) PostmanItem {
+		req := PostmanRequest{Method: "GET", URL: URLFromString(rawURL)}
+		original := CloneRequest(req)
+		return PostmanItem{Request: req, IntruderValues: values, Response: []PostmanResponse{{OriginalRequest: &original}}}
+	}
+	items := []PostmanItem{
+		item("https://a/x?p={{param_1}}&q={{param_2}}", "1", "2"),
+		item("https://a/plain"),
+		item("https://a/y?r={{param_1}}", "3"),
+	}
+
+	originals := NumberIntruderParams(items)
+	if want := []string{"1", "2", "3"}; !reflect.DeepEqual(originals, want) {
+		t.Errorf("originals = %q, want %q", originals, want)
+	}
+	if got := items[2].Request.URL.Query[0].Value; got != "{{param_3}}" {
+		t.Errorf("third position = %s, want {{param_3}}", got)
+	}
+	if got := items[2].Response[0].OriginalRequest.URL.Raw; got != "https://a/y?r={{param_3}}" {
+		t.Errorf("example request = %s, want it renumbered too", got)
+	}
+}
+
+func TestBuildIntruderRows(t *testing.T) {
+	originals := []string{"bob", "secret"}
+	tests := []struct {
+		name  string
+		lists [][]string
+		want  [][]string
+	}{
+		{"no lists", nil, [][]string{{"bob", "secret"}}},
+		{"one list fills every position", [][]string{{"x", "y"}}, [][]string{{"bob", "secret"}, {"x", "x"}, {"y", "y"}}},
+		{"one list per position", [][]string{{"alice"}, {"a", "b"}}, [][]string{{"bob", "secret"}, {"alice", "a"}, {"bob", "b"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := BuildIntruderRows(originals, tt.lists); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("rows = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIntruderDataFiles(t *testing.T) {
+	dir := t.TempDir()
+	list := filepath.Join(dir, "payloads.txt")
+	if err := os.WriteFile(list, []byte("a\r\n\nb,c\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	lists, err := LoadPayloadLists(list + ", ")
+	if err != nil || !reflect.DeepEqual(lists, [][]string{{"a", "b,c"}}) {
+		t.Fatalf("LoadPayloadLists = %q, %v", lists, err)
+	}
+
+	rows := BuildIntruderRows([]string{"1"}, lists)
+	tests := []struct {
+		file, want string
+	}{
+		{"data.csv", "param_1\n1\na\n\"b,c\"\n"},
+		{"data.json", "[\n  {\n    \"param_1\": \"1\"\n  },\n  {\n    \"param_1\": \"a\"\n  },\n  {\n    \"param_1\": \"b,c\"\n  }\n]"},
+	}
+	for _, tt := range tests {
+		fileName := filepath.Join(dir, tt.file)
+		if err := WriteIntruderData(fileName, rows); err != nil {
+			t.Fatal(err)
+		}
+		if data, _ := os.ReadFile(fileName); string(data) != tt.want {
+			t.Errorf("%s =\n%s\nwant\n%s", tt.file, data, tt.want)
+		}
+	}
+}
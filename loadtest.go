@@ -0,0 +1,461 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+/*
+	###################################### LOAD TEST EXPORT ############################################################
+*/
+
+// LoadStep is a request of a replayed journey with the folder it belongs to
+type LoadStep struct {
+	Folder  string
+	Case    CodeCase
+	Extract []CorrelationRule
+}
+
+// BuildLoadSteps flattens a collection into the order the requests were recorded in Burp
+//
+// Requests keep their collection order when any of them has no recorded time, as with cURL conversions.
+// Consecutive requests from the same folder are later grouped together.
+func BuildLoadSteps(collection PostmanCollection) []LoadStep {
+	type timedStep struct {
+		step LoadStep
+		item PostmanItem
+	}
+	var timed []timedStep
+	WalkItems(collection.Item, func(folders []string, item *PostmanItem) {
+		timed = append(timed, timedStep{
+			step: LoadStep{Folder: strings.Join(folders, "/"), Case: buildCodeCase(nil, *item), Extract: ItemCorrelationRules(*item)},
+			item: *item,
+		})
+	})
+
+	recorded := true
+	for _, t := range timed {
+		if _, ok := itemTime(t.item); !ok {
+			recorded = false
+		}
+	}
+	if recorded {
+		sort.SliceStable(timed, func(i, j int) bool {
+			ti, _ := itemTime(timed[i].item)
+			tj, _ := itemTime(timed[j].item)
+			return ti.Before(tj)
+		})
+	}
+
+	steps := make([]LoadStep, 0, len(timed))
+	for _, t := range timed {
+		steps = append(steps, t.step)
+	}
+	return steps
+}
+
+// WriteK6Script writes a k6 script that replays the requests in recorded order and returns the number of requests
+//
+// Each folder becomes a group, each recorded status a check, and correlated values are read from the
+// responses into runtime variables. Other {{variables}} come from k6 run -e name=value or the collection values.
+func WriteK6Script(fileName string, collection PostmanCollection) (int, error) {
+	steps := BuildLoadSteps(collection)
+	var out strings.Builder
+	out.WriteString(`// Generated by go2postman
+import http from "k6/http";
+import encoding from "k6/encoding";
+import { check, group } from "k6";
+
+export const options = {
+  vus: 1,
+  iterations: 1,
+};
+
+// The collection values, overridden with k6 run -e name=value
+const variables = {
+`)
+	for _, variable := range collection.Variable {
+		fmt.Fprintf(&out, "  %s: %s,\n", codeString(variable.Key), codeString(variable.Value))
+	}
+	out.WriteString(`};
+
+// Values taken from earlier responses in the same iteration
+let extracted = {};
+
+// Replace {{variable}} references with their values
+function expand(value) {
+  return value.replace(/\{\{([^{}]+)\}\}/g, (match, name) => {
+    name = name.trim();
+    if (name in extracted) return extracted[name];
+    if (name in __ENV) return __ENV[name];
+    if (name in variables) return variables[name];
+    return match;
+  });
+}
+
+function send(method, url, body, headers) {
+  const params = { headers: {}, redirects: 0 };
+  for (const [name, value] of headers) {
+    params.headers[name] = expand(value);
+  }
+  return http.request(method, expand(url), typeof body === "string" ? expand(body) : body, params);
+}
+
+export default function () {
+  extracted = {};
+  let res;
+`)
+
+	indent := "  "
+	group := ""
+	for s, step := range steps {
+		if s == 0 || step.Folder != group {
+			if group != "" {
+				out.WriteString("  });\n")
+			}
+			group = step.Folder
+			indent = "  "
+			if group != "" {
+				fmt.Fprintf(&out, "\n  group(%s, function () {\n", codeString(group))
+				indent = "    "
+			}
+		}
+
+		c := step.Case
+		var headers []string
+		for _, header := range c.Header {
+			headers = append(headers, "["+codeString(header[0])+", "+codeString(header[1])+"]")
+		}
+		if c.Basic {
+			headers = append(headers, fmt.Sprintf(`["Authorization", "Basic " + encoding.b64encode(expand(%s) + ":" + expand(%s))]`, codeString(c.Username), codeString(c.Password)))
+		}
+		body := "null"
+		if c.Body != "" && !utf8.ValidString(c.Body) {
+			body = "encoding.b64decode(" + codeString(base64.StdEncoding.EncodeToString([]byte(c.Body))) + ", \"std\")"
+		} else if c.Body != "" {
+			body = codeString(c.Body)
+		}
+
+		fmt.Fprintf(&out, "\n%s// %s\n", indent, c.Name)
+		fmt.Fprintf(&out, "%sres = send(%s, %s, %s, [%s]);\n", indent, codeString(c.Method), codeString(c.URL), body, strings.Join(headers, ", "))
+		if c.Status != 0 {
+			fmt.Fprintf(&out, "%scheck(res, { %s: (r) => r.status === %d });\n", indent, codeString(fmt.Sprintf("%s status is %d", c.Name, c.Status)), c.Status)
+		}
+		for _, rule := range step.Extract {
+			fmt.Fprintf(&out, "%sextracted[%s] = %s;\n", indent, codeString(rule.Variable), k6ExtractExpression(rule))
+		}
+	}
+	if group != "" {
+		out.WriteString("  });\n")
+	}
+	out.WriteString("}\n")
+
+	if err := os.WriteFile(fileName, []byte(out.String()), 0644); err != nil {
+		return 0, fmt.Errorf("error writing k6 script: %v", err)
+	}
+	return len(steps), nil
+}
+
+// k6ExtractExpression returns the k6 expression reading a correlated value from res
+func k6ExtractExpression(rule CorrelationRule) string {
+	switch rule.Source {
+	case "json":
+		// k6 selects JSON values with gjson paths such as data.items.0.id
+		var path []string
+		for _, segment := range SplitJSONPath(rule.Expression) {
+			path = append(path, strings.NewReplacer("*", `\*`, "?", `\?`).Replace(segment))
+		}
+		return fmt.Sprintf("res.json(%s)", codeString(strings.Join(path, ".")))
+	case "header":
+		return fmt.Sprintf("res.headers[%s]", codeString(http.CanonicalHeaderKey(rule.Expression)))
+	case "cookie":
+		return fmt.Sprintf("(res.cookies[%s] || [{}])[0].value", codeString(rule.Expression))
+	}
+	return fmt.Sprintf("(String(res.body).match(new RegExp(%s)) || [])[1]", codeString(rule.Expression))
+}
+
+// jmeterVariableRegex matches {{variable}} references, rewritten to JMeter's ${variable}
+var jmeterVariableRegex = regexp.MustCompile(`\{\{\s*([^{}]+?)\s*\}\}`)
+
+// WriteJMeterPlan writes a JMeter test plan that replays the requests in recorded order and returns the number of
+// requests and the CSV file written for the collection variables
+//
+// The plan has one Thread Group running a single iteration, a Simple Controller per folder and an HTTP Request
+// sampler per request with its own Header Manager, a Response Assertion on the recorded status and extractors
+// for correlated values. The collection variables are read by a CSV Data Set Config from a file next to the plan.
+func WriteJMeterPlan(fileName string, collection PostmanCollection) (int, string, error) {
+	steps := BuildLoadSteps(collection)
+	csvFile := strings.TrimSuffix(fileName, filepath.Ext(fileName)) + ".csv"
+
+	var names, values []string
+	for _, variable := range collection.Variable {
+		names = append(names, variable.Key)
+		values = append(values, variable.Value)
+	}
+	if len(names) > 0 {
+		file, err := os.Create(csvFile)
+		if err != nil {
+			return 0, "", fmt.Errorf("error writing JMeter variables: %v", err)
+		}
+		writer := csv.NewWriter(file)
+		writer.WriteAll([][]string{names, values})
+		file.Close()
+		if err := writer.Error(); err != nil {
+			return 0, "", fmt.Errorf("error writing JMeter variables: %v", err)
+		}
+	} else {
+		csvFile = ""
+	}
+
+	var out strings.Builder
+	out.WriteString(xml.Header)
+	out.WriteString(`<jmeterTestPlan version="1.2" properties="5.0" jmeter="5.6.3">
+  <hashTree>
+`)
+	fmt.Fprintf(&out, "    <TestPlan guiclass=\"TestPlanGui\" testclass=\"TestPlan\" testname=%s>\n", jmxAttr(collection.Info.Name))
+	out.WriteString(`      <boolProp name="TestPlan.functional_mode">false</boolProp>
+      <boolProp name="TestPlan.serialize_threadgroups">false</boolProp>
+      <elementProp name="TestPlan.user_defined_variables" elementType="Arguments" guiclass="ArgumentsPanel" testclass="Arguments">
+        <collectionProp name="Arguments.arguments"/>
+      </elementProp>
+    </TestPlan>
+    <hashTree>
+`)
+	if csvFile != "" {
+		out.WriteString(`      <CSVDataSet guiclass="TestBeanGUI" testclass="CSVDataSet" testname="Collection variables">
+`)
+		fmt.Fprintf(&out, "        %s\n", jmxProp("stringProp", "filename", filepath.Base(csvFile)))
+		out.WriteString(`        <stringProp name="fileEncoding">UTF-8</stringProp>
+        <stringProp name="variableNames"></stringProp>
+        <boolProp name="ignoreFirstLine">false</boolProp>
+        <stringProp name="delimiter">,</stringProp>
+        <boolProp name="quotedData">true</boolProp>
+        <boolProp name="recycle">true</boolProp>
+        <boolProp name="stopThread">false</boolProp>
+        <stringProp name="shareMode">shareMode.all</stringProp>
+      </CSVDataSet>
+      <hashTree/>
+`)
+	}
+	out.WriteString(`      <ThreadGroup guiclass="ThreadGroupGui" testclass="ThreadGroup" testname="Recorded journey">
+        <stringProp name="ThreadGroup.on_sample_error">continue</stringProp>
+        <elementProp name="ThreadGroup.main_controller" elementType="LoopController" guiclass="LoopControlPanel" testclass="LoopController">
+          <stringProp name="LoopController.loops">1</stringProp>
+          <boolProp name="LoopController.continue_forever">false</boolProp>
+        </elementProp>
+        <stringProp name="ThreadGroup.num_threads">1</stringProp>
+        <stringProp name="ThreadGroup.ramp_time">1</stringProp>
+        <boolProp name="ThreadGroup.same_user_on_next_iteration">true</boolProp>
+      </ThreadGroup>
+      <hashTree>
+`)
+
+	indent := "        "
+	group := ""
+	for s, step := range steps {
+		if s == 0 || step.Folder != group {
+			if group != "" {
+				out.WriteString("        </hashTree>\n")
+			}
+			group = step.Folder
+			indent = "        "
+			if group != "" {
+				fmt.Fprintf(&out, "        <GenericController guiclass=\"LogicControllerGui\" testclass=\"GenericController\" testname=%s/>\n        <hashTree>\n", jmxAttr(group))
+				indent = "          "
+			}
+		}
+		writeJMeterSampler(&out, indent, step)
+	}
+	if group != "" {
+		out.WriteString("        </hashTree>\n")
+	}
+	out.WriteString(`      </hashTree>
+    </hashTree>
+  </hashTree>
+</jmeterTestPlan>
+`)
+
+	if err := os.WriteFile(fileName, []byte(out.String()), 0644); err != nil {
+		return 0, csvFile, fmt.Errorf("error writing JMeter plan: %v", err)
+	}
+	return len(steps), csvFile, nil
+}
+
+// writeJMeterSampler writes an HTTP Request sampler and its Header Manager, assertion and extractors
+func writeJMeterSampler(out *strings.Builder, indent string, step LoadStep) {
+	c := step.Case
+	jmeter := func(s string) string {
+		return jmeterVariableRegex.ReplaceAllString(s, "$${$1}")
+	}
+
+	// Concrete URLs are split into their parts, while URLs starting with a variable are given whole as the path
+	protocol, domain, port, path := "", "", "", jmeter(c.URL)
+	if scheme, rest, found := strings.Cut(c.URL, "://"); found {
+		authority, target := rest, "/"
+		if end := strings.IndexAny(rest, "/?#"); end >= 0 {
+			authority, target = rest[:end], rest[end:]
+		}
+		if !strings.Contains(authority, "{{") {
+			protocol, path = scheme, jmeter(target)
+			domain, port = SplitHostPort(authority)
+		}
+	}
+
+	fmt.Fprintf(out, "%s<HTTPSamplerProxy guiclass=\"HttpTestSampleGui\" testclass=\"HTTPSamplerProxy\" testname=%s>\n", indent, jmxAttr(c.Name))
+	props := [][2]string{
+		{"HTTPSampler.protocol", protocol},
+		{"HTTPSampler.domain", domain},
+		{"HTTPSampler.port", port},
+		{"HTTPSampler.path", path},
+		{"HTTPSampler.method", c.Method},
+		{"HTTPSampler.contentEncoding", "UTF-8"},
+	}
+	for _, prop := range props {
+		fmt.Fprintf(out, "%s  %s\n", indent, jmxProp("stringProp", prop[0], prop[1]))
+	}
+	fmt.Fprintf(out, "%s  <boolProp name=\"HTTPSampler.follow_redirects\">false</boolProp>\n", indent)
+	fmt.Fprintf(out, "%s  <boolProp name=\"HTTPSampler.auto_redirects\">false</boolProp>\n", indent)
+	fmt.Fprintf(out, "%s  <boolProp name=\"HTTPSampler.use_keepalive\">true</boolProp>\n", indent)
+	if c.Body != "" {
+		fmt.Fprintf(out, "%s  <boolProp name=\"HTTPSampler.postBodyRaw\">true</boolProp>\n", indent)
+		fmt.Fprintf(out, "%s  <elementProp name=\"HTTPsampler.Arguments\" elementType=\"Arguments\">\n", indent)
+		fmt.Fprintf(out, "%s    <collectionProp name=\"Arguments.arguments\">\n", indent)
+		fmt.Fprintf(out, "%s      <elementProp name=\"\" elementType=\"HTTPArgument\">\n", indent)
+		fmt.Fprintf(out, "%s        <boolProp name=\"HTTPArgument.always_encode\">false</boolProp>\n", indent)
+		fmt.Fprintf(out, "%s        %s\n", indent, jmxProp("stringProp", "Argument.value", jmeter(c.Body)))
+		fmt.Fprintf(out, "%s        <stringProp name=\"Argument.metadata\">=</stringProp>\n", indent)
+		fmt.Fprintf(out, "%s      </elementProp>\n", indent)
+		fmt.Fprintf(out, "%s    </collectionProp>\n", indent)
+		fmt.Fprintf(out, "%s  </elementProp>\n", indent)
+	} else {
+		fmt.Fprintf(out, "%s  <elementProp name=\"HTTPsampler.Arguments\" elementType=\"Arguments\">\n", indent)
+		fmt.Fprintf(out, "%s    <collectionProp name=\"Arguments.arguments\"/>\n", indent)
+		fmt.Fprintf(out, "%s  </elementProp>\n", indent)
+	}
+	fmt.Fprintf(out, "%s</HTTPSamplerProxy>\n%s<hashTree>\n", indent, indent)
+
+	headers := c.Header
+	if c.Basic {
+		credentials := c.Username + ":" + c.Password
+		value := "Basic " + base64.StdEncoding.EncodeToString([]byte(credentials))
+		if jmeterVariableRegex.MatchString(credentials) {
+			// Credentials holding variables are encoded when the request is sent
+			value = "Basic ${__groovy(" + jmeterFunctionArgument(groovyConcatenation(credentials)+".bytes.encodeBase64().toString()") + ")}"
+		}
+		headers = append(append([][2]string{}, headers...), [2]string{"Authorization", value})
+	}
+	if len(headers) > 0 {
+		fmt.Fprintf(out, "%s  <HeaderManager guiclass=\"HeaderPanel\" testclass=\"HeaderManager\" testname=\"HTTP Header Manager\">\n", indent)
+		fmt.Fprintf(out, "%s    <collectionProp name=\"HeaderManager.headers\">\n", indent)
+		for _, header := range headers {
+			value := header[1]
+			if !c.Basic || header[0] != "Authorization" {
+				value = jmeter(value)
+			}
+			fmt.Fprintf(out, "%s      <elementProp name=\"\" elementType=\"Header\">\n", indent)
+			fmt.Fprintf(out, "%s        %s\n", indent, jmxProp("stringProp", "Header.name", header[0]))
+			fmt.Fprintf(out, "%s        %s\n", indent, jmxProp("stringProp", "Header.value", value))
+			fmt.Fprintf(out, "%s      </elementProp>\n", indent)
+		}
+		fmt.Fprintf(out, "%s    </collectionProp>\n", indent)
+		fmt.Fprintf(out, "%s  </HeaderManager>\n%s  <hashTree/>\n", indent, indent)
+	}
+
+	if c.Status != 0 {
+		fmt.Fprintf(out, "%s  <ResponseAssertion guiclass=\"AssertionGui\" testclass=\"ResponseAssertion\" testname=%s>\n", indent, jmxAttr(fmt.Sprintf("Status %d", c.Status)))
+		fmt.Fprintf(out, "%s    <collectionProp name=\"Asserion.test_strings\">\n", indent)
+		fmt.Fprintf(out, "%s      <stringProp name=\"status\">%d</stringProp>\n", indent, c.Status)
+		fmt.Fprintf(out, "%s    </collectionProp>\n", indent)
+		fmt.Fprintf(out, "%s    <stringProp name=\"Assertion.test_field\">Assertion.response_code</stringProp>\n", indent)
+		fmt.Fprintf(out, "%s    <boolProp name=\"Assertion.assume_success\">true</boolProp>\n", indent)
+		fmt.Fprintf(out, "%s    <intProp name=\"Assertion.test_type\">8</intProp>\n", indent)
+		fmt.Fprintf(out, "%s  </ResponseAssertion>\n%s  <hashTree/>\n", indent, indent)
+	}
+
+	for _, rule := range step.Extract {
+		writeJMeterExtractor(out, indent+"  ", rule)
+	}
+	fmt.Fprintf(out, "%s</hashTree>\n", indent)
+}
+
+// writeJMeterExtractor writes the post-processor storing a correlated value in a JMeter variable
+func writeJMeterExtractor(out *strings.Builder, indent string, rule CorrelationRule) {
+	if rule.Source == "json" {
+		fmt.Fprintf(out, "%s<JSONPostProcessor guiclass=\"JSONPostProcessorGui\" testclass=\"JSONPostProcessor\" testname=%s>\n", indent, jmxAttr("Extract "+rule.Variable))
+		fmt.Fprintf(out, "%s  %s\n", indent, jmxProp("stringProp", "JSONPostProcessor.referenceNames", rule.Variable))
+		fmt.Fprintf(out, "%s  %s\n", indent, jmxProp("stringProp", "JSONPostProcessor.jsonPathExprs", rule.Expression))
+		fmt.Fprintf(out, "%s  <stringProp name=\"JSONPostProcessor.match_numbers\">1</stringProp>\n", indent)
+		fmt.Fprintf(out, "%s</JSONPostProcessor>\n%s<hashTree/>\n", indent, indent)
+		return
+	}
+
+	// Headers and cookies are matched in the response headers, anything else in the body
+	field, pattern := "false", rule.Expression
+	switch rule.Source {
+	case "header":
+		field, pattern = "true", "(?im)^"+regexp.QuoteMeta(rule.Expression)+`:\s*(.+?)\s*$`
+	case "cookie":
+		field, pattern = "true", "(?im)^Set-Cookie:\\s*"+regexp.QuoteMeta(rule.Expression)+"=([^;\\r\\n]*)"
+	}
+	fmt.Fprintf(out, "%s<RegexExtractor guiclass=\"RegexExtractorGui\" testclass=\"RegexExtractor\" testname=%s>\n", indent, jmxAttr("Extract "+rule.Variable))
+	fmt.Fprintf(out, "%s  <stringProp name=\"RegexExtractor.useHeaders\">%s</stringProp>\n", indent, field)
+	fmt.Fprintf(out, "%s  %s\n", indent, jmxProp("stringProp", "RegexExtractor.refname", rule.Variable))
+	fmt.Fprintf(out, "%s  %s\n", indent, jmxProp("stringProp", "RegexExtractor.regex", pattern))
+	fmt.Fprintf(out, "%s  <stringProp name=\"RegexExtractor.template\">$1$</stringProp>\n", indent)
+	fmt.Fprintf(out, "%s  <stringProp name=\"RegexExtractor.match_number\">1</stringProp>\n", indent)
+	fmt.Fprintf(out, "%s</RegexExtractor>\n%s<hashTree/>\n", indent, indent)
+}
+
+// groovyConcatenation renders a string holding {{variable}} references as a Groovy expression that reads the
+// variables with vars.get, keeping the literal parts in single quotes so they are never interpolated
+func groovyConcatenation(value string) string {
+	quote := func(literal string) string {
+		// Parentheses are unicode escapes so they cannot end the JMeter function call
+		return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`, `(`, `\u0028`, `)`, `\u0029`).Replace(literal) + "'"
+	}
+	var parts []string
+	last := 0
+	for _, match := range jmeterVariableRegex.FindAllStringSubmatchIndex(value, -1) {
+		if match[0] > last {
+			parts = append(parts, quote(value[last:match[0]]))
+		}
+		parts = append(parts, "vars.get("+quote(value[match[2]:match[3]])+")")
+		last = match[1]
+	}
+	if last < len(value) || len(parts) == 0 {
+		parts = append(parts, quote(value[last:]))
+	}
+	return "(" + strings.Join(parts, " + ") + ")"
+}
+
+// jmeterFunctionArgument escapes the backslashes, commas and dollar signs JMeter's function parser would
+// otherwise treat as escapes, parameter separators and variable references
+func jmeterFunctionArgument(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `,`, `\,`, `$`, `\$`).Replace(value)
+}
+
+// jmxProp renders a JMeter property element with an escaped value
+func jmxProp(kind, name, value string) string {
+	return fmt.Sprintf("<%s name=%s>%s</%s>", kind, jmxAttr(name), jmxText(value), kind)
+}
+
+// jmxAttr renders a quoted, escaped XML attribute value
+func jmxAttr(value string) string {
+	return `"` + jmxText(value) + `"`
+}
+
+// jmxText escapes text for XML; line breaks become character references, so they also survive in attributes
+func jmxText(value string) string {
+	var out strings.Builder
+	xml.EscapeText(&out, []byte(value))
+	return out.String()
+}
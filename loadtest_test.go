@@ -0,0 +1,112 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuildLoadSteps(t *testing.T) {
+	at := func(minute int) *ItemSource {
+		return &ItemSource{Time: time.Date(2024, 1, 1, 10, minute, 0, 0, time.UTC)}
+	}
+	request := func(name string, source *ItemSource) PostmanItem {
+		return PostmanItem{Name: name, Source: source, Request: PostmanRequest{Method: "GET", URL: URLFromString("https://api.example.com/" + name)}}
+	}
+	names := func(steps []LoadStep) []string {
+		var names []string
+		for _, step := range steps {
+			names = append(names, step.Folder+":"+step.Case.Name)
+		}
+		return names
+	}
+
+	recorded := PostmanCollection{Item: []PostmanItem{
+		{Name: "users", Item: []PostmanItem{request("list", at(3)), request("me", at(1))}},
+		request("login", at(0)),
+		request("logout", at(5)),
+	}}
+	if got, want := names(BuildLoadSteps(recorded)), []string{":login", "users:me", "users:list", ":logout"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("recorded steps = %q, want %q", got, want)
+	}
+
+	// cURL conversions carry no times, so the collection order is kept
+	converted := PostmanCollection{Item: []PostmanItem{request("b", nil), request("a", at(0))}}
+	if got, want := names(BuildLoadSteps(converted)), []string{":b", ":a"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("converted steps = %q, want %q", got, want)
+	}
+}
+
+func TestK6ExtractExpression(t *testing.T) {
+	tests := []struct {
+		rule CorrelationRule
+		want string
+	}{
+		{CorrelationRule{Source: "json", Expression: "$.data.items[0].id"}, `res.json("data.items.0.id")`},
+		{CorrelationRule{Source: "json", Expression: "$.a*b.c?"}, `res.json("a\\*b.c\\?")`},
+		{CorrelationRule{Source: "header", Expression: "x-request-id"}, `res.headers["X-Request-Id"]`},
+		{CorrelationRule{Source: "cookie", Expression: "session"}, `(res.cookies["session"] || [{}])[0].value`},
+		{CorrelationRule{Source: "regex", Expression: `csrf" value="([^"]+)"`}, `(String(res.body).match(new RegExp("csrf\" value=\"([^\"]+)\"")) || [])[1]`},
+	}
+	for _, tt := range tests {
+		if got := k6ExtractExpression(tt.rule); got != tt.want {
+			t.Errorf("k6ExtractExpression(%+v) = %s, want %s", tt.rule, got, tt.want)
+		}
+	}
+}
+
+func TestWriteK6Script(t *testing.T) {
+	collection := PostmanCollection{
+		Variable: []PostmanVariable{{Key: "baseUrl", Value: "https://api.example.com"}},
+		Item: []PostmanItem{
+			{Name: "upload", Request: PostmanRequest{Method: "PUT", URL: URLFromString("{{baseUrl}}/image"), Body: PostmanBody{Mode: "raw", Raw: "\x89PNG"}}},
+			{Name: "auth", Item: []PostmanItem{{Name: "login", Request: PostmanRequest{
+				Method: "POST",
+				URL:    URLFromString("{{baseUrl}}/login"),
+				Body:   PostmanBody{Mode: "raw", Raw: `{"user":"{{user}}"}`},
+			}, Response: []PostmanResponse{{Code: 200}}, Extract: []CorrelationRule{{Variable: "token", Source: "json", Expression: "$.token"}}}}},
+		},
+	}
+	fileName := filepath.Join(t.TempDir(), "load-test.js")
+	count, err := WriteK6Script(fileName, collection)
+	if err != nil || count != 2 {
+		t.Fatalf("WriteK6Script = %d, %v, want 2 requests", count, err)
+	}
+	script, err := os.ReadFile(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`  "baseUrl": "https://api.example.com",`,
+		`  res = send("PUT", "{{baseUrl}}/image", encoding.b64decode("iVBORw==", "std"), []);`,
+		"\n  group(\"auth\", function () {\n\n    // login\n",
+		`    res = send("POST", "{{baseUrl}}/login", "{\"user\":\"{{user}}\"}", []);`,
+		`    check(res, { "login status is 200": (r) => r.status === 200 });`,
+		`    extracted["token"] = res.json("token");`,
+	} {
+		if !strings.Contains(string(script), want) {
+			t.Errorf("script does not contain %s:\n%s", want, script)
+		}
+	}
+}
+
+func TestJMeterEscaping(t *testing.T) {
+	tests := []struct {
+		name, got, want string
+	}{
+		{"literal credentials", groovyConcatenation("user:pa'ss"), `('user:pa\'ss')`},
+		{"variables", groovyConcatenation("{{ user }}:{{password}}"), `(vars.get('user') + ':' + vars.get('password'))`},
+		{"parentheses", groovyConcatenation("a(b)"), `('a\u0028b\u0029')`},
+		{"empty", groovyConcatenation(""), `('')`},
+		{"function argument", jmeterFunctionArgument(`('a,b' + '$x\y')`), `('a\,b' + '\$x\\y')`},
+		{"text keeps line breaks as references", jmxText("a\n<b>&\"c\""), "a&#xA;&lt;b&gt;&amp;&#34;c&#34;"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s = %s, want %s", tt.name, tt.got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,585 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+/*
+	###################################### OPENAPI EXPORT ##############################################################
+*/
+
+// OpenAPIDocument is an OpenAPI 3.1 description inferred from captured traffic
+type OpenAPIDocument struct {
+	OpenAPI    string                                  `json:"openapi"`
+	Info       OpenAPIInfo                             `json:"info"`
+	Servers    []OpenAPIServer                         `json:"servers,omitempty"`
+	Paths      map[string]map[string]*OpenAPIOperation `json:"paths"`
+	Components *OpenAPIComponents                      `json:"components,omitempty"`
+}
+
+// OpenAPIInfo describes the API
+type OpenAPIInfo struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// OpenAPIServer is a recorded origin the API was reached at
+type OpenAPIServer struct {
+	URL string `json:"url"`
+}
+
+// OpenAPIOperation is one method on a path
+type OpenAPIOperation struct {
+	Summary     string                     `json:"summary,omitempty"`
+	OperationID string                     `json:"operationId"`
+	Parameters  []OpenAPIParameter         `json:"parameters,omitempty"`
+	RequestBody *OpenAPIRequestBody        `json:"requestBody,omitempty"`
+	Responses   map[string]OpenAPIResponse `json:"responses"`
+	Security    []map[string][]string      `json:"security,omitempty"`
+}
+
+// OpenAPIParameter is a path, query, header or cookie parameter
+type OpenAPIParameter struct {
+	Name     string      `json:"name"`
+	In       string      `json:"in"`
+	Required bool        `json:"required"`
+	Schema   *JSONSchema `json:"schema"`
+	Example  interface{} `json:"example,omitempty"`
+}
+
+// OpenAPIRequestBody lists the observed request bodies by media type
+type OpenAPIRequestBody struct {
+	Required bool                        `json:"required"`
+	Content  map[string]OpenAPIMediaType `json:"content"`
+}
+
+// OpenAPIMediaType holds the merged schema and an observed example of a body
+type OpenAPIMediaType struct {
+	Schema  *JSONSchema `json:"schema"`
+	Example interface{} `json:"example,omitempty"`
+}
+
+// OpenAPIResponse is the observed response for one status code
+type OpenAPIResponse struct {
+	Description string                      `json:"description"`
+	Content     map[string]OpenAPIMediaType `json:"content,omitempty"`
+}
+
+// OpenAPIComponents holds the security schemes derived from the detected auth types
+type OpenAPIComponents struct {
+	SecuritySchemes map[string]OpenAPISecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+// OpenAPISecurityScheme is an HTTP or API key security scheme
+type OpenAPISecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+	Name         string `json:"name,omitempty"`
+	In           string `json:"in,omitempty"`
+}
+
+// skippedHeaderParameters are headers described elsewhere in OpenAPI or added by every client
+var skippedHeaderParameters = []string{
+	"accept", "content-type", "authorization", "cookie", "user-agent", "origin", "referer", "content-length",
+}
+
+// operationSamples collects every observed request for one method and templated path
+type operationSamples struct {
+	method     string
+	path       string
+	pathParams []string
+	pathValues map[string][]string
+	items      []PostmanItem
+}
+
+// ExportOpenAPI infers an OpenAPI 3.1 document from the requests and saved examples of a collection
+//
+// Paths use the collection's path variables, with ID-like segments templated as well. Request and
+// response bodies are merged into one JSON Schema per media type and status code, so fields seen in every
+// sample are required. The first observed value of each body and parameter becomes its example.
+func ExportOpenAPI(collection PostmanCollection) OpenAPIDocument {
+	variables := map[string]string{}
+	for _, variable := range collection.Variable {
+		if variable.Value != "" {
+			variables[variable.Key] = variable.Value
+		}
+	}
+
+	var order []string
+	operations := map[string]*operationSamples{}
+	var servers []OpenAPIServer
+	seenServers := map[string]bool{}
+	WalkItems(collection.Item, func(_ []string, item *PostmanItem) {
+		path, params, values := openAPIPath(item.Request.URL)
+		method := strings.ToLower(item.Request.Method)
+		key := method + " " + path
+		samples, ok := operations[key]
+		if !ok {
+			samples = &operationSamples{method: method, path: path, pathParams: params, pathValues: map[string][]string{}}
+			operations[key] = samples
+			order = append(order, key)
+		}
+		for i, name := range params {
+			samples.pathValues[name] = append(samples.pathValues[name], ResolveVariables(values[i], variables))
+		}
+		samples.items = append(samples.items, *item)
+
+		server := openAPIServer(item.Request.URL, variables)
+		if server != "" && !seenServers[server] {
+			seenServers[server] = true
+			servers = append(servers, OpenAPIServer{URL: server})
+		}
+	})
+
+	document := OpenAPIDocument{
+		OpenAPI: "3.1.0",
+		Info:    OpenAPIInfo{Title: collection.Info.Name, Description: collection.Info.Description, Version: "1.0.0"},
+		Servers: servers,
+		Paths:   map[string]map[string]*OpenAPIOperation{},
+	}
+	schemes := map[string]OpenAPISecurityScheme{}
+	operationIDs := map[string]bool{}
+	for _, key := range order {
+		samples := operations[key]
+		operation := buildOperation(samples, variables, schemes)
+		operation.OperationID = uniqueVariableName(samples.method+" "+strings.NewReplacer("{", "by ", "}", "").Replace(samples.path), operationIDs)
+		if document.Paths[samples.path] == nil {
+			document.Paths[samples.path] = map[string]*OpenAPIOperation{}
+		}
+		document.Paths[samples.path][samples.method] = operation
+	}
+	if len(schemes) > 0 {
+		document.Components = &OpenAPIComponents{SecuritySchemes: schemes}
+	}
+	return document
+}
+
+// WriteOpenAPI writes the OpenAPI document inferred from a collection and returns the number of operations
+func WriteOpenAPI(fileName string, collection PostmanCollection) (int, error) {
+	document := ExportOpenAPI(collection)
+	output, err := json.MarshalIndent(document, "", "  ")
+	if err != nil {
+		return 0, fmt.Errorf("error marshaling OpenAPI document: %v", err)
+	}
+	if err := os.WriteFile(fileName, output, 0644); err != nil {
+		return 0, fmt.Errorf("error writing OpenAPI document: %v", err)
+	}
+
+	count := 0
+	for _, methods := range document.Paths {
+		count += len(methods)
+	}
+	return count, nil
+}
+
+// openAPIPath turns a URL path into an OpenAPI path template, returning the parameter names and observed values
+func openAPIPath(u PostmanURL) (string, []string, []string) {
+	var names, values []string
+	used := map[string]bool{}
+	segments := make([]string, len(u.Path))
+	for p, segment := range u.Path {
+		name, value := "", segment
+		if strings.HasPrefix(segment, ":") {
+			name = segment[1:]
+			value = ""
+			for _, variable := range u.Variable {
+				if variable.Key == name {
+					value = variable.Value
+				}
+			}
+		} else if kind := ClassifySegment(segment); kind != "" {
+			name = segmentVariableName(u.Path, p, kind)
+		}
+		if name == "" {
+			segments[p] = segment
+			continue
+		}
+
+		unique := name
+		for n := 2; used[unique]; n++ {
+			unique = fmt.Sprintf("%s%d", name, n)
+		}
+		used[unique] = true
+		segments[p] = "{" + unique + "}"
+		names = append(names, unique)
+		values = append(values, value)
+	}
+	return "/" + strings.Join(segments, "/"), names, values
+}
+
+// openAPIServer returns the resolved origin of a URL, or "" when its host is an unresolved variable
+func openAPIServer(u PostmanURL, variables map[string]string) string {
+	host := ResolveVariables(strings.Join(u.Host, "."), variables)
+	if strings.Contains(host, "://") {
+		return strings.TrimRight(host, "/")
+	}
+	if origin, _ := requestOrigin(PostmanURL{Protocol: u.Protocol, Host: []string{host}, Port: u.Port}); origin != "" {
+		return origin
+	}
+	return ""
+}
+
+// buildOperation describes the parameters, bodies, responses and security of one operation from its samples
+func buildOperation(samples *operationSamples, variables map[string]string, schemes map[string]OpenAPISecurityScheme) *OpenAPIOperation {
+	operation := &OpenAPIOperation{Summary: strings.ToUpper(samples.method) + " " + samples.path, Responses: map[string]OpenAPIResponse{}}
+	resolve := func(s string) string {
+		return ResolveVariables(s, variables)
+	}
+
+	for _, name := range samples.pathParams {
+		values := samples.pathValues[name]
+		schema := valueSchema(values)
+		operation.Parameters = append(operation.Parameters, OpenAPIParameter{
+			Name: name, In: "path", Required: true, Schema: schema, Example: firstExample(values, schema),
+		})
+	}
+
+	// Query, header and cookie parameters are required when every sample sent them
+	type parameterValues struct {
+		in, name string
+		values   []string
+	}
+	var parameters []*parameterValues
+	byKey := map[string]*parameterValues{}
+	observe := func(in, name, value string) {
+		key := in + "\x00" + strings.ToLower(name)
+		p, ok := byKey[key]
+		if !ok {
+			p = &parameterValues{in: in, name: name}
+			byKey[key] = p
+			parameters = append(parameters, p)
+		}
+		p.values = append(p.values, resolve(value))
+	}
+
+	bodies := map[string][]string{}
+	forms := map[string][][]PostmanFormParam{}
+	var mediaTypes []string
+	bodyCount := 0
+	security := map[string]bool{}
+	var securityOrder []string
+	for _, item := range samples.items {
+		req := item.Request
+		seen := map[string]bool{}
+		once := func(in, name, value string) {
+			if key := in + "\x00" + strings.ToLower(name); !seen[key] {
+				seen[key] = true
+				observe(in, name, value)
+			}
+		}
+
+		for _, param := range req.URL.Query {
+			once("query", param.Key, param.Value)
+		}
+		contentType := ""
+		for _, header := range req.Header {
+			if header.Disabled {
+				continue
+			}
+			name := strings.ToLower(header.Key)
+			switch {
+			case name == "content-type":
+				contentType = header.Value
+			case name == "cookie":
+				for _, cookie := range parseRequestCookies(header.Value) {
+					once("cookie", cookie.Name, cookie.Value)
+				}
+			case matchesAny(name, skippedHeaderParameters), matchesAny(name, noisyHeaders), apiKeyHeaders[name]:
+			default:
+				once("header", header.Key, header.Value)
+			}
+		}
+
+		for _, scheme := range securitySchemes(req, schemes) {
+			if !security[scheme] {
+				security[scheme] = true
+				securityOrder = append(securityOrder, scheme)
+			}
+		}
+
+		switch {
+		case len(req.Body.Formdata) > 0, len(req.Body.Urlencoded) > 0:
+			mediaType, fields := "multipart/form-data", req.Body.Formdata
+			if len(fields) == 0 {
+				mediaType, fields = "application/x-www-form-urlencoded", req.Body.Urlencoded
+			}
+			if _, ok := bodies[mediaType]; !ok {
+				if _, ok := forms[mediaType]; !ok {
+					mediaTypes = append(mediaTypes, mediaType)
+				}
+			}
+			forms[mediaType] = append(forms[mediaType], fields)
+			bodyCount++
+		default:
+			if body := resolve(req.Body.Raw); strings.TrimSpace(body) != "" {
+				mediaType := mediaTypeOf(contentType, "application/octet-stream")
+				if _, ok := bodies[mediaType]; !ok {
+					if _, ok := forms[mediaType]; !ok {
+						mediaTypes = append(mediaTypes, mediaType)
+					}
+				}
+				bodies[mediaType] = append(bodies[mediaType], body)
+				bodyCount++
+			}
+		}
+	}
+
+	for _, p := range parameters {
+		schema := valueSchema(p.values)
+		operation.Parameters = append(operation.Parameters, OpenAPIParameter{
+			Name: p.name, In: p.in, Required: len(p.values) == len(samples.items),
+			Schema: schema, Example: firstExample(p.values, schema),
+		})
+	}
+
+	if bodyCount > 0 {
+		operation.RequestBody = &OpenAPIRequestBody{Required: bodyCount == len(samples.items), Content: map[string]OpenAPIMediaType{}}
+		for _, mediaType := range mediaTypes {
+			content := formMediaType(forms[mediaType], resolve)
+			if len(bodies[mediaType]) > 0 {
+				raw := bodyMediaType(mediaType, bodies[mediaType])
+				content.Schema = MergeJSONSchema(content.Schema, raw.Schema)
+				if content.Example == nil {
+					content.Example = raw.Example
+				}
+			}
+			operation.RequestBody.Content[mediaType] = content
+		}
+	}
+
+	// Responses are merged per status code from the saved examples
+	type responseSamples struct {
+		description string
+		mediaTypes  []string
+		bodies      map[string][]string
+	}
+	responses := map[string]*responseSamples{}
+	for _, item := range samples.items {
+		for _, response := range item.Response {
+			code := "default"
+			if response.Code > 0 {
+				code = strconv.Itoa(response.Code)
+			}
+			r, ok := responses[code]
+			if !ok {
+				description := response.Status
+				if description == "" {
+					description = http.StatusText(response.Code)
+				}
+				r = &responseSamples{description: description, bodies: map[string][]string{}}
+				responses[code] = r
+			}
+			if strings.TrimSpace(response.Body) == "" {
+				continue
+			}
+			contentType := ""
+			for _, header := range response.Header {
+				if strings.EqualFold(header.Key, "Content-Type") {
+					contentType = header.Value
+				}
+			}
+			mediaType := mediaTypeOf(contentType, "text/plain")
+			if _, ok := r.bodies[mediaType]; !ok {
+				r.mediaTypes = append(r.mediaTypes, mediaType)
+			}
+			r.bodies[mediaType] = append(r.bodies[mediaType], response.Body)
+		}
+	}
+	for code, r := range responses {
+		response := OpenAPIResponse{Description: r.description}
+		if response.Description == "" {
+			response.Description = "Recorded response"
+		}
+		for _, mediaType := range r.mediaTypes {
+			if response.Content == nil {
+				response.Content = map[string]OpenAPIMediaType{}
+			}
+			response.Content[mediaType] = bodyMediaType(mediaType, r.bodies[mediaType])
+		}
+		operation.Responses[code] = response
+	}
+	if len(operation.Responses) == 0 {
+		operation.Responses["default"] = OpenAPIResponse{Description: "No response was recorded"}
+	}
+
+	for _, scheme := range securityOrder {
+		operation.Security = append(operation.Security, map[string][]string{scheme: {}})
+	}
+	return operation
+}
+
+// securitySchemes registers and returns the security schemes a request uses, from its auth types
+func securitySchemes(req PostmanRequest, schemes map[string]OpenAPISecurityScheme) []string {
+	var names []string
+	for _, auth := range authTypes(req) {
+		var name string
+		var scheme OpenAPISecurityScheme
+		switch {
+		case auth == "cookie" || auth == "":
+			continue
+		case strings.HasPrefix(auth, "api-key:"):
+			header := strings.TrimPrefix(auth, "api-key:")
+			name = camelCase(header)
+			scheme = OpenAPISecurityScheme{Type: "apiKey", Name: header, In: "header"}
+		case auth == "bearer":
+			name = "bearerAuth"
+			scheme = OpenAPISecurityScheme{Type: "http", Scheme: "bearer"}
+			for _, header := range req.Header {
+				if strings.EqualFold(header.Key, "Authorization") && jwtRegex.MatchString(header.Value) {
+					scheme.BearerFormat = "JWT"
+				}
+			}
+		default:
+			name = auth + "Auth"
+			scheme = OpenAPISecurityScheme{Type: "http", Scheme: auth}
+		}
+
+		if existing, ok := schemes[name]; !ok || existing.BearerFormat == "" {
+			schemes[name] = scheme
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+// bodyMediaType merges the schemas of observed bodies of one media type, using the first as the example
+func bodyMediaType(mediaType string, bodies []string) OpenAPIMediaType {
+	var schema *JSONSchema
+	var example interface{}
+
+	switch {
+	case strings.Contains(mediaType, "json"):
+		for _, body := range bodies {
+			if sample := InferJSONSchemaFromBody(body); sample != nil {
+				schema = MergeJSONSchema(schema, sample)
+				if example == nil {
+					example = json.RawMessage(strings.TrimSpace(body))
+				}
+			}
+		}
+	case mediaType == "application/x-www-form-urlencoded":
+		for _, body := range bodies {
+			values, err := url.ParseQuery(strings.TrimSpace(body))
+			if err != nil {
+				continue
+			}
+			sample := &JSONSchema{Types: []string{"object"}, Properties: map[string]*JSONSchema{}}
+			for name := range values {
+				sample.Properties[name] = &JSONSchema{Types: []string{"string"}}
+				sample.Required = append(sample.Required, name)
+			}
+			sort.Strings(sample.Required)
+			schema = MergeJSONSchema(schema, sample)
+			if example == nil {
+				example = strings.TrimSpace(body)
+			}
+		}
+	}
+
+	if schema == nil {
+		schema = &JSONSchema{Types: []string{"string"}}
+		if len(bodies) > 0 {
+			example = bodies[0]
+		}
+	}
+	return OpenAPIMediaType{Schema: schema, Example: example}
+}
+
+// formMediaType merges the fields of urlencoded or multipart bodies into an object schema, using the first
+// body as the example. Fields are required when every body sent them, repeated fields are arrays and file
+// uploads are binary strings.
+func formMediaType(forms [][]PostmanFormParam, resolve func(string) string) OpenAPIMediaType {
+	var schema *JSONSchema
+	var example map[string]interface{}
+	for _, fields := range forms {
+		sample := &JSONSchema{Types: []string{"object"}, Properties: map[string]*JSONSchema{}}
+		values := map[string]interface{}{}
+		for _, field := range fields {
+			if field.Disabled {
+				continue
+			}
+			name := resolve(field.Key)
+			property := &JSONSchema{Types: []string{"string"}}
+			if field.Type == "file" {
+				property.ContentMediaType = "application/octet-stream"
+			} else if previous, ok := values[name]; ok {
+				if list, ok := previous.([]string); ok {
+					values[name] = append(list, resolve(field.Value))
+				} else {
+					values[name] = []string{previous.(string), resolve(field.Value)}
+				}
+			} else {
+				values[name] = resolve(field.Value)
+			}
+
+			if existing, ok := sample.Properties[name]; ok {
+				if !existing.HasType("array") {
+					existing = &JSONSchema{Types: []string{"array"}, Items: existing}
+				}
+				existing.Items = MergeJSONSchema(existing.Items, property)
+				sample.Properties[name] = existing
+				continue
+			}
+			sample.Properties[name] = property
+			sample.Required = append(sample.Required, name)
+		}
+		sort.Strings(sample.Required)
+		schema = MergeJSONSchema(schema, sample)
+		if example == nil && len(values) > 0 {
+			example = values
+		}
+	}
+
+	content := OpenAPIMediaType{Schema: schema}
+	if example != nil {
+		content.Example = example
+	}
+	return content
+}
+
+// valueSchema returns an integer schema when every observed value is an integer, otherwise a string schema
+func valueSchema(values []string) *JSONSchema {
+	if len(values) == 0 {
+		return &JSONSchema{Types: []string{"string"}}
+	}
+	for _, value := range values {
+		if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+			return &JSONSchema{Types: []string{"string"}}
+		}
+	}
+	return &JSONSchema{Types: []string{"integer"}}
+}
+
+// firstExample returns the first non-empty observed value, as a number for integer schemas
+func firstExample(values []string, schema *JSONSchema) interface{} {
+	for _, value := range values {
+		if value == "" {
+			continue
+		}
+		if schema.HasType("integer") {
+			if number, err := strconv.ParseInt(value, 10, 64); err == nil {
+				return number
+			}
+		}
+		return value
+	}
+	return nil
+}
+
+// mediaTypeOf returns the media type of a Content-Type header without its parameters
+func mediaTypeOf(contentType, fallback string) string {
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+		return mediaType
+	}
+	return fallback
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestExportOpenAPI(t *testing.T) {
+	request := func(method, rawURL, contentType, body string) PostmanItem {
+		req := PostmanRequest{Method: method, URL: URLFromString(rawURL), Body: PostmanBody{Mode: "raw", Raw: body}}
+		if contentType != "" {
+			req.Header = []PostmanHeader{{Key: "Content-Type", Value: contentType}}
+		}
+		return PostmanItem{Name: method + " request", Request: req}
+	}
+	form := func(body PostmanBody) PostmanItem {
+		return PostmanItem{Name: "POST upload", Request: PostmanRequest{Method: "POST", URL: URLFromString("https://api.example.com/upload"), Body: body}}
+	}
+
+	collection := PostmanCollection{Item: []PostmanItem{
+		request("GET", "https://api.example.com/users/123?page=1&sort=name", "", ""),
+		request("GET", "https://api.example.com/users/456?page=2", "", ""),
+		request("POST", "https://api.example.com/users", "application/json", `{"name":"a","age":30}`),
+		request("POST", "https://api.example.com/users", "application/json; charset=utf-8", `{"name":"b"}`),
+		request("PUT", "https://api.example.com/broken", "application/json", `{"a":1}}`),
+		form(PostmanBody{Mode: "formdata", Formdata: []PostmanFormParam{{Key: "name", Value: "bob"}, {Key: "tag", Value: "a"}, {Key: "tag", Value: "b"}, {Key: "file", Type: "file", Src: "/tmp/x.png"}}}),
+		form(PostmanBody{Mode: "formdata", Formdata: []PostmanFormParam{{Key: "file", Type: "file", Src: "/tmp/y.png"}, {Key: "tag", Value: "c"}}}),
+		form(PostmanBody{Mode: "urlencoded", Urlencoded: []PostmanFormParam{{Key: "q", Value: "1"}, {Key: "off", Value: "1", Disabled: true}}}),
+	}}
+
+	doc := ExportOpenAPI(collection)
+	output, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatalf("marshaling the document: %v", err)
+	}
+	var written struct {
+		Paths map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	if err := json.Unmarshal(output, &written); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name, path, method, want string
+	}{
+		{
+			name: "templated path and query parameters", path: "/users/{userId}", method: "get",
+			want: `{"summary":"GET /users/{userId}","operationId":"getUsersByUserId","parameters":[` +
+				`{"name":"userId","in":"path","required":true,"schema":{"type":"integer"},"example":123},` +
+				`{"name":"page","in":"query","required":true,"schema":{"type":"integer"},"example":1},` +
+				`{"name":"sort","in":"query","required":false,"schema":{"type":"string"},"example":"name"}],` +
+				`"responses":{"default":{"description":"No response was recorded"}}}`,
+		},
+		{
+			name: "JSON bodies merged per media type", path: "/users", method: "post",
+			want: `{"summary":"POST /users","operationId":"postUsers","requestBody":{"required":true,"content":{"application/json":{` +
+				`"schema":{"properties":{"age":{"type":"integer"},"name":{"type":"string"}},"required":["name"],"type":"object"},` +
+				`"example":{"name":"a","age":30}}}},"responses":{"default":{"description":"No response was recorded"}}}`,
+		},
+		{
+			name: "invalid JSON is kept as a string", path: "/broken", method: "put",
+			want: `{"summary":"PUT /broken","operationId":"putBroken","requestBody":{"required":true,"content":{"application/json":{` +
+				`"schema":{"type":"string"},"example":"{\"a\":1}}"}}},"responses":{"default":{"description":"No response was recorded"}}}`,
+		},
+		{
+			name: "form bodies", path: "/upload", method: "post",
+			want: `{"summary":"POST /upload","operationId":"postUpload","requestBody":{"required":true,"content":{` +
+				`"application/x-www-form-urlencoded":{"schema":{"properties":{"q":{"type":"string"}},"required":["q"],"type":"object"},"example":{"q":"1"}},` +
+				`"multipart/form-data":{"schema":{"properties":{` +
+				`"file":{"contentMediaType":"application/octet-stream","type":"string"},` +
+				`"name":{"type":"string"},` +
+				`"tag":{"items":{"type":"string"},"type":["array","string"]}},"required":["file","tag"],"type":"object"},` +
+				`"example":{"name":"bob","tag":["a","b"]}}}},"responses":{"default":{"description":"No response was recorded"}}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := string(compactJSON(written.Paths[tt.path][tt.method]))
+			if got != tt.want {
+				t.Errorf("operation =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+/*
+	###################################### ORDERING CONVERTED ITEMS ####################################################
+*/
+
+// burpTimeLayouts lists the date formats Burp writes for <time> and exportTime across versions and locales
+var burpTimeLayouts = []string{
+	"Mon Jan 2 15:04:05 MST 2006",
+	"Mon Jan 2 15:04:05 -0700 2006",
+	"Jan 2 15:04:05 MST 2006",
+	"15:04:05 2 Jan 2006",
+	"15:04:05 2 January 2006",
+	"2 Jan 2006 15:04:05",
+	"2 January 2006 15:04:05",
+	"Jan 2, 2006, 3:04:05 PM",
+	"Jan 2, 2006 3:04:05 PM",
+	"January 2, 2006 3:04:05 PM",
+	"02/01/2006 15:04:05",
+	"02/01/2006, 15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+	time.RFC3339,
+	time.RFC1123,
+	time.RFC1123Z,
+	time.UnixDate,
+}
+
+// burpZoneOffsets maps the zone abbreviations Java writes to their offsets, which Go cannot derive from the name
+var burpZoneOffsets = map[string]int{
+	"UTC": 0, "GMT": 0, "WET": 0, "BST": 1, "WEST": 1, "CET": 1, "CEST": 2, "EET": 2, "EEST": 3,
+	"MSK": 3, "GST": 4, "PKT": 5, "SGT": 8, "HKT": 8, "AWST": 8, "JST": 9, "KST": 9, "AEST": 10, "AEDT": 11,
+	"NZST": 12, "NZDT": 13, "EST": -5, "EDT": -4, "CST": -6, "CDT": -5, "MST": -7, "MDT": -6, "PST": -8, "PDT": -7,
+}
+
+// zoneAbbreviationRegex finds a zone abbreviation between the time of day and the year, as in Java's Date.toString
+var zoneAbbreviationRegex = regexp.MustCompile(`\d{2}:\d{2}:\d{2} ([A-Z]{2,5}) \d{4}`)
+
+// ParseBurpTime parses a Burp timestamp, returning false if no known layout matches
+func ParseBurpTime(value string) (time.Time, bool) {
+	value = strings.Join(strings.Fields(value), " ")
+	if value == "" {
+		return time.Time{}, false
+	}
+
+	for _, layout := range burpTimeLayouts {
+		parsed, err := time.Parse(layout, value)
+		if err != nil {
+			continue
+		}
+
+		// Apply the real offset for abbreviations Go records as UTC+0
+		if match := zoneAbbreviationRegex.FindStringSubmatch(value); match != nil {
+			if hours, ok := burpZoneOffsets[match[1]]; ok {
+				zone := time.FixedZone(match[1], hours*3600)
+				parsed = time.Date(parsed.Year(), parsed.Month(), parsed.Day(), parsed.Hour(), parsed.Minute(),
+					parsed.Second(), parsed.Nanosecond(), zone)
+			}
+		}
+		return parsed, true
+	}
+
+	return time.Time{}, false
+}
+
+// SortItems orders items by "original" recording order, Burp "time" or "host" and path
+func SortItems(items []PostmanItem, mode string) error {
+	switch mode {
+	case "", "original":
+		return nil
+
+	case "time":
+		sort.SliceStable(items, func(i, j int) bool {
+			ti, iok := itemTime(items[i])
+			tj, jok := itemTime(items[j])
+			// Items without a timestamp keep their place after the timed ones
+			if iok != jok {
+				return iok
+			}
+			return iok && ti.Before(tj)
+		})
+		return nil
+
+	case "host":
+		sort.SliceStable(items, func(i, j int) bool {
+			hi, pi := itemHostAndPath(items[i])
+			hj, pj := itemHostAndPath(items[j])
+			if hi != hj {
+				return hi < hj
+			}
+			return pi < pj
+		})
+		return nil
+	}
+
+	return fmt.Errorf("unknown sort mode %q, expected original, time or host", mode)
+}
+
+// itemTime returns the Burp timestamp of an item, falling back to the export time of its file
+func itemTime(item PostmanItem) (time.Time, bool) {
+	if item.Source == nil {
+		return time.Time{}, false
+	}
+	if !item.Source.Time.IsZero() {
+		return item.Source.Time, true
+	}
+	return ParseBurpTime(item.Source.ExportTime)
+}
+
+// itemHostAndPath returns the lower case host and the path of an item's URL
+func itemHostAndPath(item PostmanItem) (string, string) {
+	host := strings.ToLower(strings.Join(item.Request.URL.Host, "."))
+	if item.Request.URL.Port != "" {
+		host += ":" + item.Request.URL.Port
+	}
+	return host, "/" + strings.Join(item.Request.URL.Path, "/")
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseBurpTime(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+		ok    bool
+	}{
+		{"Mon Oct 19 10:00:00 UTC 2026", "2026-10-19T10:00:00Z", true},
+		{"Mon Oct 19 10:00:00 CEST 2026", "2026-10-19T10:00:00+02:00", true},
+		{"Mon  Oct 19 10:00:00 PDT 2026", "2026-10-19T10:00:00-07:00", true},
+		{"10:00:00 19 Oct 2026", "2026-10-19T10:00:00Z", true},
+		{"Oct 19, 2026, 3:04:05 PM", "2026-10-19T15:04:05Z", true},
+		{"2026-10-19T10:00:00+01:00", "2026-10-19T10:00:00+01:00", true},
+		{"yesterday", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		parsed, ok := ParseBurpTime(tt.value)
+		got := ""
+		if ok {
+			got = parsed.Format(time.RFC3339)
+		}
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("ParseBurpTime(%q) = %s, %v, want %s, %v", tt.value, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestSortItems(t *testing.T) {
+	item := func(name, rawURL string, source *ItemSource) PostmanItem {
+		return PostmanItem{Name: name, Source: source, Request: PostmanRequest{Method: "GET", URL: URLFromString(rawURL)}}
+	}
+	recorded := func() []PostmanItem {
+		return []PostmanItem{
+			item("late", "https://b.example.com/x", &ItemSource{Time: time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)}),
+			item("untimed", "https://a.example.com/z", nil),
+			item("exported", "https://a.example.com:8443/a", &ItemSource{ExportTime: "Thu Jan 01 11:00:00 UTC 2026"}),
+			item("early", "https://a.example.com/b", &ItemSource{Time: time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)}),
+		}
+	}
+
+	tests := []struct {
+		mode string
+		want []string
+	}{
+		{"original", []string{"late", "untimed", "exported", "early"}},
+		{"time", []string{"early", "exported", "late", "untimed"}},
+		{"host", []string{"early", "untimed", "exported", "late"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			items := recorded()
+			if err := SortItems(items, tt.mode); err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, item := range items {
+				got = append(got, item.Name)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("order = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if err := SortItems(recorded(), "size"); err == nil {
+		t.Errorf("SortItems accepted an unknown mode")
+	}
+}
@@ -0,0 +1,442 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+/*
+	###################################### RAW REQUEST FILE EXPORT #####################################################
+*/
+
+// rawPointLocations are the parts of a request a marker can be placed in
+var rawPointLocations = []string{"query", "path", "header", "cookie", "form", "json"}
+
+// RawPoint is a parameter, header or JSON field a marker is placed at, such as query:id or json:$.user.name
+type RawPoint struct {
+	Location string
+	Name     string
+}
+
+// String returns the point as it is given on the command line
+func (p RawPoint) String() string {
+	return p.Location + ":" + p.Name
+}
+
+// RawIndexEntry maps a written request file back to its collection item
+type RawIndexEntry struct {
+	File   string   `json:"file"`
+	Item   string   `json:"item"`
+	Folder []string `json:"folder,omitempty"`
+	Method string   `json:"method"`
+	URL    string   `json:"url"`
+	Point  string   `json:"point,omitempty"`
+}
+
+// ParseRawPoint parses a -raw-point value, returning each as true for "each"
+func ParseRawPoint(spec string) (point RawPoint, each bool, err error) {
+	if spec == "" {
+		return point, false, nil
+	}
+	if strings.EqualFold(spec, "each") {
+		return point, true, nil
+	}
+	location, name, found := strings.Cut(spec, ":")
+	location = strings.ToLower(strings.TrimSpace(location))
+	if !found || strings.TrimSpace(name) == "" {
+		return point, false, fmt.Errorf("point %q is not each or LOCATION:NAME", spec)
+	}
+	for _, known := range rawPointLocations {
+		if location == known {
+			return RawPoint{Location: location, Name: strings.TrimSpace(name)}, false, nil
+		}
+	}
+	return point, false, fmt.Errorf("point location %q is not one of %s", location, strings.Join(rawPointLocations, ", "))
+}
+
+// WriteRawRequests writes each request as a raw HTTP/1.1 request file for sqlmap -r, ffuf -request and similar tools
+//
+// Files are named by method and templated path, with {{variables}} resolved from the collection values. With a point
+// the marker is placed there and requests without that point are skipped; with each, one file is written per query
+// parameter, path variable, form field and JSON field of every request. A "*" marker is appended to the value, as
+// sqlmap expects, and any other marker such as FUZZ replaces it. An index.json maps every file to its item.
+func WriteRawRequests(dir string, collection PostmanCollection, point RawPoint, each bool, marker string) (int, []string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return 0, nil, fmt.Errorf("error creating raw request directory: %v", err)
+	}
+	variables := map[string]string{}
+	for _, variable := range collection.Variable {
+		if variable.Value != "" {
+			variables[variable.Key] = variable.Value
+		}
+	}
+
+	var index []RawIndexEntry
+	var skipped []string
+	missing := 0
+	used := map[string]bool{"index": true}
+	var writeErr error
+	WalkItems(collection.Item, func(folders []string, item *PostmanItem) {
+		if writeErr != nil {
+			return
+		}
+		points := []RawPoint{point}
+		switch {
+		case each:
+			points = RawPoints(item.Request)
+			if len(points) == 0 {
+				skipped = append(skipped, fmt.Sprintf("%s: no parameters to mark", item.Name))
+				return
+			}
+		case point.Location == "":
+			points = []RawPoint{{}}
+		}
+
+		base := rawFileName(item.Request)
+		for _, p := range points {
+			req := item.Request
+			name := base
+			if p.Location != "" {
+				marked, ok := MarkRequest(item.Request, p, marker, variables)
+				if !ok {
+					missing++
+					continue
+				}
+				req = marked
+				name = SafeFileName(fmt.Sprintf("%s_%s-%s", base, p.Location, strings.TrimPrefix(strings.TrimPrefix(p.Name, "$"), ".")))
+			}
+
+			raw, err := BuildRawRequest(req, variables)
+			if err != nil {
+				skipped = append(skipped, fmt.Sprintf("%s: %v", item.Name, err))
+				return
+			}
+			// Spaces are avoided so the files are easy to loop over in a shell
+			fileName := strings.ReplaceAll(uniqueFileName(strings.ReplaceAll(name, " ", "_"), used), " ", "_") + ".txt"
+			if err := os.WriteFile(filepath.Join(dir, fileName), raw.Data, 0644); err != nil {
+				writeErr = fmt.Errorf("error writing raw request %s: %v", fileName, err)
+				return
+			}
+			entry := RawIndexEntry{File: fileName, Item: item.Name, Folder: folders, Method: strings.ToUpper(req.Method), URL: raw.URL()}
+			if p.Location != "" {
+				entry.Point = p.String()
+			}
+			index = append(index, entry)
+		}
+	})
+	if missing > 0 && !each {
+		skipped = append(skipped, fmt.Sprintf("%d requests without %s", missing, point))
+	}
+	if writeErr != nil {
+		return len(index), skipped, writeErr
+	}
+
+	output, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return len(index), skipped, fmt.Errorf("error marshaling raw request index: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "index.json"), append(output, '\n'), 0644); err != nil {
+		return len(index), skipped, fmt.Errorf("error writing raw request index: %v", err)
+	}
+	return len(index), skipped, nil
+}
+
+// rawFileName names a request file by its method and path, with variables and identifiers as {name} segments
+func rawFileName(req PostmanRequest) string {
+	method := strings.ToUpper(req.Method)
+	if method == "" {
+		method = "GET"
+	}
+	segments := []string{method}
+	for _, segment := range req.URL.Path {
+		switch kind := ClassifySegment(segment); {
+		case segment == "":
+			continue
+		case strings.HasPrefix(segment, ":"):
+			segment = "{" + segment[1:] + "}"
+		case kind != "":
+			segment = "{" + kind + "}"
+		}
+		segments = append(segments, segment)
+	}
+	if len(segments) == 1 {
+		segments = append(segments, "root")
+	}
+	return SafeFileName(strings.Join(segments, "_"))
+}
+
+// RawPoints lists the query parameters, path variables, form fields and JSON fields of a request in order
+func RawPoints(req PostmanRequest) []RawPoint {
+	var points []RawPoint
+	seen := map[RawPoint]bool{}
+	add := func(p RawPoint) {
+		if !seen[p] {
+			seen[p] = true
+			points = append(points, p)
+		}
+	}
+	for _, param := range req.URL.Query {
+		add(RawPoint{Location: "query", Name: param.Key})
+	}
+	for _, variable := range req.URL.Variable {
+		add(RawPoint{Location: "path", Name: variable.Key})
+	}
+	for _, fields := range [][]PostmanFormParam{req.Body.Urlencoded, req.Body.Formdata} {
+		for _, field := range fields {
+			if !field.Disabled && field.Type != "file" {
+				add(RawPoint{Location: "form", Name: field.Key})
+			}
+		}
+	}
+	if req.Body.Raw != "" && len(req.Body.Formdata) == 0 && len(req.Body.Urlencoded) == 0 {
+		for _, leaf := range jsonLeaves(req.Body.Raw) {
+			add(RawPoint{Location: "json", Name: leaf.Path})
+		}
+	}
+	return points
+}
+
+// MarkRequest returns a copy of a request with the marker placed at a point, or false if the request has no such point
+func MarkRequest(req PostmanRequest, point RawPoint, marker string, variables map[string]string) (PostmanRequest, bool) {
+	mark := func(value string) string {
+		if marker == "*" {
+			return value + marker
+		}
+		return marker
+	}
+
+	switch point.Location {
+	case "query":
+		query := append([]PostmanQueryParam{}, req.URL.Query...)
+		for q := range query {
+			if queryKeyIs(query[q].Key, point.Name) {
+				query[q].Value = mark(query[q].Value)
+				req.URL.Query = query
+				req.URL.Raw = markRawQuery(req.URL.Raw, point.Name, mark)
+				return req, true
+			}
+		}
+	case "path":
+		vars := append([]PostmanVariable{}, req.URL.Variable...)
+		for v := range vars {
+			if vars[v].Key == point.Name {
+				vars[v].Value = mark(vars[v].Value)
+				req.URL.Variable = vars
+				return req, true
+			}
+		}
+	case "header", "cookie":
+		headers := append([]PostmanHeader{}, req.Header...)
+		for h, header := range headers {
+			if header.Disabled {
+				continue
+			}
+			if point.Location == "header" && strings.EqualFold(header.Key, point.Name) {
+				headers[h].Value = mark(header.Value)
+				req.Header = headers
+				return req, true
+			}
+			if point.Location == "cookie" && strings.EqualFold(header.Key, "Cookie") {
+				cookies := strings.Split(header.Value, ";")
+				for c, cookie := range cookies {
+					name, value, found := strings.Cut(cookie, "=")
+					if found && strings.TrimSpace(name) == point.Name {
+						cookies[c] = name + "=" + mark(value)
+						headers[h].Value = strings.Join(cookies, ";")
+						req.Header = headers
+						return req, true
+					}
+				}
+			}
+		}
+	case "form":
+		if fields := req.Body.Formdata; len(fields) > 0 {
+			fields = append([]PostmanFormParam{}, fields...)
+			for f := range fields {
+				if !fields[f].Disabled && fields[f].Type != "file" && fields[f].Key == point.Name {
+					fields[f].Value = mark(fields[f].Value)
+					req.Body.Formdata = fields
+					return req, true
+				}
+			}
+		}
+		// URL-encoded bodies are written out here so the marker is not percent-encoded with the value
+		var pairs []string
+		found := false
+		for _, field := range req.Body.Urlencoded {
+			if field.Disabled {
+				continue
+			}
+			value := url.QueryEscape(ResolveVariables(field.Value, variables))
+			if field.Key == point.Name && !found {
+				value, found = mark(value), true
+			}
+			pairs = append(pairs, url.QueryEscape(ResolveVariables(field.Key, variables))+"="+value)
+		}
+		if found {
+			req.Body = PostmanBody{Mode: "raw", Raw: strings.Join(pairs, "&")}
+			if !hasEnabledHeader(req.Header, "Content-Type") {
+				req.Header = append(append([]PostmanHeader{}, req.Header...), PostmanHeader{Key: "Content-Type", Value: "application/x-www-form-urlencoded"})
+			}
+			return req, true
+		}
+	case "json":
+		want := strings.Join(SplitJSONPath(point.Name), "\x00")
+		for _, leaf := range jsonLeaves(req.Body.Raw) {
+			if strings.Join(SplitJSONPath(leaf.Path), "\x00") != want {
+				continue
+			}
+			body := req.Body.Raw
+			switch {
+			case marker == "*":
+				// Inside the closing quote of strings, straight after other values
+				end := leaf.End
+				if leaf.String {
+					end--
+				}
+				req.Body.Raw = body[:end] + marker + body[end:]
+			case leaf.String:
+				req.Body.Raw = body[:leaf.Start] + `"` + marker + `"` + body[leaf.End:]
+			default:
+				req.Body.Raw = body[:leaf.Start] + marker + body[leaf.End:]
+			}
+			return req, true
+		}
+	}
+	return req, false
+}
+
+// markRawQuery marks the first parameter called name in the query string of a raw URL, leaving the rest of the
+// URL exactly as recorded. An empty string is returned when the parameter cannot be found, so the URL is rebuilt
+// from its parts instead.
+func markRawQuery(rawURL, name string, mark func(string) string) string {
+	base, fragment, hasFragment := strings.Cut(rawURL, "#")
+	path, query, found := strings.Cut(base, "?")
+	if !found {
+		return ""
+	}
+	pairs := strings.Split(query, "&")
+	for p, pair := range pairs {
+		key, value, _ := strings.Cut(pair, "=")
+		if !queryKeyIs(key, name) {
+			continue
+		}
+		pairs[p] = key + "=" + mark(value)
+		marked := path + "?" + strings.Join(pairs, "&")
+		if hasFragment {
+			marked += "#" + fragment
+		}
+		return marked
+	}
+	return ""
+}
+
+// queryKeyIs reports whether a query parameter key, as recorded or percent-decoded, is name
+func queryKeyIs(key, name string) bool {
+	if key == name {
+		return true
+	}
+	decoded, err := url.QueryUnescape(key)
+	return err == nil && decoded == name
+}
+
+// hasEnabledHeader reports whether a request sends a header
+func hasEnabledHeader(headers []PostmanHeader, key string) bool {
+	for _, header := range headers {
+		if !header.Disabled && strings.EqualFold(header.Key, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonLeaf is a scalar value in a JSON body with its path and byte offsets
+type jsonLeaf struct {
+	Path       string
+	Start, End int
+	String     bool
+}
+
+// jsonLeaves lists the scalar values of a JSON body in document order, with paths such as $.items[0].id
+//
+// Offsets are taken from the decoder so a marker can be placed without re-encoding, and the rest of the body
+// is kept byte for byte. Bodies that are not JSON have no leaves.
+func jsonLeaves(body string) []jsonLeaf {
+	type frame struct {
+		object    bool
+		path      string
+		key       string
+		index     int
+		expectKey bool
+	}
+	decoder := json.NewDecoder(strings.NewReader(body))
+	decoder.UseNumber()
+	var stack []*frame
+	var leaves []jsonLeaf
+
+	childPath := func() string {
+		if len(stack) == 0 {
+			return "$"
+		}
+		top := stack[len(stack)-1]
+		if top.object {
+			return top.path + "." + top.key
+		}
+		return fmt.Sprintf("%s[%d]", top.path, top.index)
+	}
+	valueDone := func() {
+		if len(stack) == 0 {
+			return
+		}
+		top := stack[len(stack)-1]
+		if top.object {
+			top.expectKey = true
+		} else {
+			top.index++
+		}
+	}
+
+	for {
+		start := int(decoder.InputOffset())
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil
+		}
+		end := int(decoder.InputOffset())
+		for start < end && strings.ContainsRune(" \t\r\n:,", rune(body[start])) {
+			start++
+		}
+
+		switch t := token.(type) {
+		case json.Delim:
+			switch t {
+			case '{', '[':
+				stack = append(stack, &frame{object: t == '{', path: childPath(), expectKey: t == '{'})
+			default:
+				stack = stack[:len(stack)-1]
+				valueDone()
+			}
+		default:
+			if len(stack) > 0 && stack[len(stack)-1].object && stack[len(stack)-1].expectKey {
+				stack[len(stack)-1].key, _ = t.(string)
+				stack[len(stack)-1].expectKey = false
+				continue
+			}
+			_, isString := t.(string)
+			leaves = append(leaves, jsonLeaf{Path: childPath(), Start: start, End: end, String: isString})
+			valueDone()
+		}
+	}
+	if len(stack) > 0 {
+		return nil
+	}
+	return leaves
+}
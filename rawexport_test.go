@@ -0,0 +1,117 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestJSONLeaves(t *testing.T) {
+	body := "{ \"user\" : {\"name\":\"bob\" ,\n\t\"tags\": [ \"a\",  {\"id\" : 7} ]}, \"ok\":true,\"none\":null }"
+	var got []string
+	for _, leaf := range jsonLeaves(body) {
+		kind := "other"
+		if leaf.String {
+			kind = "string"
+		}
+		got = append(got, leaf.Path+" "+body[leaf.Start:leaf.End]+" "+kind)
+	}
+	want := []string{
+		`$.user.name "bob" string`,
+		`$.user.tags[0] "a" string`,
+		`$.user.tags[1].id 7 other`,
+		`$.ok true other`,
+		`$.none null other`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("leaves = %q, want %q", got, want)
+	}
+
+	for _, invalid := range []string{"", "name=bob", `{"a":1`, `{"a":1}}`} {
+		if leaves := jsonLeaves(invalid); leaves != nil {
+			t.Errorf("jsonLeaves(%q) = %+v, want none", invalid, leaves)
+		}
+	}
+}
+
+func TestMarkRequest(t *testing.T) {
+	body := `{"user": {"name": "bob", "ids": [1, 22]},  "admin":false}`
+	jsonRequest := PostmanRequest{Method: "POST", URL: URLFromString("https://api.example.com/users"), Body: PostmanBody{Mode: "raw", Raw: body}}
+	queryRequest := PostmanRequest{Method: "GET", URL: URLFromString("https://api.example.com/search?q=shoes&user%5Bid%5D=5&page=2")}
+
+	tests := []struct {
+		name   string
+		req    PostmanRequest
+		point  RawPoint
+		marker string
+		want   string
+	}{
+		{"json string with *", jsonRequest, RawPoint{"json", "$.user.name"}, "*", `{"user": {"name": "bob*", "ids": [1, 22]},  "admin":false}`},
+		{"json string with FUZZ", jsonRequest, RawPoint{"json", "$.user.name"}, "FUZZ", `{"user": {"name": "FUZZ", "ids": [1, 22]},  "admin":false}`},
+		{"json array number with *", jsonRequest, RawPoint{"json", "$.user.ids[1]"}, "*", `{"user": {"name": "bob", "ids": [1, 22*]},  "admin":false}`},
+		{"json array number with FUZZ", jsonRequest, RawPoint{"json", "user.ids[0]"}, "FUZZ", `{"user": {"name": "bob", "ids": [FUZZ, 22]},  "admin":false}`},
+		{"json boolean after a comma and spaces", jsonRequest, RawPoint{"json", "$.admin"}, "FUZZ", `{"user": {"name": "bob", "ids": [1, 22]},  "admin":FUZZ}`},
+		{"query with *", queryRequest, RawPoint{"query", "q"}, "*", "https://api.example.com/search?q=shoes*&user%5Bid%5D=5&page=2"},
+		{"percent-encoded query key with FUZZ", queryRequest, RawPoint{"query", "user[id]"}, "FUZZ", "https://api.example.com/search?q=shoes&user%5Bid%5D=FUZZ&page=2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			marked, ok := MarkRequest(tt.req, tt.point, tt.marker, nil)
+			if !ok {
+				t.Fatalf("MarkRequest found no %s", tt.point)
+			}
+			got := marked.Body.Raw
+			if tt.point.Location == "query" {
+				got = marked.URL.Raw
+			}
+			if got != tt.want {
+				t.Errorf("marked = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	if _, ok := MarkRequest(jsonRequest, RawPoint{"json", "$.user"}, "*", nil); ok {
+		t.Errorf("MarkRequest marked an object, want only scalar leaves")
+	}
+	if queryRequest.URL.Query[0].Value != "shoes" {
+		t.Errorf("MarkRequest changed the original request")
+	}
+}
+
+func TestMarkRawQuery(t *testing.T) {
+	mark := func(value string) string { return value + "*" }
+	tests := []struct {
+		rawURL, name, want string
+	}{
+		{"https://a/p?a%20b=1&c=2", "a b", "https://a/p?a%20b=1*&c=2"},
+		{"https://a/p?q=%41&flag", "flag", "https://a/p?q=%41&flag=*"},
+		{"https://a/p?x=1", "y", ""},
+		{"https://a/p#q=1", "q", ""},
+	}
+	for _, tt := range tests {
+		if got := markRawQuery(tt.rawURL, tt.name, mark); got != tt.want {
+			t.Errorf("markRawQuery(%q, %q) = %q, want %q", tt.rawURL, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParseRawPoint(t *testing.T) {
+	tests := []struct {
+		spec    string
+		point   RawPoint
+		each    bool
+		wantErr bool
+	}{
+		{spec: ""},
+		{spec: "EACH", each: true},
+		{spec: "Query: id ", point: RawPoint{"query", "id"}},
+		{spec: "json:$.user.name", point: RawPoint{"json", "$.user.name"}},
+		{spec: "body:id", wantErr: true},
+		{spec: "query:", wantErr: true},
+	}
+	for _, tt := range tests {
+		point, each, err := ParseRawPoint(tt.spec)
+		if (err != nil) != tt.wantErr || point != tt.point || each != tt.each {
+			t.Errorf("ParseRawPoint(%q) = %+v, %v, %v", tt.spec, point, each, err)
+		}
+	}
+}
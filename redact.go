@@ -0,0 +1,287 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+/*
+	###################################### REDACTING SECRETS ###########################################################
+*/
+
+// apiKeyHeaders lists header names known to carry API keys and session tokens
+var apiKeyHeaders = map[string]bool{
+	"x-api-key": true, "api-key": true, "apikey": true, "x-apikey": true, "x-auth-token": true, "x-access-token": true,
+	"x-session-token": true, "x-csrf-token": true, "x-xsrf-token": true, "x-goog-api-key": true, "private-token": true,
+	"ocp-apim-subscription-key": true, "x-amz-security-token": true, "x-functions-key": true, "authentication": true,
+	"proxy-authorization": true, "x-shopify-access-token": true, "x-vault-token": true,
+}
+
+var (
+	// secretFieldRegex matches query, form and JSON field names that hold passwords, keys or tokens
+	secretFieldRegex = regexp.MustCompile(`(?i)^(pass(word)?|passwd|pwd|passphrase|secret|client_?secret|.*_secret|api_?key|access_?token|refresh_?token|id_?token|auth_?token|token|private_?key|aws_?secret_?access_?key|secret_?access_?key|otp|pin|cvv|card_?number)$`)
+	jwtRegex         = regexp.MustCompile(`eyJ[A-Za-z0-9_-]{5,}\.eyJ[A-Za-z0-9_-]{5,}\.[A-Za-z0-9_-]*`)
+	awsKeyRegex      = regexp.MustCompile(`\b(AKIA|ASIA)[0-9A-Z]{16}\b`)
+)
+
+// minGlobalSecretLength is the shortest secret replaced wherever it appears; shorter ones are only replaced in their own field
+const minGlobalSecretLength = 8
+
+// Redactor replaces secrets with {{variables}} and remembers the value behind each variable
+type Redactor struct {
+	names  map[string]string
+	used   map[string]bool
+	Values []PostmanVariable
+}
+
+// NewRedactor returns a Redactor with no secrets recorded
+func NewRedactor() *Redactor {
+	return &Redactor{names: map[string]string{}, used: map[string]bool{}}
+}
+
+// RedactItems replaces secrets in headers, query, cookies, bodies, auth blocks and saved examples with {{variables}}
+func (r *Redactor) RedactItems(items []PostmanItem) {
+	WalkItems(items, func(_ []string, item *PostmanItem) {
+		r.redactRequest(&item.Request)
+		for j := range item.Response {
+			response := &item.Response[j]
+			if response.OriginalRequest != nil {
+				r.redactRequest(response.OriginalRequest)
+			}
+			r.redactResponse(response)
+		}
+	})
+
+	// Replace long secrets wherever else they appear, longest first so tokens containing others win
+	var secrets []string
+	for value := range r.names {
+		if len(value) >= minGlobalSecretLength {
+			secrets = append(secrets, value)
+		}
+	}
+	sort.Slice(secrets, func(i, j int) bool {
+		return len(secrets[i]) > len(secrets[j])
+	})
+	replaceAll := func(s string) string {
+		for _, value := range secrets {
+			s = replaceBounded(s, value, "{{"+r.names[value]+"}}")
+		}
+		return s
+	}
+
+	WalkItems(items, func(_ []string, item *PostmanItem) {
+		RewriteRequestStrings(&item.Request, replaceAll)
+		for j := range item.Response {
+			response := &item.Response[j]
+			if response.OriginalRequest != nil {
+				RewriteRequestStrings(response.OriginalRequest, replaceAll)
+			}
+			for k := range response.Header {
+				response.Header[k].Value = replaceAll(response.Header[k].Value)
+			}
+			response.Body = replaceAll(response.Body)
+		}
+	})
+}
+
+// Variable returns the {{variable}} reference for a secret, naming it after the field it was found in
+func (r *Redactor) Variable(base, value string) string {
+	if name, ok := r.names[value]; ok {
+		return "{{" + name + "}}"
+	}
+
+	name := uniqueVariableName(base, r.used)
+	r.names[value] = name
+	r.Values = append(r.Values, PostmanVariable{Key: name, Value: value, Type: "string"})
+	return "{{" + name + "}}"
+}
+
+// redactRequest replaces secrets in the structured parts of a request
+func (r *Redactor) redactRequest(req *PostmanRequest) {
+	for i := range req.Header {
+		req.Header[i].Value = r.redactHeader(req.Header[i].Key, req.Header[i].Value)
+	}
+
+	queryChanged := false
+	for i := range req.URL.Query {
+		param := &req.URL.Query[i]
+		if secretFieldRegex.MatchString(param.Key) && param.Value != "" && !strings.Contains(param.Value, "{{") {
+			param.Value = r.Variable(param.Key, param.Value)
+			queryChanged = true
+		}
+	}
+	if queryChanged {
+		req.URL.Raw = BuildRawURL(req.URL)
+	}
+
+	req.Body.Raw = r.redactBody(req.Body.Raw)
+	for _, fields := range [][]PostmanFormParam{req.Body.Urlencoded, req.Body.Formdata} {
+		for i := range fields {
+			field := &fields[i]
+			if field.Type != "file" && secretFieldRegex.MatchString(field.Key) && field.Value != "" && !strings.Contains(field.Value, "{{") {
+				field.Value = r.Variable(field.Key, field.Value)
+			}
+		}
+	}
+
+	if req.Auth != nil {
+		for i := range req.Auth.Bearer {
+			if req.Auth.Bearer[i].Key == "token" && !strings.Contains(req.Auth.Bearer[i].Value, "{{") {
+				req.Auth.Bearer[i].Value = r.Variable("bearerToken", req.Auth.Bearer[i].Value)
+			}
+		}
+		for i := range req.Auth.Basic {
+			if req.Auth.Basic[i].Key == "password" && !strings.Contains(req.Auth.Basic[i].Value, "{{") {
+				req.Auth.Basic[i].Value = r.Variable("password", req.Auth.Basic[i].Value)
+			}
+		}
+	}
+}
+
+// redactHeader replaces the secret parts of a request header value
+func (r *Redactor) redactHeader(key, value string) string {
+	name := strings.ToLower(key)
+	switch {
+	case name == "authorization":
+		scheme, credentials, found := strings.Cut(value, " ")
+		if !found {
+			return r.Variable("authorization", value)
+		}
+		if strings.Contains(credentials, "{{") {
+			return value
+		}
+		switch strings.ToLower(scheme) {
+		case "bearer":
+			return scheme + " " + r.Variable("bearerToken", credentials)
+		case "basic":
+			return scheme + " " + r.Variable("basicAuth", credentials)
+		}
+		return scheme + " " + r.Variable(scheme+"Credentials", credentials)
+
+	case name == "cookie":
+		cookies := strings.Split(value, ";")
+		for i, cookie := range cookies {
+			cookieName, cookieValue, found := strings.Cut(cookie, "=")
+			if !found || cookieValue == "" || strings.Contains(cookieValue, "{{") {
+				continue
+			}
+			cookies[i] = cookieName + "=" + r.Variable("cookie_"+strings.TrimSpace(cookieName), cookieValue)
+		}
+		return strings.Join(cookies, ";")
+
+	case apiKeyHeaders[name]:
+		if strings.Contains(value, "{{") {
+			return value
+		}
+		return r.Variable(key, value)
+	}
+
+	return r.redactPatterns(value)
+}
+
+// redactResponse replaces Set-Cookie values and secret fields in a saved example
+func (r *Redactor) redactResponse(response *PostmanResponse) {
+	for i := range response.Header {
+		header := &response.Header[i]
+		if !strings.EqualFold(header.Key, "Set-Cookie") {
+			header.Value = r.redactPatterns(header.Value)
+			continue
+		}
+		pair, attributes, _ := strings.Cut(header.Value, ";")
+		cookieName, cookieValue, found := strings.Cut(pair, "=")
+		if found && cookieValue != "" && !strings.Contains(cookieValue, "{{") {
+			header.Value = cookieName + "=" + r.Variable("cookie_"+strings.TrimSpace(cookieName), cookieValue)
+			if attributes != "" {
+				header.Value += ";" + attributes
+			}
+		}
+	}
+	response.Body = r.redactBody(response.Body)
+}
+
+// redactBody replaces password-like fields in JSON and form bodies, and JWTs and AWS keys anywhere
+func (r *Redactor) redactBody(body string) string {
+	if strings.TrimSpace(body) == "" {
+		return body
+	}
+
+	var document interface{}
+	decoder := json.NewDecoder(strings.NewReader(body))
+	decoder.UseNumber()
+	if decoder.Decode(&document) == nil && !decoder.More() {
+		r.redactJSONFields(document, &body)
+	} else if !strings.ContainsAny(body, "\r\n<{ ") && strings.Contains(body, "=") {
+		pairs := strings.Split(body, "&")
+		for i, pair := range pairs {
+			key, value, found := strings.Cut(pair, "=")
+			if found && value != "" && secretFieldRegex.MatchString(key) && !strings.Contains(value, "{{") {
+				pairs[i] = key + "=" + r.Variable(key, value)
+			}
+		}
+		body = strings.Join(pairs, "&")
+	}
+
+	return r.redactPatterns(body)
+}
+
+// redactJSONFields rewrites the values of secret keys in the JSON text of body, keeping its formatting
+func (r *Redactor) redactJSONFields(value interface{}, body *string) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			child := v[key]
+			if !secretFieldRegex.MatchString(key) {
+				r.redactJSONFields(child, body)
+				continue
+			}
+
+			var literal string
+			switch c := child.(type) {
+			case string:
+				if c == "" || strings.Contains(c, "{{") {
+					continue
+				}
+				encoded, _ := json.Marshal(c)
+				literal = string(encoded)
+				variable := r.Variable(key, c)
+				*body = replaceJSONField(*body, key, literal, `"`+variable+`"`)
+			case json.Number, bool:
+				// The variable is quoted so the body stays valid JSON, sending the value as a string
+				literal = fmt.Sprint(c)
+				variable := r.Variable(key, literal)
+				*body = replaceJSONField(*body, key, literal, `"`+variable+`"`)
+			}
+		}
+	case []interface{}:
+		for _, element := range v {
+			r.redactJSONFields(element, body)
+		}
+	}
+}
+
+// replaceJSONField replaces the literal value of a key in JSON text
+func replaceJSONField(body, key, literal, replacement string) string {
+	encodedKey, _ := json.Marshal(key)
+	pattern := regexp.MustCompile(`(` + regexp.QuoteMeta(string(encodedKey)) + `\s*:\s*)` + regexp.QuoteMeta(literal))
+	return pattern.ReplaceAllStringFunc(body, func(match string) string {
+		return pattern.FindStringSubmatch(match)[1] + replacement
+	})
+}
+
+// redactPatterns replaces JWTs and AWS access key IDs found anywhere in a string
+func (r *Redactor) redactPatterns(s string) string {
+	s = jwtRegex.ReplaceAllStringFunc(s, func(token string) string {
+		return r.Variable("jwt", token)
+	})
+	return awsKeyRegex.ReplaceAllStringFunc(s, func(key string) string {
+		return r.Variable("awsAccessKeyId", key)
+	})
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestRedactItems(t *testing.T) {
+	items := []PostmanItem{
+		{Name: "POST login", Request: PostmanRequest{
+			Method: "POST",
+			Header: []PostmanHeader{
+				{Key: "Authorization", Value: "Bearer tok_1234567890abcdef"},
+				{Key: "Cookie", Value: "sid=abcdef123456; theme=dark"},
+				{Key: "X-Api-Key", Value: "key-0987654321"},
+			},
+			Body: PostmanBody{Mode: "raw", Raw: `{"user":"bob","password":"hunter22","pin":4321,"otp":true}`},
+			URL:  URLFromString("https://api.example.com/login?access_token=qwertyuiop"),
+		}},
+		{Name: "GET me", Request: PostmanRequest{
+			Method: "GET",
+			Header: []PostmanHeader{{Key: "Authorization", Value: "rawtoken99"}},
+			URL:    URLFromString("https://api.example.com/me"),
+		}},
+	}
+
+	r := NewRedactor()
+	r.RedactItems(items)
+
+	login := items[0].Request
+	var headers []string
+	for _, header := range login.Header {
+		headers = append(headers, header.Key+": "+header.Value)
+	}
+	want := []string{"Authorization: Bearer {{bearerToken}}", "Cookie: sid={{cookieSid}}; theme={{cookieTheme}}", "X-Api-Key: {{xApiKey}}"}
+	if !reflect.DeepEqual(headers, want) {
+		t.Errorf("headers = %q, want %q", headers, want)
+	}
+	if login.URL.Query[0].Value != "{{accessToken}}" {
+		t.Errorf("query = %+v, want the access token redacted", login.URL.Query)
+	}
+
+	// Numbers and booleans are replaced by quoted variables so the body stays valid JSON
+	var body map[string]interface{}
+	if err := json.Unmarshal([]byte(login.Body.Raw), &body); err != nil {
+		t.Fatalf("redacted body %s is not JSON: %v", login.Body.Raw, err)
+	}
+	if body["user"] != "bob" || body["password"] != "{{password}}" || body["pin"] != "{{pin}}" || body["otp"] != "{{otp}}" {
+		t.Errorf("body = %v, want the secret fields redacted", body)
+	}
+
+	if value := items[1].Request.Header[0].Value; value != "{{authorization}}" {
+		t.Errorf("bare Authorization = %q, want {{authorization}}", value)
+	}
+	for _, variable := range r.Values {
+		if variable.Type != "string" || variable.Value == "" {
+			t.Errorf("variable %+v, want a string holding the secret", variable)
+		}
+	}
+}
+
+func TestRedactedBareAuthorizationIsAnAPIKeyScheme(t *testing.T) {
+	items := []PostmanItem{{Name: "GET me", Request: PostmanRequest{
+		Method: "GET",
+		Header: []PostmanHeader{{Key: "Authorization", Value: "rawtoken99"}},
+		URL:    URLFromString("https://api.example.com/me"),
+	}}}
+	NewRedactor().RedactItems(items)
+
+	doc := ExportOpenAPI(PostmanCollection{Item: items})
+	want := map[string]OpenAPISecurityScheme{"authorization": {Type: "apiKey", Name: "authorization", In: "header"}}
+	if !reflect.DeepEqual(doc.Components.SecuritySchemes, want) {
+		t.Errorf("security schemes = %+v, want %+v", doc.Components.SecuritySchemes, want)
+	}
+}
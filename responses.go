@@ -0,0 +1,207 @@
+package main
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+)
+
+/*
+	################################### BURP RESPONSES AS SAVED EXAMPLES ###############################################
+*/
+
+// RawHTTPResponse represents an HTTP response decoded from a Burp item
+type RawHTTPResponse struct {
+	Proto      string
+	StatusCode int
+	Reason     string
+	Headers    []HTTPHeader
+	Body       []byte
+}
+
+// Get returns the value of the first header with the given name, ignoring case
+func (r *RawHTTPResponse) Get(name string) string {
+	return headerValue(r.Headers, name)
+}
+
+// ParseRawHTTPResponse parses a raw HTTP response, removing chunked framing and any content encoding from the body
+func ParseRawHTTPResponse(data []byte) (*RawHTTPResponse, error) {
+	resp := &RawHTTPResponse{}
+
+	startLine, _, headers, bodyOffset, err := parseHTTPHead(data)
+	if err != nil {
+		return nil, err
+	}
+	resp.Headers = headers
+
+	// Status line: HTTP-version SP status-code [SP reason-phrase]
+	parts := strings.SplitN(strings.TrimSpace(startLine), " ", 3)
+	if len(parts) < 2 || !strings.HasPrefix(parts[0], "HTTP/") {
+		return nil, &HTTPParseError{Offset: skipLeadingNewlines(data), Msg: fmt.Sprintf("invalid status line %q", startLine)}
+	}
+	code, err := strconv.Atoi(parts[1])
+	if err != nil || code < 100 || code > 999 {
+		return nil, &HTTPParseError{Offset: skipLeadingNewlines(data) + len(parts[0]) + 1, Msg: fmt.Sprintf("invalid status code %q", parts[1])}
+	}
+	resp.Proto = parts[0]
+	resp.StatusCode = code
+	if len(parts) == 3 {
+		resp.Reason = strings.TrimSpace(parts[2])
+	}
+	if resp.Reason == "" {
+		resp.Reason = http.StatusText(code)
+	}
+
+	body, err := frameHTTPBody(data, bodyOffset, headers, true)
+	if err != nil {
+		return nil, err
+	}
+
+	decoded, err := DecodeContentEncoding(body, resp.Get("Content-Encoding"))
+	if err != nil {
+		return nil, fmt.Errorf("error decoding response body: %v", err)
+	}
+	resp.Body = decoded
+
+	return resp, nil
+}
+
+// DecodeContentEncoding removes gzip, deflate and brotli content codings, last applied first
+func DecodeContentEncoding(body []byte, contentEncoding string) ([]byte, error) {
+	if len(body) == 0 || contentEncoding == "" {
+		return body, nil
+	}
+
+	codings := strings.Split(contentEncoding, ",")
+	for i := len(codings) - 1; i >= 0; i-- {
+		var reader io.Reader
+		switch strings.ToLower(strings.TrimSpace(codings[i])) {
+		case "gzip", "x-gzip":
+			gz, err := gzip.NewReader(bytes.NewReader(body))
+			if err != nil {
+				return nil, fmt.Errorf("gzip: %v", err)
+			}
+			reader = gz
+		case "deflate":
+			// Servers send deflate both with and without the zlib wrapper
+			if zr, err := zlib.NewReader(bytes.NewReader(body)); err == nil {
+				reader = zr
+			} else {
+				reader = flate.NewReader(bytes.NewReader(body))
+			}
+		case "br":
+			reader = brotli.NewReader(bytes.NewReader(body))
+		case "identity", "":
+			continue
+		default:
+			return nil, fmt.Errorf("unsupported content encoding %q", codings[i])
+		}
+
+		decoded, err := io.ReadAll(reader)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", strings.TrimSpace(codings[i]), err)
+		}
+		body = decoded
+	}
+
+	return body, nil
+}
+
+// BuildBurpResponse converts the response recorded in a Burp item into a Postman saved example
+func BuildBurpResponse(burp BurpItem, originalRequest PostmanRequest) (*PostmanResponse, error) {
+	data := []byte(burp.Response.Content)
+	if burp.Response.Base64 == "true" {
+		decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(burp.Response.Content))
+		if err != nil {
+			return nil, fmt.Errorf("could not decode base64 response: %v", err)
+		}
+		data = decoded
+	}
+
+	rawResp, err := ParseRawHTTPResponse(data)
+	if err != nil {
+		return nil, err
+	}
+
+	request := CloneRequest(originalRequest)
+	response := &PostmanResponse{
+		Name:            fmt.Sprintf("%d %s", rawResp.StatusCode, rawResp.Reason),
+		OriginalRequest: &request,
+		Status:          rawResp.Reason,
+		Code:            rawResp.StatusCode,
+		PreviewLanguage: BodyLanguage(rawResp.Get("Content-Type")),
+		Header:          []PostmanHeader{},
+		Body:            string(rawResp.Body),
+	}
+	// The body is saved unchunked and decoded, so the framing headers are made to match it
+	hasLength, chunked := false, false
+	for _, header := range rawResp.Headers {
+		value := header.Value
+		switch strings.ToLower(header.Name) {
+		case "content-encoding":
+			continue
+		case "transfer-encoding":
+			chunked = true
+			continue
+		case "content-length":
+			if hasLength {
+				continue
+			}
+			hasLength, value = true, strconv.Itoa(len(rawResp.Body))
+		}
+		response.Header = append(response.Header, PostmanHeader{
+			Key:   header.Name,
+			Value: value,
+			Type:  "text",
+		})
+	}
+	if chunked && !hasLength {
+		response.Header = append(response.Header, PostmanHeader{Key: "Content-Length", Value: strconv.Itoa(len(rawResp.Body)), Type: "text"})
+	}
+
+	return response, nil
+}
+
+// BodyLanguage returns the Postman body language for a Content-Type
+func BodyLanguage(contentType string) string {
+	contentType = strings.ToLower(contentType)
+	switch {
+	case strings.Contains(contentType, "json"):
+		return "json"
+	case strings.Contains(contentType, "xml"):
+		return "xml"
+	case strings.Contains(contentType, "javascript"):
+		return "javascript"
+	case strings.Contains(contentType, "html"):
+		return "html"
+	}
+	return "text"
+}
+
+// CloneRequest returns a deep copy of a request so examples do not share slices with the item
+func CloneRequest(req PostmanRequest) PostmanRequest {
+	clone := req
+	clone.Header = append([]PostmanHeader{}, req.Header...)
+	clone.URL.Host = append([]string{}, req.URL.Host...)
+	clone.URL.Path = append([]string{}, req.URL.Path...)
+	clone.URL.Query = append([]PostmanQueryParam(nil), req.URL.Query...)
+	clone.URL.Variable = append([]PostmanVariable(nil), req.URL.Variable...)
+	clone.Body.Urlencoded = append([]PostmanFormParam(nil), req.Body.Urlencoded...)
+	clone.Body.Formdata = append([]PostmanFormParam(nil), req.Body.Formdata...)
+	if req.Auth != nil {
+		auth := *req.Auth
+		auth.Bearer = append([]PostmanAuthDetail(nil), req.Auth.Bearer...)
+		auth.Basic = append([]PostmanAuthDetail(nil), req.Auth.Basic...)
+		clone.Auth = &auth
+	}
+	return clone
+}
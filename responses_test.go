@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"testing"
+)
+
+func TestBuildBurpResponse(t *testing.T) {
+	var gzipped bytes.Buffer
+	gz := gzip.NewWriter(&gzipped)
+	gz.Write([]byte(`{"ok":true}`))
+	gz.Close()
+
+	tests := []struct {
+		name     string
+		raw      string
+		code     int
+		status   string
+		language string
+		headers  [][2]string
+		body     string
+	}{
+		{
+			name:     "gzip body with its length",
+			raw:      "HTTP/1.1 200 OK\r\nContent-Type: application/json\r\nContent-Encoding: gzip\r\nContent-Length: " + strconv.Itoa(gzipped.Len()) + "\r\n\r\n" + gzipped.String(),
+			code:     200,
+			status:   "OK",
+			language: "json",
+			headers:  [][2]string{{"Content-Type", "application/json"}, {"Content-Length", "11"}},
+			body:     `{"ok":true}`,
+		},
+		{
+			name:     "chunked body",
+			raw:      "HTTP/1.1 404 Not Found\r\nContent-Type: text/html\r\nTransfer-Encoding: chunked\r\n\r\n4\r\n<p>x\r\n4\r\n</p>\r\n0\r\n\r\n",
+			code:     404,
+			status:   "Not Found",
+			language: "html",
+			headers:  [][2]string{{"Content-Type", "text/html"}, {"Content-Length", "8"}},
+			body:     "<p>x</p>",
+		},
+		{
+			name:     "missing reason phrase",
+			raw:      "HTTP/2 204\r\n\r\n",
+			code:     204,
+			status:   "No Content",
+			language: "text",
+		},
+	}
+	request := PostmanRequest{Method: "GET", URL: URLFromString("https://api.example.com/")}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			burp := BurpItem{Response: BurpResponseData{Base64: "true", Content: base64.StdEncoding.EncodeToString([]byte(tt.raw))}}
+			response, err := BuildBurpResponse(burp, request)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var headers [][2]string
+			for _, header := range response.Header {
+				headers = append(headers, [2]string{header.Key, header.Value})
+			}
+			if response.Code != tt.code || response.Status != tt.status || response.PreviewLanguage != tt.language {
+				t.Errorf("response = %d %q %s, want %d %q %s", response.Code, response.Status, response.PreviewLanguage, tt.code, tt.status, tt.language)
+			}
+			if fmt.Sprint(headers) != fmt.Sprint(tt.headers) {
+				t.Errorf("headers = %q, want %q", headers, tt.headers)
+			}
+			if response.Body != tt.body {
+				t.Errorf("body = %q, want %q", response.Body, tt.body)
+			}
+			if response.OriginalRequest == nil || response.OriginalRequest.URL.Raw != request.URL.Raw {
+				t.Errorf("original request = %+v, want a copy of the request", response.OriginalRequest)
+			}
+		})
+	}
+}
+
+func TestDecodeContentEncoding(t *testing.T) {
+	var zlibbed bytes.Buffer
+	zw := zlib.NewWriter(&zlibbed)
+	zw.Write([]byte("hello"))
+	zw.Close()
+
+	if got, err := DecodeContentEncoding(zlibbed.Bytes(), "identity, deflate"); err != nil || string(got) != "hello" {
+		t.Errorf("deflate = %q, %v, want hello", got, err)
+	}
+	if _, err := DecodeContentEncoding([]byte("x"), "compress"); err == nil {
+		t.Errorf("DecodeContentEncoding accepted an unsupported coding")
+	}
+	if got, err := DecodeContentEncoding(nil, "gzip"); err != nil || got != nil {
+		t.Errorf("empty body = %q, %v, want it unchanged", got, err)
+	}
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"strings"
+)
+
+/*
+	###################################### JSON SCHEMA INFERENCE #######################################################
+*/
+
+// JSONSchema represents the shape of a JSON value inferred from one or more recorded samples
+type JSONSchema struct {
+	Types            []string
+	Properties       map[string]*JSONSchema
+	Required         []string
+	Items            *JSONSchema
+	ContentMediaType string
+}
+
+// MarshalJSON writes the schema as a JSON Schema document, using a single type name where possible
+func (s *JSONSchema) MarshalJSON() ([]byte, error) {
+	doc := map[string]interface{}{}
+	if len(s.Types) == 1 {
+		doc["type"] = s.Types[0]
+	} else if len(s.Types) > 1 {
+		doc["type"] = s.Types
+	}
+	if s.Properties != nil {
+		doc["properties"] = s.Properties
+		if len(s.Required) > 0 {
+			doc["required"] = s.Required
+		}
+	}
+	if s.Items != nil {
+		doc["items"] = s.Items
+	}
+	if s.ContentMediaType != "" {
+		doc["contentMediaType"] = s.ContentMediaType
+	}
+	return json.Marshal(doc)
+}
+
+// HasType reports whether the schema allows the given JSON type
+func (s *JSONSchema) HasType(name string) bool {
+	for _, t := range s.Types {
+		if t == name {
+			return true
+		}
+	}
+	return false
+}
+
+// InferJSONSchemaFromBody parses a JSON document and infers its schema, returning nil if the body is not JSON
+func InferJSONSchemaFromBody(body string) *JSONSchema {
+	// decoder.More alone lets a stray closing bracket through, which would make the body an invalid example
+	data := []byte(strings.TrimSpace(body))
+	if !json.Valid(data) {
+		return nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil
+	}
+	return InferJSONSchema(value)
+}
+
+// InferJSONSchema infers the schema of a decoded JSON value; array item shapes are merged across all elements
+func InferJSONSchema(value interface{}) *JSONSchema {
+	switch v := value.(type) {
+	case nil:
+		return &JSONSchema{Types: []string{"null"}}
+	case bool:
+		return &JSONSchema{Types: []string{"boolean"}}
+	case json.Number:
+		if _, err := v.Int64(); err == nil {
+			return &JSONSchema{Types: []string{"integer"}}
+		}
+		return &JSONSchema{Types: []string{"number"}}
+	case float64:
+		if v == float64(int64(v)) {
+			return &JSONSchema{Types: []string{"integer"}}
+		}
+		return &JSONSchema{Types: []string{"number"}}
+	case string:
+		return &JSONSchema{Types: []string{"string"}}
+	case []interface{}:
+		schema := &JSONSchema{Types: []string{"array"}}
+		for _, element := range v {
+			schema.Items = MergeJSONSchema(schema.Items, InferJSONSchema(element))
+		}
+		return schema
+	case map[string]interface{}:
+		schema := &JSONSchema{Types: []string{"object"}, Properties: map[string]*JSONSchema{}}
+		for key, element := range v {
+			schema.Properties[key] = InferJSONSchema(element)
+			schema.Required = append(schema.Required, key)
+		}
+		sort.Strings(schema.Required)
+		return schema
+	}
+	return &JSONSchema{}
+}
+
+// MergeJSONSchema combines two schemas so that both samples validate; keys are only required if seen in both
+func MergeJSONSchema(a, b *JSONSchema) *JSONSchema {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+
+	merged := &JSONSchema{}
+	seen := map[string]bool{}
+	for _, t := range append(append([]string{}, a.Types...), b.Types...) {
+		if !seen[t] {
+			seen[t] = true
+			merged.Types = append(merged.Types, t)
+		}
+	}
+
+	// A number field that was sometimes whole is still a number
+	if seen["integer"] && seen["number"] {
+		var types []string
+		for _, t := range merged.Types {
+			if t != "integer" {
+				types = append(types, t)
+			}
+		}
+		merged.Types = types
+	}
+	sort.Strings(merged.Types)
+
+	if a.Properties != nil || b.Properties != nil {
+		merged.Properties = map[string]*JSONSchema{}
+		for key, schema := range a.Properties {
+			merged.Properties[key] = schema
+		}
+		for key, schema := range b.Properties {
+			merged.Properties[key] = MergeJSONSchema(merged.Properties[key], schema)
+		}
+
+		switch {
+		case a.Properties == nil:
+			merged.Required = b.Required
+		case b.Properties == nil:
+			merged.Required = a.Required
+		default:
+			inB := map[string]bool{}
+			for _, key := range b.Required {
+				inB[key] = true
+			}
+			for _, key := range a.Required {
+				if inB[key] {
+					merged.Required = append(merged.Required, key)
+				}
+			}
+		}
+	}
+
+	if a.Items != nil || b.Items != nil {
+		merged.Items = MergeJSONSchema(a.Items, b.Items)
+	}
+
+	// A field that was only sometimes an upload is an ordinary string
+	if a.ContentMediaType == b.ContentMediaType {
+		merged.ContentMediaType = a.ContentMediaType
+	}
+
+	return merged
+}
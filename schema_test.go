@@ -0,0 +1,53 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestInferJSONSchema(t *testing.T) {
+	tests := []struct {
+		name   string
+		bodies []string
+		want   string
+	}{
+		{
+			name:   "scalars and nesting",
+			bodies: []string{`{"id":1,"price":2.5,"tags":["a"],"owner":{"name":"bob"},"deleted":null,"ok":true}`},
+			want: `{"properties":{"deleted":{"type":"null"},"id":{"type":"integer"},"ok":{"type":"boolean"},` +
+				`"owner":{"properties":{"name":{"type":"string"}},"required":["name"],"type":"object"},` +
+				`"price":{"type":"number"},"tags":{"items":{"type":"string"},"type":"array"}},` +
+				`"required":["deleted","id","ok","owner","price","tags"],"type":"object"}`,
+		},
+		{
+			name:   "array elements are merged",
+			bodies: []string{`[{"a":1,"b":"x"},{"a":1.5},{"a":null}]`},
+			want:   `{"items":{"properties":{"a":{"type":["null","number"]},"b":{"type":"string"}},"required":["a"],"type":"object"},"type":"array"}`,
+		},
+		{
+			name:   "samples are merged",
+			bodies: []string{`{"a":1,"b":[]}`, `{"a":"x","b":[2]}`},
+			want:   `{"properties":{"a":{"type":["integer","string"]},"b":{"items":{"type":"integer"},"type":"array"}},"required":["a","b"],"type":"object"}`,
+		},
+		{
+			name:   "invalid JSON",
+			bodies: []string{`{"a":1}}`, "name=bob", ""},
+			want:   "null",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var schema *JSONSchema
+			for _, body := range tt.bodies {
+				schema = MergeJSONSchema(schema, InferJSONSchemaFromBody(body))
+			}
+			got, err := json.Marshal(schema)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("schema =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
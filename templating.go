@@ -0,0 +1,246 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+)
+
+/*
+	###################################### PATH TEMPLATING #############################################################
+*/
+
+var (
+	numericSegmentRegex = regexp.MustCompile(`^\d+$`)
+	uuidSegmentRegex    = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+	hashSegmentRegex    = regexp.MustCompile(`^[0-9a-fA-F]{16,}$`)
+	emailSegmentRegex   = regexp.MustCompile(`^[^@/\s]+@[^@/\s]+\.[A-Za-z]{2,}$`)
+	versionSegmentRegex = regexp.MustCompile(`^[vV]\d+(\.\d+)*$`)
+	digitRegex          = regexp.MustCompile(`\d`)
+)
+
+// ClassifySegment returns "id", "uuid", "hash", "email" or "date" for a path segment that holds a value, or "" for a literal
+func ClassifySegment(segment string) string {
+	switch {
+	case segment == "" || strings.HasPrefix(segment, ":") || strings.Contains(segment, "{{"):
+		return ""
+	case isDateSegment(segment):
+		return "date"
+	case numericSegmentRegex.MatchString(segment):
+		return "id"
+	case uuidSegmentRegex.MatchString(segment):
+		return "uuid"
+	case hashSegmentRegex.MatchString(segment) && digitRegex.MatchString(segment):
+		return "hash"
+	case emailSegmentRegex.MatchString(strings.ReplaceAll(segment, "%40", "@")):
+		return "email"
+	}
+	return ""
+}
+
+// isDateSegment reports whether a segment is a calendar date such as 2024-01-31 or 20240131
+func isDateSegment(segment string) bool {
+	for _, layout := range []string{"2006-01-02", "20060102"} {
+		if len(segment) == len(layout) {
+			if _, err := time.Parse(layout, segment); err == nil {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// TemplatePaths rewrites value-like path segments into :name Postman path variables, defaulting to the observed value
+//
+// Segments are templated when they look like IDs, UUIDs, hashes, emails or dates, or when they contain a
+// digit and vary across requests that are otherwise identical. It returns the number of requests changed.
+func TemplatePaths(items []PostmanItem) int {
+	varying := varyingSegments(items)
+
+	changed := 0
+	for i := range items {
+		u := &items[i].Request.URL
+		used := map[string]bool{}
+		for _, variable := range u.Variable {
+			used[variable.Key] = true
+		}
+
+		// Decide on the original segments before any of them are rewritten
+		original := *u
+		original.Path = append([]string{}, u.Path...)
+
+		templated := false
+		for p, segment := range original.Path {
+			kind := ClassifySegment(segment)
+			if kind == "" && varying[segmentKey(original, p)] {
+				kind = "id"
+			}
+			if kind == "" {
+				continue
+			}
+
+			name := segmentVariableName(original.Path, p, kind)
+			unique := name
+			for n := 2; used[unique]; n++ {
+				unique = fmt.Sprintf("%s%d", name, n)
+			}
+			used[unique] = true
+
+			u.Path[p] = ":" + unique
+			u.Variable = append(u.Variable, PostmanVariable{Key: unique, Value: segment})
+			templated = true
+		}
+
+		if templated {
+			u.Raw = BuildRawURL(*u)
+			changed++
+		}
+	}
+
+	return changed
+}
+
+// varyingSegments finds path positions that differ between requests whose other segments are identical
+func varyingSegments(items []PostmanItem) map[string]bool {
+	values := map[string]map[string]bool{}
+	for _, item := range items {
+		u := item.Request.URL
+		for p, segment := range u.Path {
+			if strings.HasPrefix(segment, ":") || strings.Contains(segment, "{{") || versionSegmentRegex.MatchString(segment) {
+				continue
+			}
+			key := segmentKey(u, p)
+			if values[key] == nil {
+				values[key] = map[string]bool{}
+			}
+			values[key][segment] = true
+		}
+	}
+
+	// Resource names such as users and orders also vary, so at least one value has to contain a digit
+	varying := map[string]bool{}
+	for key, seen := range values {
+		if len(seen) < 2 {
+			continue
+		}
+		for value := range seen {
+			if digitRegex.MatchString(value) {
+				varying[key] = true
+				break
+			}
+		}
+	}
+	return varying
+}
+
+// segmentKey identifies a path position by host and every other segment, with value-like segments generalised
+func segmentKey(u PostmanURL, position int) string {
+	parts := []string{strings.ToLower(strings.Join(u.Host, ".")), u.Port}
+	for p, segment := range u.Path {
+		switch {
+		case p == position:
+			parts = append(parts, "*")
+		case strings.HasPrefix(segment, ":") || ClassifySegment(segment) != "":
+			parts = append(parts, ":")
+		default:
+			parts = append(parts, segment)
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+// segmentVariableName names a path variable after the segment before it, e.g. users/48213 becomes userId
+func segmentVariableName(path []string, position int, kind string) string {
+	switch kind {
+	case "email":
+		return "email"
+	case "date":
+		return "date"
+	}
+
+	if position == 0 || strings.HasPrefix(path[position-1], ":") || ClassifySegment(path[position-1]) != "" {
+		return "id"
+	}
+	resource := camelCase(singular(path[position-1]))
+	if resource == "" {
+		return "id"
+	}
+	return resource + "Id"
+}
+
+// singular makes a best effort to turn a plural resource name into its singular form
+func singular(word string) string {
+	lower := strings.ToLower(word)
+	switch {
+	case strings.HasSuffix(lower, "ies") && len(word) > 3:
+		return word[:len(word)-3] + "y"
+	case strings.HasSuffix(lower, "sses"), strings.HasSuffix(lower, "xes"), strings.HasSuffix(lower, "ches"), strings.HasSuffix(lower, "shes"):
+		return word[:len(word)-2]
+	case strings.HasSuffix(lower, "ss"), strings.HasSuffix(lower, "us"):
+		return word
+	case strings.HasSuffix(lower, "s") && len(word) > 1:
+		return word[:len(word)-1]
+	}
+	return word
+}
+
+// camelCase joins the words of a segment such as line-items into lineItems
+func camelCase(word string) string {
+	var out strings.Builder
+	upperNext := false
+	for _, r := range word {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upperNext = out.Len() > 0
+			continue
+		}
+		if upperNext {
+			r = unicode.ToUpper(r)
+			upperNext = false
+		} else if out.Len() == 0 {
+			r = unicode.ToLower(r)
+		}
+		out.WriteRune(r)
+	}
+	return out.String()
+}
+
+// BuildRawURL rebuilds the raw form of a URL from its protocol, host, port, path and query
+func BuildRawURL(u PostmanURL) string {
+	raw := strings.Join(u.Host, ".")
+	if u.Protocol != "" {
+		raw = u.Protocol + "://" + JoinHostPort(raw, u.Port, u.Protocol)
+	} else if u.Port != "" {
+		raw += ":" + u.Port
+	}
+	if len(u.Path) > 0 {
+		raw += "/" + strings.Join(u.Path, "/")
+	}
+	if len(u.Query) > 0 {
+		queryStrings := []string{}
+		for _, param := range u.Query {
+			if param.Value != "" {
+				queryStrings = append(queryStrings, fmt.Sprintf("%s=%s", param.Key, param.Value))
+			} else {
+				queryStrings = append(queryStrings, param.Key)
+			}
+		}
+		raw += "?" + strings.Join(queryStrings, "&")
+	}
+	return raw
+}
+
+// TemplatedPath returns the method, host and path of a request with value-like segments generalised, for matching requests across captures
+func TemplatedPath(req PostmanRequest) string {
+	u := req.URL
+	segments := make([]string, len(u.Path))
+	for p, segment := range u.Path {
+		if strings.HasPrefix(segment, ":") || ClassifySegment(segment) != "" {
+			segment = "{}"
+		}
+		segments[p] = segment
+	}
+	host := strings.ToLower(JoinHostPort(strings.Join(u.Host, "."), u.Port, u.Protocol))
+	return strings.ToUpper(req.Method) + " " + host + "/" + strings.Join(segments, "/")
+}
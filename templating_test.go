@@ -0,0 +1,76 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestClassifySegment(t *testing.T) {
+	tests := map[string]string{
+		"48213":                                "id",
+		"2024-01-31":                           "date",
+		"20240131":                             "date",
+		"20241399":                             "id",
+		"3f2504e0-4f89-11d3-9a0c-0305e82c3301": "uuid",
+		"9f86d081884c7d65":                     "hash",
+		"deadbeefdeadbeef":                     "",
+		"bob%40example.com":                    "email",
+		"users":                                "",
+		"v2":                                   "",
+		":id":                                  "",
+		"{{userId}}":                           "",
+	}
+	for segment, want := range tests {
+		if got := ClassifySegment(segment); got != want {
+			t.Errorf("ClassifySegment(%q) = %q, want %q", segment, got, want)
+		}
+	}
+}
+
+func TestTemplatePaths(t *testing.T) {
+	items := []PostmanItem{
+		{Request: PostmanRequest{Method: "GET", URL: URLFromString("https://api.example.com/v1/users/48213/addresses/7?full=1")}},
+		{Request: PostmanRequest{Method: "GET", URL: URLFromString("https://api.example.com/v1/files/a1b2/download")}},
+		{Request: PostmanRequest{Method: "GET", URL: URLFromString("https://api.example.com/v1/files/c3d4/download")}},
+		{Request: PostmanRequest{Method: "GET", URL: URLFromString("https://api.example.com/v1/categories/2024-01-31")}},
+		{Request: PostmanRequest{Method: "GET", URL: URLFromString("https://api.example.com/v2/users")}},
+	}
+
+	if changed := TemplatePaths(items); changed != 4 {
+		t.Errorf("TemplatePaths changed %d requests, want 4", changed)
+	}
+	tests := []struct {
+		raw       string
+		variables []PostmanVariable
+	}{
+		{"https://api.example.com/v1/users/:userId/addresses/:addressId?full=1", []PostmanVariable{{Key: "userId", Value: "48213"}, {Key: "addressId", Value: "7"}}},
+		{"https://api.example.com/v1/files/:fileId/download", []PostmanVariable{{Key: "fileId", Value: "a1b2"}}},
+		{"https://api.example.com/v1/files/:fileId/download", []PostmanVariable{{Key: "fileId", Value: "c3d4"}}},
+		{"https://api.example.com/v1/categories/:date", []PostmanVariable{{Key: "date", Value: "2024-01-31"}}},
+		{"https://api.example.com/v2/users", nil},
+	}
+	for i, tt := range tests {
+		u := items[i].Request.URL
+		if u.Raw != tt.raw || !reflect.DeepEqual(u.Variable, tt.variables) {
+			t.Errorf("URL %d = %s %+v, want %s %+v", i, u.Raw, u.Variable, tt.raw, tt.variables)
+		}
+	}
+}
+
+func TestSegmentNames(t *testing.T) {
+	tests := map[string]string{
+		"users": "user", "categories": "category", "boxes": "box", "addresses": "address",
+		"status": "status", "class": "class", "data": "data",
+	}
+	for word, want := range tests {
+		if got := singular(word); got != want {
+			t.Errorf("singular(%q) = %q, want %q", word, got, want)
+		}
+	}
+	if got := segmentVariableName([]string{"line-items", "7"}, 1, "id"); got != "lineItemId" {
+		t.Errorf("segmentVariableName = %q, want lineItemId", got)
+	}
+	if got := TemplatedPath(PostmanRequest{Method: "delete", URL: URLFromString("https://API.example.com:8443/users/7/:id")}); got != "DELETE api.example.com:8443/users/{}/{}" {
+		t.Errorf("TemplatedPath = %q", got)
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"strings"
+)
+
+/*
+	################################# TEST SCRIPTS FROM RECORDED RESPONSES #############################################
+*/
+
+// AddResponseTests adds pm.test assertions to every item with a recorded response and returns how many were given tests
+func AddResponseTests(items []PostmanItem) int {
+	count := 0
+	for i := range items {
+		if len(items[i].Response) == 0 {
+			continue
+		}
+		AddTestScript(&items[i], BuildResponseTests(items[i].Response[0]))
+		count++
+	}
+	return count
+}
+
+// BuildResponseTests returns assertions on the status, Content-Type and JSON body shape of a recorded response
+func BuildResponseTests(response PostmanResponse) []string {
+	lines := []string{
+		fmt.Sprintf("pm.test(%q, function () {", fmt.Sprintf("Status code is %d", response.Code)),
+		fmt.Sprintf("    pm.response.to.have.status(%d);", response.Code),
+		"});",
+	}
+
+	contentType := ""
+	for _, header := range response.Header {
+		if strings.EqualFold(header.Key, "Content-Type") {
+			contentType = header.Value
+			break
+		}
+	}
+	if contentType == "" {
+		return lines
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = strings.TrimSpace(strings.SplitN(contentType, ";", 2)[0])
+	}
+	lines = append(lines,
+		fmt.Sprintf("pm.test(%q, function () {", fmt.Sprintf("Content-Type is %s", mediaType)),
+		fmt.Sprintf("    pm.expect(pm.response.headers.get(\"Content-Type\")).to.include(%q);", mediaType),
+		"});",
+	)
+
+	if !strings.Contains(mediaType, "json") {
+		return lines
+	}
+	schema := InferJSONSchemaFromBody(response.Body)
+	if schema == nil {
+		return lines
+	}
+	schemaJSON, err := json.MarshalIndent(schema, "", "    ")
+	if err != nil {
+		return lines
+	}
+
+	schemaLines := strings.Split(string(schemaJSON), "\n")
+	schemaLines[0] = "const schema = " + schemaLines[0]
+	schemaLines[len(schemaLines)-1] += ";"
+	lines = append(lines, schemaLines...)
+	lines = append(lines,
+		"pm.test(\"Response body matches the recorded JSON schema\", function () {",
+		"    pm.response.to.have.jsonSchema(schema);",
+		"});",
+	)
+
+	return lines
+}
+
+// AddTestScript appends lines to the item's test script, creating the test event if needed
+func AddTestScript(item *PostmanItem, lines []string) {
+	if len(lines) == 0 {
+		return
+	}
+	for i := range item.Event {
+		if item.Event[i].Listen == "test" {
+			item.Event[i].Script.Exec = append(item.Event[i].Script.Exec, lines...)
+			return
+		}
+	}
+	item.Event = append(item.Event, PostmanEvent{
+		Listen: "test",
+		Script: PostmanScript{
+			Type: "text/javascript",
+			Exec: lines,
+		},
+	})
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBuildResponseTests(t *testing.T) {
+	tests := []struct {
+		name     string
+		response PostmanResponse
+		want     string
+	}{
+		{
+			name:     "status only",
+			response: PostmanResponse{Code: 204},
+			want:     "pm.test(\"Status code is 204\", function () {\n    pm.response.to.have.status(204);\n});",
+		},
+		{
+			name:     "non-JSON Content-Type",
+			response: PostmanResponse{Code: 200, Header: []PostmanHeader{{Key: "content-type", Value: "text/html; charset=utf-8"}}, Body: "<p>"},
+			want: "pm.test(\"Status code is 200\", function () {\n    pm.response.to.have.status(200);\n});\n" +
+				"pm.test(\"Content-Type is text/html\", function () {\n    pm.expect(pm.response.headers.get(\"Content-Type\")).to.include(\"text/html\");\n});",
+		},
+		{
+			name:     "JSON body schema",
+			response: PostmanResponse{Code: 201, Header: []PostmanHeader{{Key: "Content-Type", Value: "application/problem+json"}}, Body: `{"id":7}`},
+			want: "pm.test(\"Status code is 201\", function () {\n    pm.response.to.have.status(201);\n});\n" +
+				"pm.test(\"Content-Type is application/problem+json\", function () {\n    pm.expect(pm.response.headers.get(\"Content-Type\")).to.include(\"application/problem+json\");\n});\n" +
+				"const schema = {\n    \"properties\": {\n        \"id\": {\n            \"type\": \"integer\"\n        }\n    },\n    \"required\": [\n        \"id\"\n    ],\n    \"type\": \"object\"\n};\n" +
+				"pm.test(\"Response body matches the recorded JSON schema\", function () {\n    pm.response.to.have.jsonSchema(schema);\n});",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := strings.Join(BuildResponseTests(tt.response), "\n"); got != tt.want {
+				t.Errorf("tests =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddResponseTests(t *testing.T) {
+	items := []PostmanItem{
+		{Name: "no response"},
+		{Name: "existing script", Event: []PostmanEvent{{Listen: "test", Script: PostmanScript{Exec: []string{"// kept"}}}}, Response: []PostmanResponse{{Code: 200}}},
+	}
+	if count := AddResponseTests(items); count != 1 {
+		t.Errorf("AddResponseTests = %d, want 1", count)
+	}
+	if items[0].Event != nil {
+		t.Errorf("item without a response got events %+v", items[0].Event)
+	}
+	want := append([]string{"// kept"}, BuildResponseTests(PostmanResponse{Code: 200})...)
+	if len(items[1].Event) != 1 || !reflect.DeepEqual(items[1].Event[0].Script.Exec, want) {
+		t.Errorf("events = %+v, want the tests appended to the existing script", items[1].Event)
+	}
+}
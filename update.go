@@ -0,0 +1,470 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+/*
+	###################################### UPDATING AN EXISTING COLLECTION #############################################
+*/
+
+// staleMarker starts the description line added to requests missing from the latest conversion
+const staleMarker = "[stale]"
+
+// MergeReport counts what happened to each request when merging into an existing collection
+type MergeReport struct {
+	Added    int
+	Replaced int
+	Kept     int
+	Stale    []string
+}
+
+// UnmarshalJSON accepts a URL written either as an object or as a plain string
+func (u *PostmanURL) UnmarshalJSON(data []byte) error {
+	var raw string
+	if err := json.Unmarshal(data, &raw); err == nil {
+		*u = URLFromString(raw)
+		return nil
+	}
+
+	type plainURL PostmanURL
+	var plain plainURL
+	if err := json.Unmarshal(data, &plain); err != nil {
+		return err
+	}
+	*u = PostmanURL(plain)
+	return nil
+}
+
+// UnmarshalJSON accepts a request written either as an object or as a plain URL string
+func (req *PostmanRequest) UnmarshalJSON(data []byte) error {
+	var raw string
+	if err := json.Unmarshal(data, &raw); err == nil {
+		*req = PostmanRequest{Method: "GET", Header: []PostmanHeader{}, URL: URLFromString(raw)}
+		return nil
+	}
+
+	type plainRequest PostmanRequest
+	var plain plainRequest
+	if err := json.Unmarshal(data, &plain); err != nil {
+		return err
+	}
+	*req = PostmanRequest(plain)
+	return nil
+}
+
+// UnmarshalJSON reads a collection, keeping its JSON so fields not modelled here survive an update
+func (collection *PostmanCollection) UnmarshalJSON(data []byte) error {
+	type plainCollection PostmanCollection
+	var plain struct {
+		plainCollection
+		Info json.RawMessage `json:"info"`
+	}
+	if err := json.Unmarshal(data, &plain); err != nil {
+		return err
+	}
+	*collection = PostmanCollection(plain.plainCollection)
+	if plain.Info != nil {
+		description, err := unmarshalDescribed(plain.Info, &collection.Info)
+		if err != nil {
+			return err
+		}
+		collection.Info.Description = description
+	}
+	collection.Original = append(json.RawMessage{}, data...)
+	return nil
+}
+
+// MarshalJSON writes a collection, with the fields of the collection it was loaded from that are not modelled here
+func (collection PostmanCollection) MarshalJSON() ([]byte, error) {
+	type plainCollection PostmanCollection
+	if collection.Original == nil {
+		return json.Marshal(plainCollection(collection))
+	}
+	var loaded PostmanCollection
+	if err := json.Unmarshal(collection.Original, &loaded); err != nil {
+		return nil, err
+	}
+	return marshalPreserving(collection.Original, func() ([]byte, error) { return json.Marshal(plainCollection(loaded)) }, func() ([]byte, error) { return json.Marshal(plainCollection(collection)) })
+}
+
+// UnmarshalJSON reads an item, keeping its JSON so fields not modelled here survive an update
+func (item *PostmanItem) UnmarshalJSON(data []byte) error {
+	type plainItem PostmanItem
+	var plain plainItem
+	description, err := unmarshalDescribed(data, &plain)
+	if err != nil {
+		return err
+	}
+	*item = PostmanItem(plain)
+	item.Description = description
+	item.Original = append(json.RawMessage{}, data...)
+	return nil
+}
+
+// UnmarshalJSON reads a variable whose description may be a string or a {content, type} object
+func (variable *PostmanVariable) UnmarshalJSON(data []byte) error {
+	type plainVariable PostmanVariable
+	var plain plainVariable
+	description, err := unmarshalDescribed(data, &plain)
+	if err != nil {
+		return err
+	}
+	*variable = PostmanVariable(plain)
+	variable.Description = description
+	return nil
+}
+
+// unmarshalDescribed decodes an object into v and returns the text of its description, which Postman writes
+// either as a string or as a {content, type} object. An object description is taken out before decoding, and
+// is written back as it was through Original while its text is unchanged.
+func unmarshalDescribed(data []byte, v interface{}) (string, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return "", json.Unmarshal(data, v)
+	}
+	raw := bytes.TrimSpace(fields["description"])
+	if len(raw) == 0 || raw[0] != '{' {
+		var description string
+		if len(raw) > 0 && !bytes.Equal(raw, []byte("null")) {
+			if err := json.Unmarshal(raw, &description); err != nil {
+				return "", fmt.Errorf("invalid description: %v", err)
+			}
+		}
+		return description, json.Unmarshal(data, v)
+	}
+
+	var object struct {
+		Content string `json:"content"`
+	}
+	if err := json.Unmarshal(raw, &object); err != nil {
+		return "", fmt.Errorf("invalid description: %v", err)
+	}
+	delete(fields, "description")
+	stripped, err := json.Marshal(fields)
+	if err != nil {
+		return "", err
+	}
+	return object.Content, json.Unmarshal(stripped, v)
+}
+
+// UnmarshalJSON reads a saved example, keeping its JSON so fields not modelled here survive an update
+func (response *PostmanResponse) UnmarshalJSON(data []byte) error {
+	type plainResponse PostmanResponse
+	var plain plainResponse
+	if err := json.Unmarshal(data, &plain); err != nil {
+		return err
+	}
+	*response = PostmanResponse(plain)
+	response.Original = append(json.RawMessage{}, data...)
+	return nil
+}
+
+// MarshalJSON writes a saved example, with the fields of the example it was loaded from that are not modelled here
+func (response PostmanResponse) MarshalJSON() ([]byte, error) {
+	type plainResponse PostmanResponse
+	if response.Original == nil {
+		return json.Marshal(plainResponse(response))
+	}
+	var loaded plainResponse
+	if err := json.Unmarshal(response.Original, &loaded); err != nil {
+		return nil, err
+	}
+	return marshalPreserving(response.Original, func() ([]byte, error) { return json.Marshal(loaded) }, func() ([]byte, error) { return json.Marshal(plainResponse(response)) })
+}
+
+// marshalPreserving writes a value loaded from original JSON, changing only the fields that differ from what was loaded
+//
+// loaded marshals the modelled fields as they were read and current as they are now. Fields this tool does not model,
+// such as events, scripts, IDs and other auth types, are never in either and so are written back untouched.
+func marshalPreserving(original json.RawMessage, loaded, current func() ([]byte, error)) ([]byte, error) {
+	before, err := loaded()
+	if err != nil {
+		return nil, err
+	}
+	after, err := current()
+	if err != nil {
+		return nil, err
+	}
+	return patchJSON(original, before, after)
+}
+
+// patchJSON applies the change from before to after onto original, recursing into objects and replacing anything else
+func patchJSON(original, before, after json.RawMessage) (json.RawMessage, error) {
+	if bytes.Equal(compactJSON(before), compactJSON(after)) {
+		return original, nil
+	}
+	originalFields, ok1 := objectFields(original)
+	beforeFields, ok2 := objectFields(before)
+	afterFields, ok3 := objectFields(after)
+	if !ok1 || !ok2 || !ok3 {
+		return after, nil
+	}
+
+	beforeValues := map[string]json.RawMessage{}
+	for _, field := range beforeFields {
+		beforeValues[field.key] = field.value
+	}
+	afterValues := map[string]json.RawMessage{}
+	for _, field := range afterFields {
+		afterValues[field.key] = field.value
+	}
+
+	// Fields keep their original order, with new ones after them
+	var out bytes.Buffer
+	out.WriteByte('{')
+	written := map[string]bool{}
+	write := func(key string, value json.RawMessage) {
+		if len(written) > 0 {
+			out.WriteByte(',')
+		}
+		name, _ := json.Marshal(key)
+		out.Write(name)
+		out.WriteByte(':')
+		out.Write(value)
+		written[key] = true
+	}
+	for _, field := range originalFields {
+		beforeValue, modelled := beforeValues[field.key]
+		afterValue, kept := afterValues[field.key]
+		switch {
+		case !modelled:
+			write(field.key, field.value)
+		case kept:
+			value, err := patchJSON(field.value, beforeValue, afterValue)
+			if err != nil {
+				return nil, err
+			}
+			write(field.key, value)
+		}
+	}
+	for _, field := range afterFields {
+		if containsField(originalFields, field.key) {
+			continue
+		}
+		// Defaults the original left out are only added once they change
+		if beforeValue, modelled := beforeValues[field.key]; !modelled || !bytes.Equal(compactJSON(beforeValue), compactJSON(field.value)) {
+			write(field.key, field.value)
+		}
+	}
+	out.WriteByte('}')
+	return out.Bytes(), nil
+}
+
+// jsonField is a member of a JSON object
+type jsonField struct {
+	key   string
+	value json.RawMessage
+}
+
+// objectFields splits a JSON object into its members in document order, or reports false if it is not an object
+func objectFields(data json.RawMessage) ([]jsonField, bool) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if token, err := decoder.Token(); err != nil || token != json.Delim('{') {
+		return nil, false
+	}
+	var fields []jsonField
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, false
+		}
+		key, _ := token.(string)
+		var value json.RawMessage
+		if err := decoder.Decode(&value); err != nil {
+			return nil, false
+		}
+		fields = append(fields, jsonField{key: key, value: value})
+	}
+	return fields, true
+}
+
+// containsField reports whether an object has a member
+func containsField(fields []jsonField, key string) bool {
+	for _, field := range fields {
+		if field.key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// compactJSON removes insignificant whitespace so JSON values can be compared
+func compactJSON(data json.RawMessage) []byte {
+	var out bytes.Buffer
+	if err := json.Compact(&out, data); err != nil {
+		return data
+	}
+	return out.Bytes()
+}
+
+// URLFromString splits a URL string such as {{baseUrl}}/users/:userId?page=2 into its Postman parts
+func URLFromString(raw string) PostmanURL {
+	u, err := ParseURL(raw)
+	if err != nil {
+		// URLs starting with a variable have no scheme
+		u, _ = ParseURL("none://" + raw)
+		u.Protocol = ""
+	}
+	u.Raw = raw
+
+	host, port := SplitHostPort(strings.Join(u.Host, "."))
+	if strings.Contains(host, "{{") {
+		u.Host = []string{host}
+	} else {
+		u.Host = strings.Split(host, ".")
+	}
+	u.Port = port
+	return u
+}
+
+// LoadCollection reads a Postman collection file
+func LoadCollection(fileName string) (PostmanCollection, error) {
+	var collection PostmanCollection
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return collection, err
+	}
+	if err := json.Unmarshal(data, &collection); err != nil {
+		return collection, fmt.Errorf("error parsing collection %s: %v", fileName, err)
+	}
+	return collection, nil
+}
+
+// MergeCollections merges a freshly converted collection into an existing one, matching requests by method and templated URL
+//
+// New requests are added to the folders they were converted into. Matched requests are left alone with
+// "keep", or have their request replaced with "replace" while their events, description and saved examples
+// are kept and any new examples appended; the correlation lines of their test script are replaced with the
+// converted ones, so variables the new request uses are still set. Existing requests missing from the conversion are marked stale in
+// their description. The existing collection info and variables win over the converted ones.
+func MergeCollections(existing, converted PostmanCollection, mode string) (PostmanCollection, MergeReport, error) {
+	var report MergeReport
+	if mode != "keep" && mode != "replace" {
+		return existing, report, fmt.Errorf("unknown update mode %q, expected keep or replace", mode)
+	}
+
+	matches := map[string][]*PostmanItem{}
+	WalkItems(existing.Item, func(_ []string, item *PostmanItem) {
+		key := TemplatedPath(item.Request)
+		matches[key] = append(matches[key], item)
+	})
+
+	// Match first, as adding items below may move the existing ones
+	type addition struct {
+		folders []string
+		item    PostmanItem
+	}
+	var additions []addition
+	seen := map[string]bool{}
+	WalkItems(converted.Item, func(folders []string, item *PostmanItem) {
+		key := TemplatedPath(item.Request)
+		targets, ok := matches[key]
+		if !ok {
+			additions = append(additions, addition{folders: append([]string{}, folders...), item: *item})
+			return
+		}
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+
+		target := targets[0]
+		if mode == "replace" {
+			target.Request = item.Request
+			target.Response = mergeExamples(target.Response, item.Response)
+			replaceCorrelationScripts(target, ItemCorrelationRules(*item))
+			report.Replaced++
+		} else {
+			report.Kept++
+		}
+	})
+
+	WalkItems(existing.Item, func(_ []string, item *PostmanItem) {
+		item.Description = removeStaleMarker(item.Description)
+		if !seen[TemplatedPath(item.Request)] {
+			item.Description = appendLine(item.Description, fmt.Sprintf("%s Not present in the conversion of %s", staleMarker, time.Now().Format("2006-01-02")))
+			report.Stale = append(report.Stale, strings.ToUpper(item.Request.Method)+" "+item.Request.URL.Raw)
+		}
+	})
+
+	for _, add := range additions {
+		existing.Item = insertIntoFolder(existing.Item, add.folders, add.item)
+		report.Added++
+	}
+
+	known := map[string]bool{}
+	for _, variable := range existing.Variable {
+		known[variable.Key] = true
+	}
+	for _, variable := range converted.Variable {
+		if !known[variable.Key] {
+			existing.Variable = append(existing.Variable, variable)
+			known[variable.Key] = true
+		}
+	}
+
+	existing.Info.Updated = converted.Info.Updated
+	return existing, report, nil
+}
+
+// replaceCorrelationScripts swaps the correlation lines of an item's test script for the given rules, keeping
+// every other line, and drops a test event left empty
+func replaceCorrelationScripts(item *PostmanItem, rules []CorrelationRule) {
+	var events []PostmanEvent
+	for _, event := range item.Event {
+		if event.Listen == "test" {
+			var exec []string
+			for _, line := range event.Script.Exec {
+				if _, ok := parseCorrelationScript(strings.TrimSpace(line)); !ok {
+					exec = append(exec, line)
+				}
+			}
+			if len(exec) == 0 {
+				continue
+			}
+			event.Script.Exec = exec
+		}
+		events = append(events, event)
+	}
+	item.Event = events
+
+	var lines []string
+	for _, rule := range rules {
+		lines = append(lines, rule.Script())
+	}
+	AddTestScript(item, lines)
+	item.Extract = rules
+}
+
+// mergeExamples appends the converted examples that are not already saved on the existing request
+func mergeExamples(existing, converted []PostmanResponse) []PostmanResponse {
+	seen := map[string]bool{}
+	for _, response := range existing {
+		seen[variantKey("", []PostmanResponse{response})] = true
+	}
+	for _, response := range converted {
+		key := variantKey("", []PostmanResponse{response})
+		if !seen[key] {
+			existing = append(existing, response)
+			seen[key] = true
+		}
+	}
+	return existing
+}
+
+// removeStaleMarker drops the stale line left by an earlier update from a description
+func removeStaleMarker(description string) string {
+	var lines []string
+	for _, line := range strings.Split(description, "\n") {
+		if !strings.HasPrefix(line, staleMarker) {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,253 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// existingCollection uses fields go2postman does not model, which an update must write back untouched
+const existingCollection = `{
+  "info": {"name": "API", "schema": "https://schema.getpostman.com/json/collection/v2.1.0/collection.json", "_postman_id": "c-1"},
+  "auth": {"type": "apikey", "apikey": [{"key": "key", "value": "X-Api-Key"}, {"key": "value", "value": "{{apiKey}}"}]},
+  "event": [{"listen": "prerequest", "script": {"id": "s-1", "type": "text/javascript", "exec": ["pm.variables.set('t', Date.now());"]}}],
+  "item": [
+    {
+      "id": "item-login",
+      "name": "POST login",
+      "protocolProfileBehavior": {"disableBodyPruning": true},
+      "request": {
+        "method": "POST",
+        "description": "Signs in",
+        "header": [],
+        "body": {"mode": "raw", "raw": "{\"user\":\"old\"}"},
+        "url": {"raw": "https://api.example.com/login#form", "protocol": "https", "host": ["api", "example", "com"], "path": ["login"], "hash": "form"}
+      },
+      "response": [
+        {"id": "resp-1", "name": "Signed in", "status": "OK", "code": 200, "header": [], "cookie": [{"name": "sid", "value": "1"}], "body": "{}"}
+      ]
+    },
+    {
+      "id": "item-search",
+      "name": "POST search",
+      "request": {
+        "method": "POST",
+        "header": [],
+        "auth": {"type": "oauth2", "oauth2": [{"key": "accessToken", "value": "{{token}}", "type": "string"}]},
+        "body": {"mode": "graphql", "graphql": {"query": "{ users { id } }", "variables": ""}},
+        "url": {
+          "raw": "https://api.example.com/search?q=a&debug=1",
+          "protocol": "https", "host": ["api", "example", "com"], "path": ["search"],
+          "query": [{"key": "q", "value": "a", "description": "Search term"}, {"key": "debug", "value": "1", "disabled": true}]
+        }
+      }
+    }
+  ]
+}`
+
+func TestMergeCollectionsKeepsUnmodelledFields(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "collection.json")
+	if err := os.WriteFile(fileName, []byte(existingCollection), 0644); err != nil {
+		t.Fatal(err)
+	}
+	existing, err := LoadCollection(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var converted PostmanCollection
+	converted.Item = []PostmanItem{{
+		Name: "POST login",
+		Request: PostmanRequest{
+			Method: "POST",
+			Header: []PostmanHeader{{Key: "Content-Type", Value: "application/json", Type: "text"}},
+			Body:   PostmanBody{Mode: "raw", Raw: `{"user":"new"}`},
+			URL:    URLFromString("https://api.example.com/login"),
+		},
+		Response: []PostmanResponse{{Name: "Signed in again", Status: "OK", Code: 200, Header: []PostmanHeader{}, Body: `{"ok":true}`}},
+	}}
+
+	merged, report, err := MergeCollections(existing, converted, "replace")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Replaced != 1 || len(report.Stale) != 1 {
+		t.Fatalf("report = %+v, want 1 replaced and 1 stale", report)
+	}
+
+	output, err := json.Marshal(merged)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var written struct {
+		Auth  map[string]interface{}   `json:"auth"`
+		Event []map[string]interface{} `json:"event"`
+		Item  []map[string]interface{} `json:"item"`
+	}
+	if err := json.Unmarshal(output, &written); err != nil {
+		t.Fatal(err)
+	}
+
+	if written.Auth["type"] != "apikey" || written.Auth["apikey"] == nil {
+		t.Errorf("collection auth = %v, want the apikey auth", written.Auth)
+	}
+	if len(written.Event) != 1 {
+		t.Errorf("collection events = %v, want the prerequest script", written.Event)
+	}
+	if len(written.Item) != 2 {
+		t.Fatalf("got %d items, want 2", len(written.Item))
+	}
+
+	login, search := written.Item[0], written.Item[1]
+	if login["id"] != "item-login" || login["protocolProfileBehavior"] == nil {
+		t.Errorf("login item = %v, want its id and protocolProfileBehavior", login)
+	}
+	request := login["request"].(map[string]interface{})
+	if request["description"] != "Signs in" {
+		t.Errorf("login request description = %v, want it kept", request["description"])
+	}
+	if body := request["body"].(map[string]interface{}); body["raw"] != `{"user":"new"}` {
+		t.Errorf("login body = %v, want the converted body", body["raw"])
+	}
+	if hash := request["url"].(map[string]interface{})["hash"]; hash != "form" {
+		t.Errorf("login url hash = %v, want it kept", hash)
+	}
+	responses := login["response"].([]interface{})
+	if len(responses) != 2 {
+		t.Fatalf("got %d login examples, want the saved and the converted one", len(responses))
+	}
+	if saved := responses[0].(map[string]interface{}); saved["id"] != "resp-1" || saved["cookie"] == nil {
+		t.Errorf("saved example = %v, want its id and cookies", saved)
+	}
+
+	if search["id"] != "item-search" {
+		t.Errorf("search item id = %v, want it kept", search["id"])
+	}
+	searchRequest := search["request"].(map[string]interface{})
+	if auth := searchRequest["auth"].(map[string]interface{}); auth["type"] != "oauth2" || auth["oauth2"] == nil {
+		t.Errorf("search auth = %v, want the oauth2 parameters", auth)
+	}
+	if body := searchRequest["body"].(map[string]interface{}); body["graphql"] == nil {
+		t.Errorf("search body = %v, want the graphql query", body)
+	}
+	query := searchRequest["url"].(map[string]interface{})["query"].([]interface{})
+	if first, second := query[0].(map[string]interface{}), query[1].(map[string]interface{}); first["description"] != "Search term" || second["disabled"] != true {
+		t.Errorf("search query = %v, want the description and disabled flag", query)
+	}
+}
+
+func TestMergeCollectionsReplacesCorrelationScripts(t *testing.T) {
+	existing := PostmanCollection{Item: []PostmanItem{
+		{
+			Name:    "POST login",
+			Request: PostmanRequest{Method: "POST", URL: URLFromString("https://api.example.com/login")},
+			Event: []PostmanEvent{{Listen: "test", Script: PostmanScript{Type: "text/javascript", Exec: []string{
+				`pm.test("Status code is 200", function () { pm.response.to.have.status(200); });`,
+				`pm.collectionVariables.set("sessionToken", pm.response.json()["session"]);`,
+			}}}},
+		},
+		{Name: "GET me", Request: PostmanRequest{Method: "GET", URL: URLFromString("https://api.example.com/me")}},
+	}}
+
+	login := PostmanItem{Name: "POST login", Request: PostmanRequest{Method: "POST", URL: URLFromString("https://api.example.com/login")}}
+	rule := CorrelationRule{Variable: "accessToken", Source: "json", Expression: "$.auth.access_token"}
+	login.Extract = []CorrelationRule{rule}
+	AddTestScript(&login, []string{rule.Script()})
+	me := PostmanItem{Name: "GET me", Request: PostmanRequest{
+		Method: "GET",
+		Header: []PostmanHeader{{Key: "Authorization", Value: "Bearer {{accessToken}}"}},
+		URL:    URLFromString("https://api.example.com/me"),
+	}}
+
+	merged, report, err := MergeCollections(existing, PostmanCollection{Item: []PostmanItem{login, me}}, "replace")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Replaced != 2 {
+		t.Fatalf("report = %+v, want 2 replaced", report)
+	}
+
+	exec := merged.Item[0].Event[0].Script.Exec
+	want := []string{
+		`pm.test("Status code is 200", function () { pm.response.to.have.status(200); });`,
+		rule.Script(),
+	}
+	if strings.Join(exec, "\n") != strings.Join(want, "\n") {
+		t.Errorf("login test script = %q, want %q", exec, want)
+	}
+	if rules := ItemCorrelationRules(merged.Item[0]); len(rules) != 1 || rules[0] != rule {
+		t.Errorf("login rules = %+v, want %+v", rules, rule)
+	}
+	if value := merged.Item[1].Request.Header[0].Value; value != "Bearer {{accessToken}}" {
+		t.Errorf("me Authorization = %q, want the correlated variable", value)
+	}
+
+	// Once saved and loaded again, the rule is recovered from the test script
+	output, err := json.Marshal(merged)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var reloaded PostmanCollection
+	if err := json.Unmarshal(output, &reloaded); err != nil {
+		t.Fatal(err)
+	}
+	if rules := ItemCorrelationRules(reloaded.Item[0]); len(rules) != 1 || rules[0] != rule {
+		t.Errorf("reloaded login rules = %+v, want %+v", rules, rule)
+	}
+}
+
+func TestLoadCollectionWithObjectDescriptions(t *testing.T) {
+	const described = `{
+  "info": {"name": "API", "description": {"content": "The **API**", "type": "text/markdown"}, "schema": "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"},
+  "variable": [{"key": "baseUrl", "value": "https://api.example.com", "description": {"content": "Origin", "type": "text/plain"}}],
+  "item": [
+    {"name": "GET users", "description": {"content": "Lists users", "type": "text/markdown"}, "request": {"method": "GET", "header": [], "url": "https://api.example.com/users"}},
+    {"name": "GET old", "description": {"content": "Removed endpoint", "type": "text/plain"}, "request": {"method": "GET", "header": [], "url": "https://api.example.com/old"}}
+  ]
+}`
+	fileName := filepath.Join(t.TempDir(), "collection.json")
+	if err := os.WriteFile(fileName, []byte(described), 0644); err != nil {
+		t.Fatal(err)
+	}
+	existing, err := LoadCollection(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if existing.Info.Description != "The **API**" || existing.Item[0].Description != "Lists users" || existing.Variable[0].Description != "Origin" {
+		t.Fatalf("descriptions = %q, %q, %q, want the object contents", existing.Info.Description, existing.Item[0].Description, existing.Variable[0].Description)
+	}
+
+	var converted PostmanCollection
+	converted.Item = []PostmanItem{{Name: "GET users", Request: PostmanRequest{Method: "GET", Header: []PostmanHeader{}, URL: URLFromString("https://api.example.com/users")}}}
+	merged, _, err := MergeCollections(existing, converted, "keep")
+	if err != nil {
+		t.Fatal(err)
+	}
+	output, err := json.Marshal(merged)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var written struct {
+		Info     map[string]interface{}   `json:"info"`
+		Variable []map[string]interface{} `json:"variable"`
+		Item     []map[string]interface{} `json:"item"`
+	}
+	if err := json.Unmarshal(output, &written); err != nil {
+		t.Fatal(err)
+	}
+
+	if description, ok := written.Info["description"].(map[string]interface{}); !ok || description["type"] != "text/markdown" {
+		t.Errorf("info description = %v, want the markdown object", written.Info["description"])
+	}
+	if description, ok := written.Variable[0]["description"].(map[string]interface{}); !ok || description["content"] != "Origin" {
+		t.Errorf("variable description = %v, want the object", written.Variable[0]["description"])
+	}
+	if description, ok := written.Item[0]["description"].(map[string]interface{}); !ok || description["content"] != "Lists users" {
+		t.Errorf("kept item description = %v, want the object", written.Item[0]["description"])
+	}
+	if description, ok := written.Item[1]["description"].(string); !ok || !strings.HasPrefix(description, "Removed endpoint\n"+staleMarker) {
+		t.Errorf("stale item description = %v, want the text with the stale marker", written.Item[1]["description"])
+	}
+}